@@ -4,11 +4,16 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/RashadTanjim/enterprise-microservice-system/common/auth"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/bulkhead"
 	"github.com/RashadTanjim/enterprise-microservice-system/common/logger"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/retry"
 
 	"go.uber.org/zap"
 )
@@ -18,11 +23,22 @@ type Config struct {
 	Enabled bool
 	BaseURL string
 	Timeout time.Duration
+	// TokenProvider, when set, supplies a service token for events tracked
+	// without a caller bearer token (for example API key requests).
+	TokenProvider func() (string, error)
+	// Retry configures retries. Events are posted, which is not idempotent,
+	// so only connection failures are retried. Zero MaxAttempts disables it.
+	Retry retry.Config
+	// Bulkhead caps concurrent posts so a slow audit-log-service cannot hold
+	// up request goroutines. Zero MaxConcurrent disables it.
+	Bulkhead bulkhead.Config
 }
 
-// Event represents an audit log event to record.
+// Event represents an audit log event to record. ActedBy names the service or
+// client that acted on behalf of Actor with a delegated token.
 type Event struct {
 	Actor        string `json:"actor"`
+	ActedBy      string `json:"acted_by,omitempty"`
 	Action       string `json:"action"`
 	ResourceType string `json:"resource_type"`
 	ResourceID   string `json:"resource_id"`
@@ -32,10 +48,13 @@ type Event struct {
 
 // Client sends audit log events to the audit-log-service.
 type Client struct {
-	enabled bool
-	baseURL string
-	client  *http.Client
-	logger  *logger.Logger
+	enabled       bool
+	baseURL       string
+	client        *http.Client
+	tokenProvider func() (string, error)
+	retrier       *retry.Retrier
+	bulkhead      *bulkhead.Bulkhead
+	logger        *logger.Logger
 }
 
 // NewClient creates a new audit log client.
@@ -51,18 +70,39 @@ func NewClient(cfg Config, log *logger.Logger) *Client {
 		client: &http.Client{
 			Timeout: timeout,
 		},
-		logger: log,
+		tokenProvider: cfg.TokenProvider,
+		retrier:       retry.New(cfg.Retry),
+		bulkhead:      bulkhead.New("audit-log-service", cfg.Bulkhead),
+		logger:        log,
 	}
 }
 
-// Track sends the event to audit-log-service using the provided bearer token.
+// Track sends the event to audit-log-service using the provided bearer token,
+// falling back to the configured TokenProvider when it is empty. When the
+// request was made with a delegated token and Actor is its subject or
+// principal, Actor is recorded as the subject and ActedBy as the actor chain.
 // This is best-effort and does not return errors to callers.
 func (c *Client) Track(ctx context.Context, event Event, bearerToken string) {
 	if c == nil || !c.enabled || c.baseURL == "" {
 		return
 	}
 
+	if claims, ok := auth.ClaimsFromContext(ctx); ok && claims.Act != nil && event.ActedBy == "" {
+		if event.Actor == claims.Subject || event.Actor == claims.Principal() {
+			event.Actor = claims.Subject
+			event.ActedBy = claims.Act.String()
+		}
+	}
+
 	token := normalizeBearerToken(bearerToken)
+	if token == "" && c.tokenProvider != nil {
+		serviceToken, err := c.tokenProvider()
+		if err != nil {
+			c.warn("failed to generate audit service token", err)
+			return
+		}
+		token = serviceToken
+	}
 	if token == "" {
 		return
 	}
@@ -73,10 +113,26 @@ func (c *Client) Track(ctx context.Context, event Event, bearerToken string) {
 		return
 	}
 
+	err = c.retrier.Do(ctx, http.MethodPost, func(ctx context.Context) error {
+		_, err := c.bulkhead.Execute(ctx, func() (interface{}, error) {
+			return nil, c.send(ctx, payload, token)
+		})
+		return err
+	})
+
+	var statusErr *retry.StatusError
+	switch {
+	case errors.As(err, &statusErr):
+		c.warn("audit log request returned non-2xx status", nil, zap.Int("status", statusErr.StatusCode))
+	case err != nil:
+		c.warn("failed to send audit log event", err)
+	}
+}
+
+func (c *Client) send(ctx context.Context, payload []byte, token string) error {
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/v1/audit-logs", bytes.NewReader(payload))
 	if err != nil {
-		c.warn("failed to create audit log request", err)
-		return
+		return fmt.Errorf("create audit log request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -84,14 +140,14 @@ func (c *Client) Track(ctx context.Context, event Event, bearerToken string) {
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		c.warn("failed to send audit log event", err)
-		return
+		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
-		c.warn("audit log request returned non-2xx status", nil, zap.Int("status", resp.StatusCode))
+		return retry.NewStatusError(resp)
 	}
+	return nil
 }
 
 func normalizeBearerToken(value string) string {
@@ -0,0 +1,222 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// APIKeyHeader is the request header carrying an API key.
+const APIKeyHeader = "X-API-Key"
+
+// API keys look like "emk_<key id>_<secret>". The "emk_<key id>" part is the
+// public key ID used for lookups; only a SHA-256 hash of the secret is stored.
+const (
+	apiKeyScheme    = "emk"
+	apiKeyIDBytes   = 8
+	apiKeySecretLen = 32
+)
+
+// ErrInvalidAPIKey is returned for malformed, unknown, expired or revoked keys.
+var ErrInvalidAPIKey = errors.New("invalid api key")
+
+// APIKeyPrincipal is the identity an API key authenticates as. IssuedAt is
+// when the key was created.
+type APIKeyPrincipal struct {
+	KeyID     string     `json:"key_id"`
+	Subject   string     `json:"subject"`
+	Roles     []string   `json:"roles"`
+	IssuedAt  time.Time  `json:"issued_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// Claims converts the principal into token-equivalent claims. The jti is the key
+// ID so a key can be revoked through the RevocationList; iat is the key's
+// creation time, so revoking the owner's tokens also rejects keys created
+// before the revocation, until that revocation entry expires. Disabling or
+// deleting the owner is enforced by the verifier, not here. A principal
+// without IssuedAt gets no iat and is rejected by any revocation of its owner.
+func (p *APIKeyPrincipal) Claims() *Claims {
+	claims := &Claims{
+		Roles: p.Roles,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:      p.KeyID,
+			Subject: p.Subject,
+		},
+	}
+	if !p.IssuedAt.IsZero() {
+		claims.IssuedAt = jwt.NewNumericDate(p.IssuedAt)
+	}
+	if p.ExpiresAt != nil {
+		claims.ExpiresAt = jwt.NewNumericDate(*p.ExpiresAt)
+	}
+	return claims
+}
+
+// APIKeyVerifier resolves an API key to the principal it authenticates.
+type APIKeyVerifier interface {
+	VerifyAPIKey(ctx context.Context, key string) (*APIKeyPrincipal, error)
+}
+
+// GenerateAPIKey creates a new key, returning its public key ID, the full key
+// to hand to the owner once, and the hash of its secret for storage.
+func GenerateAPIKey() (keyID, key, secretHash string, err error) {
+	id := make([]byte, apiKeyIDBytes)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", "", err
+	}
+	secret := make([]byte, apiKeySecretLen)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", "", err
+	}
+
+	keyID = apiKeyScheme + "_" + hex.EncodeToString(id)
+	secretValue := base64.RawURLEncoding.EncodeToString(secret)
+	return keyID, keyID + "_" + secretValue, HashAPIKeySecret(secretValue), nil
+}
+
+// ParseAPIKey splits a key into its public key ID and secret.
+func ParseAPIKey(key string) (keyID, secret string, err error) {
+	parts := strings.SplitN(strings.TrimSpace(key), "_", 3)
+	if len(parts) != 3 || parts[0] != apiKeyScheme || len(parts[1]) != apiKeyIDBytes*2 || parts[2] == "" {
+		return "", "", ErrInvalidAPIKey
+	}
+	if _, err := hex.DecodeString(parts[1]); err != nil {
+		return "", "", ErrInvalidAPIKey
+	}
+	return parts[0] + "_" + parts[1], parts[2], nil
+}
+
+// HashAPIKeySecret returns the hex SHA-256 of a key secret. Secrets are random
+// 256-bit values, so a fast hash is sufficient.
+func HashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// RemoteAPIKeyVerifier verifies API keys against the user service's verify
+// endpoint. Results are cached in memory for cacheTTL, so a revoked key stops
+// working within that window unless it is also on the RevocationList.
+type RemoteAPIKeyVerifier struct {
+	url      string
+	client   *http.Client
+	cacheTTL time.Duration
+
+	mu      sync.Mutex
+	entries map[string]apiKeyCacheEntry
+}
+
+type apiKeyCacheEntry struct {
+	principal *APIKeyPrincipal
+	expiresAt time.Time
+}
+
+// NewRemoteAPIKeyVerifier creates a verifier backed by the endpoint at url.
+func NewRemoteAPIKeyVerifier(url string, cacheTTL time.Duration) *RemoteAPIKeyVerifier {
+	if cacheTTL <= 0 {
+		cacheTTL = 30 * time.Second
+	}
+
+	return &RemoteAPIKeyVerifier{
+		url:      url,
+		client:   &http.Client{Timeout: 5 * time.Second},
+		cacheTTL: cacheTTL,
+		entries:  map[string]apiKeyCacheEntry{},
+	}
+}
+
+// VerifyAPIKey implements APIKeyVerifier. Unknown keys are cached as misses too,
+// so repeated bad keys do not reach the user service on every request.
+func (v *RemoteAPIKeyVerifier) VerifyAPIKey(ctx context.Context, key string) (*APIKeyPrincipal, error) {
+	if _, _, err := ParseAPIKey(key); err != nil {
+		return nil, err
+	}
+
+	cacheKey := HashAPIKeySecret(key)
+	now := time.Now()
+
+	v.mu.Lock()
+	entry, ok := v.entries[cacheKey]
+	v.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		if entry.principal == nil {
+			return nil, ErrInvalidAPIKey
+		}
+		return entry.principal, nil
+	}
+
+	principal, err := v.fetch(ctx, key)
+	if err != nil && !errors.Is(err, ErrInvalidAPIKey) {
+		return nil, err
+	}
+
+	expiresAt := now.Add(v.cacheTTL)
+	if principal != nil && principal.ExpiresAt != nil && principal.ExpiresAt.Before(expiresAt) {
+		expiresAt = *principal.ExpiresAt
+	}
+
+	v.mu.Lock()
+	for k, e := range v.entries {
+		if !now.Before(e.expiresAt) {
+			delete(v.entries, k)
+		}
+	}
+	v.entries[cacheKey] = apiKeyCacheEntry{principal: principal, expiresAt: expiresAt}
+	v.mu.Unlock()
+
+	if principal == nil {
+		return nil, ErrInvalidAPIKey
+	}
+	return principal, nil
+}
+
+func (v *RemoteAPIKeyVerifier) fetch(ctx context.Context, key string) (*APIKeyPrincipal, error) {
+	payload, err := json.Marshal(map[string]string{"api_key": key})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("verify api key: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusUnauthorized:
+		return nil, ErrInvalidAPIKey
+	default:
+		return nil, fmt.Errorf("verify api key: unexpected status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Data APIKeyPrincipal `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decode api key verification: %w", err)
+	}
+	if body.Data.Subject == "" {
+		return nil, ErrInvalidAPIKey
+	}
+	return &body.Data, nil
+}
@@ -5,28 +5,87 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 // Config defines JWT configuration used for signing and validation.
+// Tokens are signed with the active Keyring key (RS256/ES256) when a keyring is
+// set, otherwise with Secret (HS256). Verification accepts asymmetric tokens
+// when KeySet (or Keyring) can resolve their kid; HS256 is only accepted when
+// neither is set, so a service holding the shared secret cannot mint tokens
+// once asymmetric signing is enabled.
 type Config struct {
 	Secret   string
 	Issuer   string
 	Audience string
 	TokenTTL time.Duration
+	Keyring  *Keyring
+	KeySet   KeySet
+	// Revocations, when set, is consulted by AuthMiddleware to reject revoked tokens.
+	Revocations RevocationChecker
+	// Permissions maps token roles to permissions for RequirePermission; nil uses DefaultRoleGrants.
+	Permissions *RolePermissions
+	// APIKeys, when set, lets AuthMiddleware authenticate X-API-Key requests.
+	APIKeys APIKeyVerifier
+	// MFARequiredRoles lists roles whose grants only apply to MFA-verified tokens.
+	MFARequiredRoles []string
 }
 
-// Claims represents JWT claims with roles.
+// Authentication method references (RFC 8176) and assurance levels carried in
+// the amr and acr claims. Client secrets count as passwords.
+const (
+	AMRPassword = "pwd"
+	AMROTP      = "otp"
+	AMRMFA      = "mfa"
+
+	ACRSingleFactor = "aal1"
+	ACRMultiFactor  = "aal2"
+)
+
+// Claims represents JWT claims with roles. The OpenID Connect claims are only
+// set on ID tokens (see GenerateIDToken); Act is only set on delegated tokens
+// (see GenerateDelegatedToken).
 type Claims struct {
 	Roles []string `json:"roles,omitempty"`
+	AMR   []string `json:"amr,omitempty"`
+	ACR   string   `json:"acr,omitempty"`
+	Act   *Actor   `json:"act,omitempty"`
+
+	Email           string           `json:"email,omitempty"`
+	EmailVerified   *bool            `json:"email_verified,omitempty"`
+	Name            string           `json:"name,omitempty"`
+	Nonce           string           `json:"nonce,omitempty"`
+	AuthTime        *jwt.NumericDate `json:"auth_time,omitempty"`
+	AuthorizedParty string           `json:"azp,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// MFAVerified reports whether the token was issued after multi-factor authentication.
+func (c *Claims) MFAVerified() bool {
+	for _, method := range c.AMR {
+		if method == AMRMFA {
+			return true
+		}
+	}
+	return false
+}
+
 // GenerateToken creates a signed JWT for the provided subject and roles.
 func GenerateToken(cfg Config, subject string, roles []string) (string, error) {
-	if cfg.Secret == "" {
+	return GenerateTokenWithAMR(cfg, subject, roles, nil)
+}
+
+// GenerateTokenWithAMR creates a signed JWT recording how the subject
+// authenticated. The acr claim is derived from amr.
+func GenerateTokenWithAMR(cfg Config, subject string, roles []string, amr []string) (string, error) {
+	if cfg.Keyring == nil && cfg.Secret == "" {
 		return "", errors.New("auth secret is empty")
 	}
 
+	return signClaims(cfg, newClaims(cfg, subject, roles, amr))
+}
+
+func newClaims(cfg Config, subject string, roles []string, amr []string) Claims {
 	issuedAt := time.Now().UTC()
 	expiresAt := issuedAt.Add(cfg.TokenTTL)
 
@@ -35,9 +94,12 @@ func GenerateToken(cfg Config, subject string, roles []string) (string, error) {
 		audience = jwt.ClaimStrings{cfg.Audience}
 	}
 
-	claims := Claims{
+	return Claims{
 		Roles: roles,
+		AMR:   amr,
+		ACR:   acrFor(amr),
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
 			Issuer:    cfg.Issuer,
 			Subject:   subject,
 			Audience:  audience,
@@ -46,18 +108,48 @@ func GenerateToken(cfg Config, subject string, roles []string) (string, error) {
 			NotBefore: jwt.NewNumericDate(issuedAt),
 		},
 	}
+}
+
+func acrFor(amr []string) string {
+	if len(amr) == 0 {
+		return ""
+	}
+	if (&Claims{AMR: amr}).MFAVerified() {
+		return ACRMultiFactor
+	}
+	return ACRSingleFactor
+}
+
+func signClaims(cfg Config, claims jwt.Claims) (string, error) {
+	if cfg.Keyring == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		return token.SignedString([]byte(cfg.Secret))
+	}
+
+	key := cfg.Keyring.Active()
+	alg, err := key.Algorithm()
+	if err != nil {
+		return "", err
+	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(cfg.Secret))
+	token := jwt.NewWithClaims(jwt.GetSigningMethod(alg), claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.PrivateKey)
 }
 
 // ParseToken validates and parses a JWT into claims.
 func ParseToken(cfg Config, tokenString string) (*Claims, error) {
-	if cfg.Secret == "" {
+	keySet := cfg.verificationKeys()
+	if keySet == nil && cfg.Secret == "" {
 		return nil, errors.New("auth secret is empty")
 	}
 
-	options := []jwt.ParserOption{jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()})}
+	methods := []string{jwt.SigningMethodHS256.Alg()}
+	if keySet != nil {
+		methods = []string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg()}
+	}
+
+	options := []jwt.ParserOption{jwt.WithValidMethods(methods)}
 	if cfg.Issuer != "" {
 		options = append(options, jwt.WithIssuer(cfg.Issuer))
 	}
@@ -67,7 +159,15 @@ func ParseToken(cfg Config, tokenString string) (*Claims, error) {
 
 	parser := jwt.NewParser(options...)
 	parsedToken, err := parser.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(cfg.Secret), nil
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+			return []byte(cfg.Secret), nil
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, errors.New("token is missing kid header")
+		}
+		return keySet.PublicKey(kid)
 	})
 	if err != nil {
 		return nil, err
@@ -80,3 +180,13 @@ func ParseToken(cfg Config, tokenString string) (*Claims, error) {
 
 	return claims, nil
 }
+
+func (cfg Config) verificationKeys() KeySet {
+	if cfg.KeySet != nil {
+		return cfg.KeySet
+	}
+	if cfg.Keyring != nil {
+		return cfg.Keyring
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrKeyNotFound is returned when no verification key matches a token kid.
+var ErrKeyNotFound = errors.New("signing key not found")
+
+// KeySet resolves public verification keys by key ID.
+type KeySet interface {
+	PublicKey(kid string) (crypto.PublicKey, error)
+}
+
+// SigningKey is an asymmetric private key identified by a key ID.
+type SigningKey struct {
+	ID         string
+	PrivateKey crypto.Signer
+}
+
+// Algorithm returns the JWT algorithm that matches the key type.
+func (k SigningKey) Algorithm() (string, error) {
+	switch key := k.PrivateKey.(type) {
+	case *rsa.PrivateKey:
+		return jwt.SigningMethodRS256.Alg(), nil
+	case *ecdsa.PrivateKey:
+		if key.Curve != elliptic.P256() {
+			return "", fmt.Errorf("unsupported ECDSA curve %s", key.Curve.Params().Name)
+		}
+		return jwt.SigningMethodES256.Alg(), nil
+	default:
+		return "", fmt.Errorf("unsupported signing key type %T", k.PrivateKey)
+	}
+}
+
+// Keyring holds the asymmetric keys a token issuer signs with. The active key
+// signs new tokens while the remaining keys stay valid for verification, which
+// allows keys to be rotated without invalidating tokens already issued.
+type Keyring struct {
+	mu     sync.RWMutex
+	keys   map[string]SigningKey
+	active string
+}
+
+// NewKeyring creates a keyring with the provided keys. The key matching
+// activeID signs new tokens; when activeID is empty the first key is used.
+func NewKeyring(activeID string, keys ...SigningKey) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("keyring requires at least one key")
+	}
+
+	ring := &Keyring{keys: make(map[string]SigningKey, len(keys))}
+	for _, key := range keys {
+		if err := ring.Add(key); err != nil {
+			return nil, err
+		}
+	}
+
+	if activeID == "" {
+		activeID = keys[0].ID
+	}
+	if err := ring.SetActive(activeID); err != nil {
+		return nil, err
+	}
+
+	return ring, nil
+}
+
+// LoadKeyring reads PEM-encoded private keys from files keyed by key ID.
+func LoadKeyring(activeID string, files map[string]string) (*Keyring, error) {
+	if len(files) == 0 {
+		return nil, errors.New("no signing key files configured")
+	}
+
+	ids := make([]string, 0, len(files))
+	for id := range files {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	keys := make([]SigningKey, 0, len(ids))
+	for _, id := range ids {
+		data, err := os.ReadFile(files[id])
+		if err != nil {
+			return nil, fmt.Errorf("read signing key %s: %w", id, err)
+		}
+
+		signer, err := ParsePrivateKeyPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("parse signing key %s: %w", id, err)
+		}
+		keys = append(keys, SigningKey{ID: id, PrivateKey: signer})
+	}
+
+	return NewKeyring(activeID, keys...)
+}
+
+// Add registers a key with the keyring without making it active.
+func (r *Keyring) Add(key SigningKey) error {
+	if key.ID == "" {
+		return errors.New("signing key id is empty")
+	}
+	if _, err := key.Algorithm(); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.keys[key.ID] = key
+	return nil
+}
+
+// Remove drops a retired key. The active key cannot be removed.
+func (r *Keyring) Remove(kid string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if kid == r.active {
+		return errors.New("cannot remove the active signing key")
+	}
+	delete(r.keys, kid)
+	return nil
+}
+
+// SetActive switches the key used to sign new tokens.
+func (r *Keyring) SetActive(kid string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.keys[kid]; !ok {
+		return fmt.Errorf("%w: %s", ErrKeyNotFound, kid)
+	}
+	r.active = kid
+	return nil
+}
+
+// Active returns the key currently used for signing.
+func (r *Keyring) Active() SigningKey {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.keys[r.active]
+}
+
+// PublicKey implements KeySet using the keyring's own keys.
+func (r *Keyring) PublicKey(kid string) (crypto.PublicKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	key, ok := r.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, kid)
+	}
+	return key.PrivateKey.Public(), nil
+}
+
+// JWKS returns the public half of every key for publication.
+func (r *Keyring) JWKS() (JWKS, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := make([]string, 0, len(r.keys))
+	for id := range r.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	set := JWKS{Keys: make([]JWK, 0, len(ids))}
+	for _, id := range ids {
+		jwk, err := NewJWK(id, r.keys[id].PrivateKey.Public())
+		if err != nil {
+			return JWKS{}, err
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return set, nil
+}
+
+// ParsePrivateKeyPEM decodes an RSA or ECDSA private key in PKCS#1, SEC 1 or PKCS#8 form.
+func ParsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type %T", key)
+		}
+		return signer, nil
+	default:
+		return nil, fmt.Errorf("unsupported PEM block type %q", block.Type)
+	}
+}
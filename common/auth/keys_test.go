@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+	"time"
+)
+
+func newTestKeyring(t *testing.T) *Keyring {
+	t.Helper()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate RSA key: %v", err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate EC key: %v", err)
+	}
+
+	ring, err := NewKeyring("rsa-1",
+		SigningKey{ID: "rsa-1", PrivateKey: rsaKey},
+		SigningKey{ID: "ec-1", PrivateKey: ecKey},
+	)
+	if err != nil {
+		t.Fatalf("failed to create keyring: %v", err)
+	}
+	return ring
+}
+
+func TestKeyringRotation(t *testing.T) {
+	ring := newTestKeyring(t)
+	cfg := Config{Issuer: "test-issuer", Audience: "test-audience", TokenTTL: time.Minute, Keyring: ring}
+
+	oldToken, err := GenerateToken(cfg, "client", []string{"admin"})
+	if err != nil {
+		t.Fatalf("failed to sign with RSA key: %v", err)
+	}
+
+	if err := ring.SetActive("ec-1"); err != nil {
+		t.Fatalf("failed to rotate key: %v", err)
+	}
+
+	newToken, err := GenerateToken(cfg, "client", []string{"admin"})
+	if err != nil {
+		t.Fatalf("failed to sign with EC key: %v", err)
+	}
+
+	for name, token := range map[string]string{"previous key": oldToken, "active key": newToken} {
+		claims, err := ParseToken(cfg, token)
+		if err != nil {
+			t.Fatalf("%s: expected token to verify, got %v", name, err)
+		}
+		if claims.Subject != "client" {
+			t.Fatalf("%s: expected subject client, got %s", name, claims.Subject)
+		}
+	}
+
+	if err := ring.Remove("rsa-1"); err != nil {
+		t.Fatalf("failed to retire key: %v", err)
+	}
+	if _, err := ParseToken(cfg, oldToken); err == nil {
+		t.Fatal("expected token signed by retired key to be rejected")
+	}
+}
+
+func TestJWKSRoundTrip(t *testing.T) {
+	ring := newTestKeyring(t)
+
+	set, err := ring.JWKS()
+	if err != nil {
+		t.Fatalf("failed to build JWKS: %v", err)
+	}
+	if len(set.Keys) != 2 {
+		t.Fatalf("expected 2 keys, got %d", len(set.Keys))
+	}
+
+	for _, jwk := range set.Keys {
+		decoded, err := jwk.PublicKey()
+		if err != nil {
+			t.Fatalf("failed to decode %s: %v", jwk.Kid, err)
+		}
+		expected, _ := ring.PublicKey(jwk.Kid)
+		equal, ok := expected.(interface{ Equal(crypto.PublicKey) bool })
+		if !ok || !equal.Equal(decoded) {
+			t.Fatalf("decoded key %s does not match original", jwk.Kid)
+		}
+	}
+}
+
+func TestParseTokenRejectsHS256WithoutSecret(t *testing.T) {
+	ring := newTestKeyring(t)
+
+	hmacToken, err := GenerateToken(Config{Secret: "shared", TokenTTL: time.Minute}, "client", nil)
+	if err != nil {
+		t.Fatalf("failed to sign HS256 token: %v", err)
+	}
+
+	if _, err := ParseToken(Config{KeySet: ring}, hmacToken); err == nil {
+		t.Fatal("expected HS256 token to be rejected when only a key set is configured")
+	}
+}
+
+func TestParseTokenRejectsHS256WhenKeysAreSet(t *testing.T) {
+	ring := newTestKeyring(t)
+	cfg := Config{Secret: "shared", TokenTTL: time.Minute, Keyring: ring}
+
+	hmacToken, err := GenerateToken(Config{Secret: "shared", TokenTTL: time.Minute}, "client", nil)
+	if err != nil {
+		t.Fatalf("failed to sign HS256 token: %v", err)
+	}
+
+	if _, err := ParseToken(cfg, hmacToken); err == nil {
+		t.Fatal("expected HS256 token to be rejected once asymmetric keys are configured")
+	}
+	if _, err := ParseToken(Config{Secret: "shared", KeySet: ring}, hmacToken); err == nil {
+		t.Fatal("expected HS256 token to be rejected by a verifier with a key set")
+	}
+}
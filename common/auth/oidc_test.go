@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGenerateIDToken(t *testing.T) {
+	ring := newTestKeyring(t)
+	cfg := Config{Issuer: "https://id.example.com", Audience: "api", TokenTTL: time.Minute, Keyring: ring}
+
+	authTime := time.Now().Add(-time.Minute).Truncate(time.Second)
+	token, err := GenerateIDToken(cfg, IDToken{
+		Subject:       "42",
+		ClientID:      "grafana",
+		Nonce:         "n-0S6_WzA2Mj",
+		AuthTime:      authTime,
+		AMR:           []string{AMRPassword},
+		Email:         "jane@example.com",
+		EmailVerified: true,
+		Name:          "Jane",
+	})
+	if err != nil {
+		t.Fatalf("failed to generate id token: %v", err)
+	}
+
+	if _, err := ParseToken(cfg, token); err == nil {
+		t.Fatal("expected the id token to be rejected as an access token")
+	}
+
+	clientCfg := cfg
+	clientCfg.Audience = "grafana"
+	claims, err := ParseToken(clientCfg, token)
+	if err != nil {
+		t.Fatalf("failed to parse id token: %v", err)
+	}
+	if claims.Subject != "42" || claims.Nonce != "n-0S6_WzA2Mj" || claims.AuthorizedParty != "grafana" {
+		t.Fatalf("unexpected id token claims: %+v", claims)
+	}
+	if claims.Email != "jane@example.com" || claims.EmailVerified == nil || !*claims.EmailVerified || claims.Name != "Jane" {
+		t.Fatalf("expected profile claims, got %+v", claims)
+	}
+	if claims.AuthTime == nil || !claims.AuthTime.Time.Equal(authTime) {
+		t.Fatalf("expected auth_time %v, got %v", authTime, claims.AuthTime)
+	}
+	if claims.ACR != ACRSingleFactor {
+		t.Fatalf("expected acr %q, got %q", ACRSingleFactor, claims.ACR)
+	}
+
+	alg, err := cfg.SigningAlgorithm()
+	if err != nil || alg != "RS256" {
+		t.Fatalf("expected RS256, got %q (%v)", alg, err)
+	}
+}
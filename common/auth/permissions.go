@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Permissions checked by the service routers. A permission is "<resource>:<action>";
+// grants may use "*" for everything or "<resource>:*" for every action on a resource.
+const (
+	PermUsersRead   = "users:read"
+	PermUsersList   = "users:list"
+	PermUsersWrite  = "users:write"
+	PermUsersDelete = "users:delete"
+
+	PermAuthRevoke  = "auth:revoke"
+	PermAuthClients = "auth:clients"
+	PermAuthAPIKeys = "auth:api_keys"
+	// PermAuthImpersonate allows issuing tokens that act as another user.
+	PermAuthImpersonate = "auth:impersonate"
+
+	PermOrdersCreate = "orders:create"
+	PermOrdersRead   = "orders:read"
+	PermOrdersUpdate = "orders:update"
+	PermOrdersDelete = "orders:delete"
+	// PermOrdersAll lifts the ownership scope so every user's orders are visible.
+	PermOrdersAll = "orders:all"
+
+	PermAuditCreate = "audit:create"
+	PermAuditRead   = "audit:read"
+	PermAuditUpdate = "audit:update"
+	PermAuditDelete = "audit:delete"
+
+	// PermBreakersRead and PermBreakersWrite allow listing and forcing
+	// circuit breakers through the admin API.
+	PermBreakersRead  = "breakers:read"
+	PermBreakersWrite = "breakers:write"
+)
+
+// DefaultRoleGrants reproduces the built-in admin, user and service roles.
+func DefaultRoleGrants() map[string][]string {
+	return map[string][]string{
+		"admin":   {"*"},
+		"user":    {PermOrdersCreate, PermOrdersRead, PermAuditCreate, PermAuditRead},
+		"service": {PermUsersRead, PermAuditCreate},
+	}
+}
+
+// RolePermissions maps roles to the permissions they grant.
+type RolePermissions struct {
+	grants map[string][]string
+}
+
+// NewRolePermissions creates a role to permission mapping.
+func NewRolePermissions(grants map[string][]string) *RolePermissions {
+	normalized := make(map[string][]string, len(grants))
+	for role, permissions := range grants {
+		role = strings.TrimSpace(role)
+		if role == "" {
+			continue
+		}
+		for _, permission := range permissions {
+			if permission = strings.TrimSpace(permission); permission != "" {
+				normalized[role] = append(normalized[role], permission)
+			}
+		}
+	}
+	return &RolePermissions{grants: normalized}
+}
+
+// LoadRolePermissions reads a JSON file of the form {"roles": {"support": ["orders:read"]}}.
+// An empty path returns the default grants.
+func LoadRolePermissions(path string) (*RolePermissions, error) {
+	if path == "" {
+		return NewRolePermissions(DefaultRoleGrants()), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read permissions file: %w", err)
+	}
+
+	var file struct {
+		Roles map[string][]string `json:"roles"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse permissions file %s: %w", path, err)
+	}
+	if len(file.Roles) == 0 {
+		return nil, fmt.Errorf("permissions file %s defines no roles", path)
+	}
+
+	return NewRolePermissions(file.Roles), nil
+}
+
+// Resolve returns the sorted, de-duplicated grants for a set of roles.
+func (p *RolePermissions) Resolve(roles []string) []string {
+	if p == nil {
+		return nil
+	}
+
+	seen := map[string]struct{}{}
+	for _, role := range roles {
+		for _, permission := range p.grants[role] {
+			seen[permission] = struct{}{}
+		}
+	}
+
+	result := make([]string, 0, len(seen))
+	for permission := range seen {
+		result = append(result, permission)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Roles returns the configured role names.
+func (p *RolePermissions) Roles() []string {
+	if p == nil {
+		return nil
+	}
+	roles := make([]string, 0, len(p.grants))
+	for role := range p.grants {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// Grants reports whether any of the granted permissions covers the required one.
+func Grants(granted []string, required string) bool {
+	resource, _, _ := strings.Cut(required, ":")
+	for _, permission := range granted {
+		switch permission {
+		case "*", required, resource + ":*":
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,223 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/RashadTanjim/enterprise-microservice-system/common/cache"
+)
+
+// RevocationChecker reports whether a parsed token has been revoked.
+type RevocationChecker interface {
+	IsRevoked(ctx context.Context, claims *Claims) (bool, error)
+}
+
+// Revocation check results reported to the observer.
+const (
+	RevocationResultValid   = "valid"
+	RevocationResultRevoked = "revoked"
+	RevocationResultError   = "error"
+)
+
+// RevocationObserver receives the outcome and latency of each revocation check.
+type RevocationObserver func(result string, duration time.Duration)
+
+// RevocationList tracks revoked token IDs (jti) and subjects whose tokens were
+// revoked wholesale. Entries are shared across replicas through Redis and
+// mirrored in memory, which also serves as the fallback when Redis is disabled
+// or unreachable. Entries expire after ttl, which should be at least the
+// longest access token lifetime.
+type RevocationList struct {
+	cache    cache.Cache
+	ttl      time.Duration
+	observer RevocationObserver
+
+	mu       sync.RWMutex
+	tokens   map[string]time.Time
+	subjects map[string]revokedSubject
+}
+
+// revokedSubject records which of a subject's tokens were revoked: those whose
+// iat is not after IssuedAfter, the revocation time truncated to the second.
+// iat only has second precision, so tokens issued in the same second as the
+// revocation are revoked too.
+type revokedSubject struct {
+	IssuedAfter time.Time `json:"issued_after"`
+	ExpiresAt   time.Time `json:"-"`
+}
+
+// revokes reports whether the entry covers a token issued at issuedAt.
+func (s revokedSubject) revokes(issuedAt time.Time) bool {
+	return !issuedAt.Truncate(time.Second).After(s.IssuedAfter)
+}
+
+// NewRevocationList creates a revocation list. cacheClient may be nil or disabled
+// to keep revocations in memory only; observer may be nil.
+func NewRevocationList(cacheClient cache.Cache, ttl time.Duration, observer RevocationObserver) *RevocationList {
+	if cacheClient == nil {
+		cacheClient = cache.NewNoopCache()
+	}
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+
+	return &RevocationList{
+		cache:    cacheClient,
+		ttl:      ttl,
+		observer: observer,
+		tokens:   map[string]time.Time{},
+		subjects: map[string]revokedSubject{},
+	}
+}
+
+// RevokeToken revokes a single token by its jti.
+func (l *RevocationList) RevokeToken(ctx context.Context, jti string) error {
+	now := time.Now()
+
+	l.mu.Lock()
+	l.sweepLocked(now)
+	l.tokens[jti] = now.Add(l.ttl)
+	l.mu.Unlock()
+
+	if !l.cache.Enabled() {
+		return nil
+	}
+	return l.cache.Set(ctx, tokenRevocationKey(jti), true, l.ttl)
+}
+
+// RevokeSubject revokes every token issued to subject up to now, including
+// tokens issued later in the current second.
+func (l *RevocationList) RevokeSubject(ctx context.Context, subject string) error {
+	now := time.Now()
+	entry := revokedSubject{
+		IssuedAfter: now.Truncate(time.Second).UTC(),
+		ExpiresAt:   now.Add(l.ttl),
+	}
+
+	l.mu.Lock()
+	l.sweepLocked(now)
+	l.subjects[subject] = entry
+	l.mu.Unlock()
+
+	if !l.cache.Enabled() {
+		return nil
+	}
+	return l.cache.Set(ctx, subjectRevocationKey(subject), entry, l.ttl)
+}
+
+// IsRevoked implements RevocationChecker. Redis errors are reported but the
+// in-memory view is still used so an outage does not reject every request.
+func (l *RevocationList) IsRevoked(ctx context.Context, claims *Claims) (bool, error) {
+	start := time.Now()
+	revoked, err := l.isRevoked(ctx, claims)
+
+	if l.observer != nil {
+		result := RevocationResultValid
+		switch {
+		case err != nil:
+			result = RevocationResultError
+		case revoked:
+			result = RevocationResultRevoked
+		}
+		l.observer(result, time.Since(start))
+	}
+
+	return revoked, err
+}
+
+func (l *RevocationList) isRevoked(ctx context.Context, claims *Claims) (bool, error) {
+	if claims == nil {
+		return false, nil
+	}
+
+	var issuedAt time.Time
+	if claims.IssuedAt != nil {
+		issuedAt = claims.IssuedAt.Time
+	}
+
+	if l.revokedLocally(claims.ID, claims.Subject, issuedAt) {
+		return true, nil
+	}
+
+	if !l.cache.Enabled() {
+		return false, nil
+	}
+
+	if claims.ID != "" {
+		var revoked bool
+		found, err := l.cache.Get(ctx, tokenRevocationKey(claims.ID), &revoked)
+		if err != nil {
+			return false, err
+		}
+		if found && revoked {
+			return true, nil
+		}
+	}
+
+	if claims.Subject != "" {
+		var entry revokedSubject
+		found, err := l.cache.Get(ctx, subjectRevocationKey(claims.Subject), &entry)
+		if err != nil {
+			return false, err
+		}
+		if found && entry.revokes(issuedAt) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (l *RevocationList) revokedLocally(jti, subject string, issuedAt time.Time) bool {
+	now := time.Now()
+
+	l.mu.RLock()
+	tokenExpiry, tokenFound := l.tokens[jti]
+	subjectEntry, subjectFound := l.subjects[subject]
+	l.mu.RUnlock()
+
+	if jti != "" && tokenFound {
+		if now.Before(tokenExpiry) {
+			return true
+		}
+		l.mu.Lock()
+		delete(l.tokens, jti)
+		l.mu.Unlock()
+	}
+
+	if subject != "" && subjectFound {
+		if now.Before(subjectEntry.ExpiresAt) {
+			return subjectEntry.revokes(issuedAt)
+		}
+		l.mu.Lock()
+		delete(l.subjects, subject)
+		l.mu.Unlock()
+	}
+
+	return false
+}
+
+// sweepLocked drops in-memory entries older than the longest token lifetime.
+// Lookups only remove the entries they hit, so without a sweep tokens that are
+// never presented again would stay in memory for the life of the process.
+func (l *RevocationList) sweepLocked(now time.Time) {
+	for jti, expiresAt := range l.tokens {
+		if !now.Before(expiresAt) {
+			delete(l.tokens, jti)
+		}
+	}
+	for subject, entry := range l.subjects {
+		if !now.Before(entry.ExpiresAt) {
+			delete(l.subjects, subject)
+		}
+	}
+}
+
+func tokenRevocationKey(jti string) string {
+	return "revoked:jti:" + jti
+}
+
+func subjectRevocationKey(subject string) string {
+	return "revoked:sub:" + subject
+}
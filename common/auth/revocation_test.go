@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRevocationListSweepsExpiredEntries(t *testing.T) {
+	list := NewRevocationList(nil, 20*time.Millisecond, nil)
+	ctx := context.Background()
+
+	if err := list.RevokeToken(ctx, "old-jti"); err != nil {
+		t.Fatalf("RevokeToken() error = %v", err)
+	}
+	if err := list.RevokeSubject(ctx, "old-subject"); err != nil {
+		t.Fatalf("RevokeSubject() error = %v", err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if err := list.RevokeToken(ctx, "new-jti"); err != nil {
+		t.Fatalf("RevokeToken() error = %v", err)
+	}
+
+	list.mu.RLock()
+	defer list.mu.RUnlock()
+	if _, ok := list.tokens["old-jti"]; ok {
+		t.Fatal("expected expired jti to be swept")
+	}
+	if _, ok := list.subjects["old-subject"]; ok {
+		t.Fatal("expected expired subject to be swept")
+	}
+	if _, ok := list.tokens["new-jti"]; !ok {
+		t.Fatal("expected new jti to be kept")
+	}
+}
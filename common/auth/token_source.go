@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OAuth2 grant and token type identifiers used by TokenSource.
+const (
+	grantTypeClientCredentials = "client_credentials"
+	grantTypeTokenExchange     = "urn:ietf:params:oauth:grant-type:token-exchange"
+	tokenTypeAccessToken       = "urn:ietf:params:oauth:token-type:access_token"
+)
+
+// tokenRefreshMargin is how long before expiry a cached token is replaced.
+const tokenRefreshMargin = 30 * time.Second
+
+// TokenSource obtains access tokens from the user service's OAuth2 token
+// endpoint instead of signing them locally, so services other than the user
+// service never hold signing material. Service tokens use the client
+// credentials grant; tokens on behalf of a caller use token exchange (RFC
+// 8693). Both are cached until shortly before they expire.
+type TokenSource struct {
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	scope        string
+	client       *http.Client
+
+	mu        sync.Mutex
+	service   cachedToken
+	exchanged map[string]cachedToken
+}
+
+type cachedToken struct {
+	value     string
+	expiresAt time.Time
+}
+
+// NewTokenSource creates a token source authenticating to tokenURL as the given
+// client. scope, when set, narrows the roles requested for every token.
+func NewTokenSource(tokenURL, clientID, clientSecret, scope string) *TokenSource {
+	return &TokenSource{
+		tokenURL:     tokenURL,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		scope:        scope,
+		client:       &http.Client{Timeout: 5 * time.Second},
+		exchanged:    map[string]cachedToken{},
+	}
+}
+
+// Token returns an access token for the client itself.
+func (s *TokenSource) Token(ctx context.Context) (string, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	cached := s.service
+	s.mu.Unlock()
+	if cached.valid(now) {
+		return cached.value, nil
+	}
+
+	token, err := s.request(ctx, url.Values{"grant_type": {grantTypeClientCredentials}})
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	s.service = token
+	s.mu.Unlock()
+	return token.value, nil
+}
+
+// Exchange trades subjectToken, an access token the caller presented, for a
+// token that lets the client act on the caller's behalf.
+func (s *TokenSource) Exchange(ctx context.Context, subjectToken string) (string, error) {
+	sum := sha256.Sum256([]byte(subjectToken))
+	cacheKey := hex.EncodeToString(sum[:])
+	now := time.Now()
+
+	s.mu.Lock()
+	cached := s.exchanged[cacheKey]
+	s.mu.Unlock()
+	if cached.valid(now) {
+		return cached.value, nil
+	}
+
+	token, err := s.request(ctx, url.Values{
+		"grant_type":         {grantTypeTokenExchange},
+		"subject_token":      {subjectToken},
+		"subject_token_type": {tokenTypeAccessToken},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	for k, e := range s.exchanged {
+		if !e.valid(now) {
+			delete(s.exchanged, k)
+		}
+	}
+	s.exchanged[cacheKey] = token
+	s.mu.Unlock()
+	return token.value, nil
+}
+
+func (s *TokenSource) request(ctx context.Context, form url.Values) (cachedToken, error) {
+	if s.scope != "" {
+		form.Set("scope", s.scope)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return cachedToken{}, err
+	}
+	req.SetBasicAuth(url.QueryEscape(s.clientID), url.QueryEscape(s.clientSecret))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return cachedToken{}, fmt.Errorf("request token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		AccessToken      string `json:"access_token"`
+		ExpiresIn        int64  `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil && resp.StatusCode == http.StatusOK {
+		return cachedToken{}, fmt.Errorf("decode token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return cachedToken{}, fmt.Errorf("request token: status %d: %s %s", resp.StatusCode, body.Error, body.ErrorDescription)
+	}
+	if body.AccessToken == "" {
+		return cachedToken{}, errors.New("request token: response has no access_token")
+	}
+
+	return cachedToken{
+		value:     body.AccessToken,
+		expiresAt: time.Now().Add(time.Duration(body.ExpiresIn)*time.Second - tokenRefreshMargin),
+	}, nil
+}
+
+func (t cachedToken) valid(now time.Time) bool {
+	return t.value != "" && now.Before(t.expiresAt)
+}
@@ -0,0 +1,157 @@
+package bulkhead
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/RashadTanjim/enterprise-microservice-system/common/errors"
+)
+
+// Rejection reasons reported to an Observer.
+const (
+	RejectQueueFull = "queue_full"
+	RejectTimeout   = "timeout"
+)
+
+// Observer receives bulkhead saturation updates. metrics.Metrics implements it.
+type Observer interface {
+	// ObserveBulkhead is called whenever the number of running or waiting
+	// calls changes.
+	ObserveBulkhead(service string, inFlight, queued int)
+	// ObserveBulkheadRejection is called for every rejected call.
+	ObserveBulkheadRejection(service, reason string)
+}
+
+// Config holds bulkhead configuration
+type Config struct {
+	MaxConcurrent int           // Calls allowed in flight at once; 0 disables the bulkhead
+	MaxQueue      int           // Calls allowed to wait for a slot; further calls are rejected
+	QueueTimeout  time.Duration // How long a call waits for a slot before it is rejected
+	Observer      Observer
+}
+
+// DefaultConfig returns default bulkhead configuration
+func DefaultConfig() Config {
+	return Config{
+		MaxConcurrent: 20,
+		MaxQueue:      20,
+		QueueTimeout:  500 * time.Millisecond,
+	}
+}
+
+// Bulkhead caps the concurrent calls made to one dependency, so a slow
+// dependency cannot tie up every request goroutine. A nil Bulkhead does not
+// limit calls.
+type Bulkhead struct {
+	serviceName  string
+	slots        chan struct{}
+	maxQueue     int64
+	queueTimeout time.Duration
+	observer     Observer
+
+	inFlight atomic.Int64
+	queued   atomic.Int64
+}
+
+// New creates a bulkhead for calls to serviceName. It returns nil when
+// MaxConcurrent is zero.
+func New(serviceName string, config Config) *Bulkhead {
+	if config.MaxConcurrent <= 0 {
+		return nil
+	}
+	return &Bulkhead{
+		serviceName:  serviceName,
+		slots:        make(chan struct{}, config.MaxConcurrent),
+		maxQueue:     int64(config.MaxQueue),
+		queueTimeout: config.QueueTimeout,
+		observer:     config.Observer,
+	}
+}
+
+// Execute runs fn once a slot is free. When every slot is taken the call
+// waits in the queue for up to QueueTimeout; when the queue is full or the
+// wait times out it fails fast with ErrCodeServiceUnavail.
+func (b *Bulkhead) Execute(ctx context.Context, fn func() (interface{}, error)) (interface{}, error) {
+	if b == nil {
+		return fn()
+	}
+	if err := b.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer b.release()
+	return fn()
+}
+
+// InFlight returns the number of running calls.
+func (b *Bulkhead) InFlight() int {
+	if b == nil {
+		return 0
+	}
+	return int(b.inFlight.Load())
+}
+
+// Queued returns the number of calls waiting for a slot.
+func (b *Bulkhead) Queued() int {
+	if b == nil {
+		return 0
+	}
+	return int(b.queued.Load())
+}
+
+func (b *Bulkhead) acquire(ctx context.Context) error {
+	select {
+	case b.slots <- struct{}{}:
+		b.inFlight.Add(1)
+		b.observe()
+		return nil
+	default:
+	}
+
+	if b.queued.Add(1) > b.maxQueue {
+		b.queued.Add(-1)
+		return b.reject(RejectQueueFull)
+	}
+	b.observe()
+	defer func() {
+		b.queued.Add(-1)
+		b.observe()
+	}()
+
+	timer := time.NewTimer(b.queueTimeout)
+	defer timer.Stop()
+
+	select {
+	case b.slots <- struct{}{}:
+		b.inFlight.Add(1)
+		return nil
+	case <-timer.C:
+		return b.reject(RejectTimeout)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *Bulkhead) release() {
+	b.inFlight.Add(-1)
+	<-b.slots
+	b.observe()
+}
+
+func (b *Bulkhead) reject(reason string) error {
+	if b.observer != nil {
+		b.observer.ObserveBulkheadRejection(b.serviceName, reason)
+	}
+	return errors.New(
+		errors.ErrCodeServiceUnavail,
+		fmt.Sprintf("too many concurrent calls to service: %s", b.serviceName),
+		nil,
+	)
+}
+
+func (b *Bulkhead) observe() {
+	if b.observer != nil {
+		b.observer.ObserveBulkhead(b.serviceName, b.InFlight(), b.Queued())
+	}
+}
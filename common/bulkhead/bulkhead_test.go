@@ -0,0 +1,113 @@
+package bulkhead
+
+import (
+	"context"
+	stderrors "errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RashadTanjim/enterprise-microservice-system/common/errors"
+)
+
+type recorder struct {
+	mu         sync.Mutex
+	maxActive  int
+	rejections map[string]int
+}
+
+func (r *recorder) ObserveBulkhead(service string, inFlight, queued int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if inFlight > r.maxActive {
+		r.maxActive = inFlight
+	}
+}
+
+func (r *recorder) ObserveBulkheadRejection(service, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rejections[reason]++
+}
+
+func isUnavailable(err error) bool {
+	var appErr *errors.AppError
+	return stderrors.As(err, &appErr) && appErr.Code == errors.ErrCodeServiceUnavail
+}
+
+func TestBulkheadRejectsWhenQueueIsFull(t *testing.T) {
+	rec := &recorder{rejections: map[string]int{}}
+	bh := New("users", Config{MaxConcurrent: 1, MaxQueue: 1, QueueTimeout: time.Second, Observer: rec})
+
+	running := make(chan struct{})
+	finish := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, _ = bh.Execute(context.Background(), func() (interface{}, error) {
+			close(running)
+			<-finish
+			return nil, nil
+		})
+	}()
+	<-running
+
+	go func() {
+		defer wg.Done()
+		if _, err := bh.Execute(context.Background(), func() (interface{}, error) { return nil, nil }); err != nil {
+			t.Errorf("expected the queued call to run, got %v", err)
+		}
+	}()
+	for bh.Queued() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	if _, err := bh.Execute(context.Background(), func() (interface{}, error) { return nil, nil }); !isUnavailable(err) {
+		t.Fatalf("expected a full queue to fail fast, got %v", err)
+	}
+
+	close(finish)
+	wg.Wait()
+
+	if rec.maxActive != 1 || rec.rejections[RejectQueueFull] != 1 {
+		t.Fatalf("expected one call in flight at most and one rejection, got %d and %v", rec.maxActive, rec.rejections)
+	}
+	if bh.InFlight() != 0 || bh.Queued() != 0 {
+		t.Fatalf("expected the bulkhead to drain, got %d in flight and %d queued", bh.InFlight(), bh.Queued())
+	}
+}
+
+func TestBulkheadQueueTimeout(t *testing.T) {
+	rec := &recorder{rejections: map[string]int{}}
+	bh := New("users", Config{MaxConcurrent: 1, MaxQueue: 1, QueueTimeout: 10 * time.Millisecond, Observer: rec})
+
+	running := make(chan struct{})
+	finish := make(chan struct{})
+	go func() {
+		_, _ = bh.Execute(context.Background(), func() (interface{}, error) {
+			close(running)
+			<-finish
+			return nil, nil
+		})
+	}()
+	<-running
+	defer close(finish)
+
+	if _, err := bh.Execute(context.Background(), func() (interface{}, error) { return nil, nil }); !isUnavailable(err) {
+		t.Fatalf("expected the queue timeout to reject the call, got %v", err)
+	}
+	if rec.rejections[RejectTimeout] != 1 {
+		t.Fatalf("expected a timeout rejection, got %v", rec.rejections)
+	}
+}
+
+func TestDisabledBulkheadRunsCalls(t *testing.T) {
+	bh := New("users", Config{})
+	if bh != nil {
+		t.Fatal("expected zero MaxConcurrent to disable the bulkhead")
+	}
+	if result, err := bh.Execute(context.Background(), func() (interface{}, error) { return 1, nil }); err != nil || result != 1 {
+		t.Fatalf("expected the call to run, got %v (%v)", result, err)
+	}
+}
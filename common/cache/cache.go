@@ -2,122 +2,152 @@ package cache
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"time"
 
-	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
 )
 
-// Config holds Redis cache configuration.
+// Cache backends selectable through Config.Backend.
+const (
+	BackendRedis  = "redis"
+	BackendMemory = "memory"
+	BackendNone   = "none"
+)
+
+// Config holds cache configuration.
 type Config struct {
-	Enabled    bool
-	Host       string
-	Port       string
-	Password   string
+	Enabled bool
+	// Backend selects the implementation; empty means Redis.
+	Backend string
+	// Host and Port address a single node and are used when Addrs is empty.
+	Host string
+	Port string
+	// Addrs lists sentinel addresses when MasterName is set, otherwise
+	// cluster nodes. Two or more addresses, or Cluster, select Cluster mode.
+	Addrs      []string
+	MasterName string
+	// Cluster forces Cluster mode with a single configuration endpoint.
+	Cluster          bool
+	Username         string
+	Password         string
+	SentinelPassword string
+	// DB is ignored in Cluster mode.
 	DB         int
+	TLS        TLSConfig
 	DefaultTTL time.Duration
+	// Format ("json" or "msgpack") and Compression ("none", "gzip" or "zstd")
+	// select the codec; values of at least CompressionThreshold bytes are
+	// compressed. Values written with another codec are still readable.
+	Format               string
+	Compression          string
+	CompressionThreshold int
+	// LocalSize enables an in-process LRU of up to LocalSize entries in front
+	// of Redis; zero disables it. LocalTTL caps how long entries stay local.
+	LocalSize int
+	LocalTTL  time.Duration
+	// MaxEntries bounds the memory backend; zero uses defaultMaxEntries.
+	MaxEntries int
+	// Observer, when set, is called for every lookup with the tier and result.
+	Observer LookupObserver
 }
 
-// Cache provides JSON-based Redis caching with optional disablement.
-type Cache struct {
-	client     *redis.Client
-	enabled    bool
-	defaultTTL time.Duration
-	prefix     string
+// TLSConfig configures TLS connections to Redis.
+type TLSConfig struct {
+	Enabled bool
+	// CAFile verifies the server against a private CA instead of the system pool.
+	CAFile string
+	// CertFile and KeyFile present a client certificate.
+	CertFile           string
+	KeyFile            string
+	ServerName         string
+	InsecureSkipVerify bool
 }
 
-// NewRedisCache creates a cache client and verifies connectivity when enabled.
-func NewRedisCache(cfg Config, prefix string) (*Cache, error) {
-	cache := &Cache{
-		enabled:    cfg.Enabled,
-		defaultTTL: cfg.DefaultTTL,
-		prefix:     prefix,
-	}
+// Cache tiers and lookup results reported to a LookupObserver.
+const (
+	TierLocal   = "local"
+	TierRedis   = "redis"
+	TierMemory  = "memory"
+	ResultHit   = "hit"
+	ResultMiss  = "miss"
+	ResultError = "error"
+)
+
+// LookupObserver records the result of a cache lookup in one tier.
+type LookupObserver func(tier, result string)
+
+// Cache stores values by key, encoded with the configured Codec.
+// Implementations are provided by this package: Redis (NewRedisCache),
+// in-process memory (NewMemoryCache) and a no-op cache (NewNoopCache). Errors are for the caller to ignore or log;
+// a cache failure should never fail a request.
+type Cache interface {
+	// Enabled reports whether the cache is currently usable. A Redis cache
+	// reports false while Redis is unreachable.
+	Enabled() bool
+	// Get retrieves a cached value into dest. Returns false when missing/disabled.
+	Get(ctx context.Context, key string, dest interface{}) (bool, error)
+	// Set stores a value. When ttl <= 0, default TTL is used.
+	Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+	// Delete removes keys from cache. No-op when disabled.
+	Delete(ctx context.Context, keys ...string) error
+	// InvalidateTags invalidates every entry cached under any of tags.
+	InvalidateTags(ctx context.Context, tags ...string) error
+	// Close releases any connections held by the cache.
+	Close() error
+
+	// tagVersions returns the current version of each tag.
+	tagVersions(ctx context.Context, tags []string) ([]string, error)
+	core() *base
+}
 
+// New creates the cache selected by cfg. When Redis cannot be reached the
+// Redis cache is still returned together with the error; it reconnects in
+// the background. Invalid configuration returns a no-op cache and the error.
+func New(cfg Config, prefix string) (Cache, error) {
 	if !cfg.Enabled {
-		return cache, nil
+		return NewNoopCache(), nil
 	}
 
-	client := redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%s", cfg.Host, cfg.Port),
-		Password: cfg.Password,
-		DB:       cfg.DB,
-	})
-
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-	defer cancel()
-
-	if err := client.Ping(ctx).Err(); err != nil {
-		cache.enabled = false
+	switch cfg.Backend {
+	case "", BackendRedis:
+		cache, err := NewRedisCache(cfg, prefix)
+		if cache == nil {
+			return NewNoopCache(), err
+		}
+		return cache, err
+	case BackendMemory:
+		cache, err := NewMemoryCache(cfg)
+		if cache == nil {
+			return NewNoopCache(), err
+		}
 		return cache, err
+	case BackendNone:
+		return NewNoopCache(), nil
+	default:
+		return NewNoopCache(), fmt.Errorf("unknown cache backend %q", cfg.Backend)
 	}
-
-	cache.client = client
-	return cache, nil
-}
-
-// Enabled returns whether caching is active.
-func (c *Cache) Enabled() bool {
-	return c != nil && c.enabled && c.client != nil
 }
 
-// GetJSON retrieves a cached JSON value into dest. Returns false when missing/disabled.
-func (c *Cache) GetJSON(ctx context.Context, key string, dest interface{}) (bool, error) {
-	if !c.Enabled() {
-		return false, nil
-	}
-
-	value, err := c.client.Get(ctx, c.key(key)).Result()
-	if err == redis.Nil {
-		return false, nil
-	}
-	if err != nil {
-		return false, err
-	}
-
-	if err := json.Unmarshal([]byte(value), dest); err != nil {
-		return false, err
-	}
-
-	return true, nil
+// base holds state shared by every implementation.
+type base struct {
+	defaultTTL time.Duration
+	codec      *Codec
+	// loads coalesces concurrent GetOrLoad misses per key.
+	loads singleflight.Group
 }
 
-// SetJSON stores a value as JSON. When ttl <= 0, default TTL is used.
-func (c *Cache) SetJSON(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
-	if !c.Enabled() {
-		return nil
-	}
-
-	if ttl <= 0 {
-		ttl = c.defaultTTL
-	}
-
-	payload, err := json.Marshal(value)
-	if err != nil {
-		return err
-	}
-
-	return c.client.Set(ctx, c.key(key), payload, ttl).Err()
+func newCodec(cfg Config) (*Codec, error) {
+	return NewCodec(cfg.Format, cfg.Compression, cfg.CompressionThreshold)
 }
 
-// Delete removes keys from cache. No-op when disabled.
-func (c *Cache) Delete(ctx context.Context, keys ...string) error {
-	if !c.Enabled() || len(keys) == 0 {
-		return nil
-	}
-
-	prefixed := make([]string, 0, len(keys))
-	for _, key := range keys {
-		prefixed = append(prefixed, c.key(key))
-	}
-
-	return c.client.Del(ctx, prefixed...).Err()
+func (b *base) core() *base {
+	return b
 }
 
-func (c *Cache) key(key string) string {
-	if c.prefix == "" {
+func prefixKey(prefix, key string) string {
+	if prefix == "" {
 		return key
 	}
-	return fmt.Sprintf("%s:%s", c.prefix, key)
+	return fmt.Sprintf("%s:%s", prefix, key)
 }
@@ -0,0 +1,263 @@
+package cache
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ugorji/go/codec"
+)
+
+// Stored values start with a three byte header: the header version, the
+// format and the compression used. Decoding follows the header rather than
+// the configured codec, so the codec can change without flushing Redis.
+// Values without a header are legacy JSON; JSON text never starts with the
+// header version byte.
+const (
+	headerVersion = 1
+	headerSize    = 3
+)
+
+// defaultCompressionThreshold is the payload size, in bytes, from which
+// values are compressed when Codec.Threshold is zero.
+const defaultCompressionThreshold = 1024
+
+// Codec names accepted by NewCodec.
+const (
+	FormatJSON        = "json"
+	FormatMessagePack = "msgpack"
+
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+// Format serializes values.
+type Format interface {
+	ID() byte
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// Compressor compresses serialized values.
+type Compressor interface {
+	ID() byte
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+var (
+	formats = map[string]Format{
+		FormatJSON:        jsonFormat{},
+		FormatMessagePack: msgpackFormat{},
+	}
+	compressors = map[string]Compressor{
+		CompressionGzip: gzipCompressor{},
+		CompressionZstd: zstdCompressor{},
+	}
+)
+
+// Codec encodes cache values with a format, compressing those of at least
+// Threshold bytes.
+type Codec struct {
+	Format     Format
+	Compressor Compressor
+	Threshold  int
+}
+
+// DefaultCodec stores uncompressed JSON.
+var DefaultCodec = &Codec{Format: jsonFormat{}}
+
+// NewCodec returns the codec for a format and compression name. Empty names
+// select JSON and no compression; threshold <= 0 uses 1 KiB.
+func NewCodec(format, compression string, threshold int) (*Codec, error) {
+	if format == "" {
+		format = FormatJSON
+	}
+	f, ok := formats[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown cache format %q", format)
+	}
+
+	c := &Codec{Format: f, Threshold: threshold}
+	if compression != "" && compression != CompressionNone {
+		compressor, ok := compressors[compression]
+		if !ok {
+			return nil, fmt.Errorf("unknown cache compression %q", compression)
+		}
+		c.Compressor = compressor
+	}
+	return c, nil
+}
+
+// Encode serializes v and prefixes the header.
+func (c *Codec) Encode(v interface{}) ([]byte, error) {
+	payload, err := c.Format.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	compression := byte(0)
+	threshold := c.Threshold
+	if threshold <= 0 {
+		threshold = defaultCompressionThreshold
+	}
+	if c.Compressor != nil && len(payload) >= threshold {
+		compressed, err := c.Compressor.Compress(payload)
+		if err != nil {
+			return nil, err
+		}
+		if len(compressed) < len(payload) {
+			payload = compressed
+			compression = c.Compressor.ID()
+		}
+	}
+
+	data := make([]byte, 0, headerSize+len(payload))
+	data = append(data, headerVersion, c.Format.ID(), compression)
+	return append(data, payload...), nil
+}
+
+// Decode reads a value written by any codec, or legacy headerless JSON.
+func (c *Codec) Decode(data []byte, v interface{}) error {
+	if len(data) == 0 || data[0] != headerVersion {
+		return json.Unmarshal(data, v)
+	}
+	if len(data) < headerSize {
+		return errors.New("cache: truncated value header")
+	}
+
+	format, err := formatByID(data[1])
+	if err != nil {
+		return err
+	}
+	payload := data[headerSize:]
+	if data[2] != 0 {
+		compressor, err := compressorByID(data[2])
+		if err != nil {
+			return err
+		}
+		if payload, err = compressor.Decompress(payload); err != nil {
+			return err
+		}
+	}
+	return format.Unmarshal(payload, v)
+}
+
+func formatByID(id byte) (Format, error) {
+	for _, format := range formats {
+		if format.ID() == id {
+			return format, nil
+		}
+	}
+	return nil, fmt.Errorf("cache: unknown format id %d", id)
+}
+
+func compressorByID(id byte) (Compressor, error) {
+	for _, compressor := range compressors {
+		if compressor.ID() == id {
+			return compressor, nil
+		}
+	}
+	return nil, fmt.Errorf("cache: unknown compression id %d", id)
+}
+
+type jsonFormat struct{}
+
+func (jsonFormat) ID() byte { return 1 }
+
+func (jsonFormat) Marshal(v interface{}) ([]byte, error) { return json.Marshal(v) }
+
+func (jsonFormat) Unmarshal(data []byte, v interface{}) error { return json.Unmarshal(data, v) }
+
+// msgpackHandle honors json struct tags, so models encode with the same field
+// names and omissions as JSON.
+var msgpackHandle = func() *codec.MsgpackHandle {
+	h := &codec.MsgpackHandle{}
+	h.WriteExt = true
+	h.TypeInfos = codec.NewTypeInfos([]string{"json"})
+	return h
+}()
+
+type msgpackFormat struct{}
+
+func (msgpackFormat) ID() byte { return 2 }
+
+func (msgpackFormat) Marshal(v interface{}) ([]byte, error) {
+	var out []byte
+	err := codec.NewEncoderBytes(&out, msgpackHandle).Encode(v)
+	return out, err
+}
+
+func (msgpackFormat) Unmarshal(data []byte, v interface{}) error {
+	return codec.NewDecoderBytes(data, msgpackHandle).Decode(v)
+}
+
+type gzipCompressor struct{}
+
+func (gzipCompressor) ID() byte { return 1 }
+
+func (gzipCompressor) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (gzipCompressor) Decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// zstd encoders and decoders are safe for concurrent EncodeAll/DecodeAll and
+// expensive to create, so one of each is shared.
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+	zstdErr     error
+)
+
+func zstdCodecs() (*zstd.Encoder, *zstd.Decoder, error) {
+	zstdOnce.Do(func() {
+		zstdEncoder, zstdErr = zstd.NewWriter(nil)
+		if zstdErr == nil {
+			zstdDecoder, zstdErr = zstd.NewReader(nil)
+		}
+	})
+	return zstdEncoder, zstdDecoder, zstdErr
+}
+
+type zstdCompressor struct{}
+
+func (zstdCompressor) ID() byte { return 2 }
+
+func (zstdCompressor) Compress(data []byte) ([]byte, error) {
+	encoder, _, err := zstdCodecs()
+	if err != nil {
+		return nil, err
+	}
+	return encoder.EncodeAll(data, nil), nil
+}
+
+func (zstdCompressor) Decompress(data []byte) ([]byte, error) {
+	_, decoder, err := zstdCodecs()
+	if err != nil {
+		return nil, err
+	}
+	return decoder.DecodeAll(data, nil)
+}
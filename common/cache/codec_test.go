@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+type codecRecord struct {
+	ID        uint      `json:"id"`
+	Name      string    `json:"name"`
+	Secret    string    `json:"-"`
+	Note      *string   `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	note := strings.Repeat("repeated note ", 200)
+	value := codecRecord{
+		ID:        7,
+		Name:      "Ada",
+		Secret:    "hidden",
+		Note:      &note,
+		CreatedAt: time.Date(2024, 3, 1, 12, 30, 0, 500, time.UTC),
+	}
+
+	for _, format := range []string{FormatJSON, FormatMessagePack} {
+		for _, compression := range []string{CompressionNone, CompressionGzip, CompressionZstd} {
+			codec, err := NewCodec(format, compression, 0)
+			if err != nil {
+				t.Fatalf("NewCodec(%s, %s): %v", format, compression, err)
+			}
+			data, err := codec.Encode(value)
+			if err != nil {
+				t.Fatalf("%s/%s Encode: %v", format, compression, err)
+			}
+			if compression != CompressionNone && data[2] == 0 {
+				t.Errorf("%s/%s: expected a %d byte payload to be compressed", format, compression, len(data))
+			}
+
+			var decoded codecRecord
+			if err := codec.Decode(data, &decoded); err != nil {
+				t.Fatalf("%s/%s Decode: %v", format, compression, err)
+			}
+			if decoded.ID != value.ID || decoded.Name != value.Name || decoded.Note == nil || *decoded.Note != note {
+				t.Errorf("%s/%s: got %+v", format, compression, decoded)
+			}
+			if !decoded.CreatedAt.Equal(value.CreatedAt) {
+				t.Errorf("%s/%s: expected created_at %v, got %v", format, compression, value.CreatedAt, decoded.CreatedAt)
+			}
+			if decoded.Secret != "" {
+				t.Errorf("%s/%s: expected json:\"-\" field to be skipped", format, compression)
+			}
+		}
+	}
+}
+
+func TestCodecCompressesFromThreshold(t *testing.T) {
+	codec, err := NewCodec(FormatJSON, CompressionGzip, 256)
+	if err != nil {
+		t.Fatalf("NewCodec: %v", err)
+	}
+
+	small, err := codec.Encode("short")
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if small[2] != 0 {
+		t.Errorf("expected a value below the threshold to stay uncompressed")
+	}
+
+	large, err := codec.Encode(strings.Repeat("a", 254))
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if large[2] != (gzipCompressor{}).ID() {
+		t.Errorf("expected a value at the threshold to be gzipped")
+	}
+}
+
+func TestCodecDecodesOtherCodecsAndLegacyJSON(t *testing.T) {
+	writer, err := NewCodec(FormatMessagePack, CompressionZstd, 1)
+	if err != nil {
+		t.Fatalf("NewCodec: %v", err)
+	}
+	data, err := writer.Encode(map[string]string{"name": strings.Repeat("b", 100)})
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	var decoded map[string]string
+	if err := DefaultCodec.Decode(data, &decoded); err != nil || decoded["name"] != strings.Repeat("b", 100) {
+		t.Fatalf("expected JSON codec to read a msgpack/zstd value, got %v (%v)", decoded, err)
+	}
+
+	legacy, _ := json.Marshal(map[string]string{"name": "legacy"})
+	decoded = nil
+	if err := writer.Decode(legacy, &decoded); err != nil || decoded["name"] != "legacy" {
+		t.Fatalf("expected headerless JSON to decode, got %v (%v)", decoded, err)
+	}
+
+	if _, err := NewCodec("xml", "", 0); err == nil {
+		t.Error("expected an unknown format to fail")
+	}
+	if _, err := NewCodec(FormatJSON, "lz4", 0); err == nil {
+		t.Error("expected an unknown compression to fail")
+	}
+	if err := DefaultCodec.Decode(append([]byte{headerVersion, 9, 0}, bytes.Repeat([]byte{1}, 4)...), &decoded); err == nil {
+		t.Error("expected an unknown format id to fail")
+	}
+}
@@ -0,0 +1,128 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// ErrNotFound is returned by loaders to report that a value does not exist. It
+// is cached for LoadOptions.NegativeTTL and returned unwrapped by GetOrLoad.
+var ErrNotFound = errors.New("cache: value not found")
+
+// LoadOptions configures GetOrLoad.
+type LoadOptions struct {
+	// TTL is how long loaded values are cached; zero uses the cache default.
+	TTL time.Duration
+	// NegativeTTL is how long ErrNotFound results are cached; zero disables
+	// negative caching.
+	NegativeTTL time.Duration
+	// EarlyRefresh enables probabilistic early refresh (XFetch). Values are
+	// reloaded in the background shortly before they expire, with a likelihood
+	// that grows as expiry nears and with the time the last load took. 1 is the
+	// usual setting, larger values refresh earlier; zero disables it.
+	EarlyRefresh float64
+	// Tags lists the tags the value is cached under; InvalidateTags on any of
+	// them makes the value reload.
+	Tags []string
+}
+
+// entry wraps cached values with the metadata needed for early refresh.
+type entry[T any] struct {
+	Value    T    `json:"v,omitempty"`
+	NotFound bool `json:"nf,omitempty"`
+	// LoadTime is how long the load took and ExpiresAt when the entry expires,
+	// both in milliseconds.
+	LoadTime  int64 `json:"lt"`
+	ExpiresAt int64 `json:"exp"`
+}
+
+// GetOrLoad returns the value cached under key or calls load and caches its
+// result. Concurrent misses for the same key on one instance share a single
+// load. Redis errors are ignored so the cache never fails a request; when the
+// cache is nil or disabled, load is still coalesced where possible.
+func GetOrLoad[T any](ctx context.Context, c Cache, key string, opts LoadOptions, load func(ctx context.Context) (T, error)) (T, error) {
+	var zero T
+	if c == nil {
+		return load(ctx)
+	}
+	key, err := taggedKey(ctx, c, key, opts.Tags)
+	if err != nil {
+		// Without the tag versions a cached value might be stale.
+		return load(ctx)
+	}
+
+	loads := &c.core().loads
+	if cached, found := getEntry[T](ctx, c, key); found {
+		if cached.NotFound {
+			return zero, ErrNotFound
+		}
+		if opts.EarlyRefresh > 0 && cached.refreshDue(opts.EarlyRefresh) {
+			loads.DoChan(key, func() (interface{}, error) {
+				return loadAndStore(context.WithoutCancel(ctx), c, key, opts, load)
+			})
+		}
+		return cached.Value, nil
+	}
+
+	// The shared load must not be cancelled when the caller that started it goes away.
+	result := loads.DoChan(key, func() (interface{}, error) {
+		return loadAndStore(context.WithoutCancel(ctx), c, key, opts, load)
+	})
+	select {
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	case res := <-result:
+		if res.Err != nil {
+			return zero, res.Err
+		}
+		value, _ := res.Val.(T)
+		return value, nil
+	}
+}
+
+func loadAndStore[T any](ctx context.Context, c Cache, key string, opts LoadOptions, load func(ctx context.Context) (T, error)) (T, error) {
+	start := time.Now()
+	value, err := load(ctx)
+	loadTime := time.Since(start)
+
+	switch {
+	case err == nil:
+		ttl := opts.TTL
+		if ttl <= 0 {
+			ttl = c.core().defaultTTL
+		}
+		setEntry(ctx, c, key, entry[T]{Value: value}, loadTime, ttl)
+	case errors.Is(err, ErrNotFound) && opts.NegativeTTL > 0:
+		setEntry(ctx, c, key, entry[T]{NotFound: true}, loadTime, opts.NegativeTTL)
+	}
+	return value, err
+}
+
+// getEntry reads an entry; values not written by setEntry count as misses.
+func getEntry[T any](ctx context.Context, c Cache, key string) (entry[T], bool) {
+	var cached entry[T]
+	found, err := c.Get(ctx, key, &cached)
+	if err != nil || !found || cached.ExpiresAt == 0 {
+		return entry[T]{}, false
+	}
+	return cached, true
+}
+
+func setEntry[T any](ctx context.Context, c Cache, key string, value entry[T], loadTime, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	value.LoadTime = loadTime.Milliseconds()
+	value.ExpiresAt = time.Now().Add(ttl).UnixMilli()
+	_ = c.Set(ctx, key, value, ttl)
+}
+
+// refreshDue implements the XFetch check: refresh when
+// now - loadTime * beta * ln(rand) >= expiry.
+func (e entry[T]) refreshDue(beta float64) bool {
+	gap := float64(e.LoadTime) * beta * -math.Log(1-rand.Float64())
+	return float64(time.Now().UnixMilli())+gap >= float64(e.ExpiresAt)
+}
@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetOrLoadCoalescesConcurrentMisses(t *testing.T) {
+	c := NewNoopCache()
+
+	var loads int32
+	release := make(chan struct{})
+	load := func(ctx context.Context) (string, error) {
+		atomic.AddInt32(&loads, 1)
+		<-release
+		return "value", nil
+	}
+
+	var wg sync.WaitGroup
+	results := make(chan string, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := GetOrLoad(context.Background(), c, "key", LoadOptions{}, load)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			results <- value
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+
+	if got := atomic.LoadInt32(&loads); got != 1 {
+		t.Fatalf("expected a single load, got %d", got)
+	}
+	for value := range results {
+		if value != "value" {
+			t.Fatalf("expected every caller to share the loaded value, got %q", value)
+		}
+	}
+}
+
+func TestGetOrLoadReturnsNotFound(t *testing.T) {
+	value, err := GetOrLoad(context.Background(), nil, "key", LoadOptions{NegativeTTL: time.Minute}, func(ctx context.Context) (*int, error) {
+		return nil, ErrNotFound
+	})
+	if err != ErrNotFound || value != nil {
+		t.Fatalf("expected ErrNotFound, got %v and %v", value, err)
+	}
+}
+
+func TestGetOrLoadStopsWaitingWhenCallerCancels(t *testing.T) {
+	c := NewNoopCache()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := GetOrLoad(ctx, c, "key", LoadOptions{}, func(ctx context.Context) (int, error) {
+		time.Sleep(20 * time.Millisecond)
+		return 1, nil
+	})
+	if err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestEntryRefreshDue(t *testing.T) {
+	now := time.Now()
+	fresh := entry[int]{LoadTime: 10, ExpiresAt: now.Add(time.Hour).UnixMilli()}
+	expiring := entry[int]{LoadTime: 10, ExpiresAt: now.UnixMilli()}
+
+	if fresh.refreshDue(1) {
+		t.Fatalf("expected an entry far from expiry not to refresh")
+	}
+	if !expiring.refreshDue(1) {
+		t.Fatalf("expected an entry at expiry to refresh")
+	}
+}
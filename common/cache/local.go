@@ -0,0 +1,124 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// localCache is a bounded in-process LRU of encoded payloads with per-entry
+// expiry. A positive ttl caps every entry's lifetime; with a zero ttl entries
+// stored without one never expire. It is safe for concurrent use.
+type localCache struct {
+	mu    sync.Mutex
+	size  int
+	ttl   time.Duration
+	items map[string]*list.Element
+	order *list.List
+	// generation is bumped on every invalidation so a Redis read that raced
+	// with one does not repopulate the entry it dropped.
+	generation uint64
+}
+
+type localItem struct {
+	key       string
+	payload   []byte
+	expiresAt time.Time
+}
+
+func newLocalCache(size int, ttl time.Duration) *localCache {
+	return &localCache{
+		size:  size,
+		ttl:   ttl,
+		items: make(map[string]*list.Element, size),
+		order: list.New(),
+	}
+}
+
+func (l *localCache) get(key string) ([]byte, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	elem, ok := l.items[key]
+	if !ok {
+		return nil, false
+	}
+	item := elem.Value.(*localItem)
+	if !item.expiresAt.IsZero() && time.Now().After(item.expiresAt) {
+		l.remove(elem)
+		return nil, false
+	}
+	l.order.MoveToFront(elem)
+	return item.payload, true
+}
+
+// version returns the current invalidation generation, to be passed to setAt.
+func (l *localCache) version() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.generation
+}
+
+// set stores payload for the shorter of ttl and the cache's cap.
+func (l *localCache) set(key string, payload []byte, ttl time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.store(key, payload, ttl)
+}
+
+// setAt stores payload unless an invalidation happened since version was read.
+func (l *localCache) setAt(key string, payload []byte, ttl time.Duration, version uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.generation != version {
+		return
+	}
+	l.store(key, payload, ttl)
+}
+
+func (l *localCache) delete(keys ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.generation++
+	for _, key := range keys {
+		if elem, ok := l.items[key]; ok {
+			l.remove(elem)
+		}
+	}
+}
+
+func (l *localCache) clear() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.generation++
+	l.items = make(map[string]*list.Element, l.size)
+	l.order.Init()
+}
+
+func (l *localCache) store(key string, payload []byte, ttl time.Duration) {
+	if l.ttl > 0 && (ttl <= 0 || ttl > l.ttl) {
+		ttl = l.ttl
+	}
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+
+	if elem, ok := l.items[key]; ok {
+		item := elem.Value.(*localItem)
+		item.payload = payload
+		item.expiresAt = expiresAt
+		l.order.MoveToFront(elem)
+		return
+	}
+
+	l.items[key] = l.order.PushFront(&localItem{key: key, payload: payload, expiresAt: expiresAt})
+	for l.order.Len() > l.size {
+		l.remove(l.order.Back())
+	}
+}
+
+func (l *localCache) remove(elem *list.Element) {
+	l.order.Remove(elem)
+	delete(l.items, elem.Value.(*localItem).key)
+}
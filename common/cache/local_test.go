@@ -0,0 +1,55 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLocalCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	l := newLocalCache(2, time.Minute)
+	l.set("a", []byte("1"), 0)
+	l.set("b", []byte("2"), 0)
+	if _, ok := l.get("a"); !ok {
+		t.Fatal("expected a to be cached")
+	}
+	l.set("c", []byte("3"), 0)
+
+	if _, ok := l.get("b"); ok {
+		t.Fatal("expected b to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, ok := l.get(key); !ok {
+			t.Fatalf("expected %s to be cached", key)
+		}
+	}
+}
+
+func TestLocalCacheExpiresEntries(t *testing.T) {
+	l := newLocalCache(10, time.Minute)
+	l.set("short", []byte("1"), 10*time.Millisecond)
+	l.set("long", []byte("2"), time.Hour)
+
+	time.Sleep(20 * time.Millisecond)
+	if _, ok := l.get("short"); ok {
+		t.Fatal("expected short to expire")
+	}
+	if item := l.items["long"].Value.(*localItem); time.Until(item.expiresAt) > time.Minute {
+		t.Fatal("expected the local TTL to cap the entry lifetime")
+	}
+}
+
+func TestLocalCacheSkipsWritesRacingAnInvalidation(t *testing.T) {
+	l := newLocalCache(10, time.Minute)
+	version := l.version()
+	l.delete("key")
+	l.setAt("key", []byte("stale"), 0, version)
+
+	if _, ok := l.get("key"); ok {
+		t.Fatal("expected a read that raced an invalidation not to be cached")
+	}
+
+	l.setAt("key", []byte("fresh"), 0, l.version())
+	if payload, ok := l.get("key"); !ok || string(payload) != "fresh" {
+		t.Fatalf("expected fresh payload, got %q", payload)
+	}
+}
@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultMaxEntries bounds a memory cache when Config.MaxEntries is zero.
+const defaultMaxEntries = 10000
+
+// MemoryCache keeps entries in process in a bounded LRU. It is meant for
+// local development and tests; entries are not shared between replicas.
+type MemoryCache struct {
+	base
+	entries  *localCache
+	observer LookupObserver
+
+	mu   sync.Mutex
+	tags map[string]uint64
+}
+
+// NewMemoryCache creates an in-memory cache holding up to cfg.MaxEntries.
+// Values are encoded like they would be in Redis, so codec errors surface in
+// tests. It fails only on an unknown codec.
+func NewMemoryCache(cfg Config) (*MemoryCache, error) {
+	codec, err := newCodec(cfg)
+	if err != nil {
+		return nil, err
+	}
+	size := cfg.MaxEntries
+	if size <= 0 {
+		size = defaultMaxEntries
+	}
+	return &MemoryCache{
+		base:     base{defaultTTL: cfg.DefaultTTL, codec: codec},
+		entries:  newLocalCache(size, 0),
+		observer: cfg.Observer,
+		tags:     map[string]uint64{},
+	}, nil
+}
+
+// Enabled always returns true.
+func (c *MemoryCache) Enabled() bool {
+	return true
+}
+
+// Get retrieves a cached value into dest. Returns false when missing.
+func (c *MemoryCache) Get(ctx context.Context, key string, dest interface{}) (bool, error) {
+	payload, ok := c.entries.get(key)
+	if !ok {
+		c.observe(ResultMiss)
+		return false, nil
+	}
+	c.observe(ResultHit)
+
+	if err := c.codec.Decode(payload, dest); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Set stores a value. When ttl <= 0, default TTL is used.
+func (c *MemoryCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	if ttl <= 0 {
+		ttl = c.defaultTTL
+	}
+
+	payload, err := c.codec.Encode(value)
+	if err != nil {
+		return err
+	}
+	c.entries.set(key, payload, ttl)
+	return nil
+}
+
+// Delete removes keys from cache.
+func (c *MemoryCache) Delete(ctx context.Context, keys ...string) error {
+	c.entries.delete(keys...)
+	return nil
+}
+
+// InvalidateTags increments the version of each tag.
+func (c *MemoryCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, tag := range tags {
+		c.tags[tag]++
+	}
+	return nil
+}
+
+// Close is a no-op.
+func (c *MemoryCache) Close() error {
+	return nil
+}
+
+func (c *MemoryCache) tagVersions(ctx context.Context, tags []string) ([]string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	versions := make([]string, len(tags))
+	for i, tag := range tags {
+		versions[i] = strconv.FormatUint(c.tags[tag], 10)
+	}
+	return versions, nil
+}
+
+func (c *MemoryCache) observe(result string) {
+	if c.observer != nil {
+		c.observer(TierMemory, result)
+	}
+}
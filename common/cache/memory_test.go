@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryCacheSetGetDelete(t *testing.T) {
+	c, err := NewMemoryCache(Config{DefaultTTL: time.Minute})
+	if err != nil {
+		t.Fatalf("NewMemoryCache: %v", err)
+	}
+	ctx := context.Background()
+
+	if err := c.Set(ctx, "key", map[string]int{"n": 1}, 0); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	var value map[string]int
+	if found, err := c.Get(ctx, "key", &value); err != nil || !found || value["n"] != 1 {
+		t.Fatalf("expected cached value, got %v (found=%v, err=%v)", value, found, err)
+	}
+
+	if err := c.Delete(ctx, "key"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if found, _ := c.Get(ctx, "key", &value); found {
+		t.Fatal("expected key to be deleted")
+	}
+
+	if err := c.Set(ctx, "short", 1, 10*time.Millisecond); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if found, _ := c.Get(ctx, "short", &value); found {
+		t.Fatal("expected short to expire")
+	}
+}
+
+func TestGetOrLoadReloadsAfterTagInvalidation(t *testing.T) {
+	c, err := NewMemoryCache(Config{DefaultTTL: time.Minute})
+	if err != nil {
+		t.Fatalf("NewMemoryCache: %v", err)
+	}
+	ctx := context.Background()
+	opts := LoadOptions{Tags: []string{"items:list"}}
+
+	loads := 0
+	load := func(ctx context.Context) (int, error) {
+		loads++
+		return loads, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		if value, err := GetOrLoad(ctx, c, "items:page:1", opts, load); err != nil || value != 1 {
+			t.Fatalf("expected cached value 1, got %d (%v)", value, err)
+		}
+	}
+
+	if err := c.InvalidateTags(ctx, "items:list"); err != nil {
+		t.Fatalf("InvalidateTags: %v", err)
+	}
+	if value, err := GetOrLoad(ctx, c, "items:page:1", opts, load); err != nil || value != 2 {
+		t.Fatalf("expected reload after invalidation, got %d (%v)", value, err)
+	}
+}
+
+func TestNewSelectsBackend(t *testing.T) {
+	cases := map[string]Config{
+		"disabled": {Enabled: false, Backend: BackendMemory},
+		"none":     {Enabled: true, Backend: BackendNone},
+		"memory":   {Enabled: true, Backend: BackendMemory},
+	}
+	for name, cfg := range cases {
+		c, err := New(cfg, "test")
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if want := name == "memory"; c.Enabled() != want {
+			t.Errorf("%s: expected Enabled() = %v", name, want)
+		}
+	}
+
+	if _, err := New(Config{Enabled: true, Backend: "bogus"}, "test"); err == nil {
+		t.Error("expected an error for an unknown backend")
+	}
+}
@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// NoopCache caches nothing. It is used when caching is disabled; GetOrLoad
+// still coalesces concurrent loads.
+type NoopCache struct {
+	base
+}
+
+// NewNoopCache creates a cache that stores nothing.
+func NewNoopCache() *NoopCache {
+	return &NoopCache{}
+}
+
+// Enabled always returns false.
+func (c *NoopCache) Enabled() bool {
+	return false
+}
+
+// Get always reports a miss.
+func (c *NoopCache) Get(ctx context.Context, key string, dest interface{}) (bool, error) {
+	return false, nil
+}
+
+// Set discards the value.
+func (c *NoopCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	return nil
+}
+
+// Delete is a no-op.
+func (c *NoopCache) Delete(ctx context.Context, keys ...string) error {
+	return nil
+}
+
+// InvalidateTags is a no-op.
+func (c *NoopCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	return nil
+}
+
+// Close is a no-op.
+func (c *NoopCache) Close() error {
+	return nil
+}
+
+func (c *NoopCache) tagVersions(ctx context.Context, tags []string) ([]string, error) {
+	return make([]string, len(tags)), nil
+}
@@ -0,0 +1,511 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// defaultLocalTTL bounds how stale a local entry can get if an invalidation
+// message is lost.
+const defaultLocalTTL = 30 * time.Second
+
+// reconnectInterval is how often an unreachable Redis is pinged.
+const reconnectInterval = 2 * time.Second
+
+// RedisCache provides caching on a single Redis node, a
+// Sentinel-managed master or a Redis Cluster, with an optional in-process
+// tier. When a command fails because Redis is unreachable the cache reports
+// itself disabled, so callers fall back without waiting on timeouts, and
+// reconnects in the background. Invalidations are still attempted while
+// disabled, since another instance may be reading the same entries; those
+// that fail are replayed before the cache is enabled again.
+type RedisCache struct {
+	base
+	client redis.UniversalClient
+	prefix string
+
+	healthy   atomic.Bool
+	retrying  atomic.Bool
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	// pendingKeys and pendingTags hold prefixed keys and tag keys whose
+	// invalidation failed, until they are replayed on reconnect.
+	pendingMu   sync.Mutex
+	pendingKeys map[string]struct{}
+	pendingTags map[string]struct{}
+
+	// local is the optional in-process tier. Writes are broadcast on the
+	// invalidation channel so other instances drop their local copies;
+	// instanceID lets an instance ignore its own messages.
+	local      *localCache
+	pubsub     *redis.PubSub
+	instanceID string
+	observer   LookupObserver
+}
+
+// invalidation is the message published when keys change.
+type invalidation struct {
+	Origin string   `json:"origin"`
+	Keys   []string `json:"keys"`
+}
+
+// NewRedisCache creates a Redis cache and verifies connectivity. When Redis
+// cannot be reached the cache is returned with the error and starts disabled
+// until a background ping succeeds. A nil cache is returned when the TLS or
+// codec configuration is invalid.
+func NewRedisCache(cfg Config, prefix string) (*RedisCache, error) {
+	options, err := universalOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	codec, err := newCodec(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	cache := &RedisCache{
+		base:     base{defaultTTL: cfg.DefaultTTL, codec: codec},
+		client:   redis.NewUniversalClient(options),
+		prefix:   prefix,
+		closed:   make(chan struct{}),
+		observer: cfg.Observer,
+	}
+	if cfg.LocalSize > 0 {
+		cache.enableLocal(cfg.LocalSize, cfg.LocalTTL)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if err := cache.client.Ping(ctx).Err(); err != nil {
+		cache.markDown()
+		return cache, err
+	}
+
+	cache.healthy.Store(true)
+	return cache, nil
+}
+
+// universalOptions maps cfg onto go-redis options. NewUniversalClient picks
+// a Sentinel failover client when MasterName is set, a cluster client for
+// several addresses or Cluster, and a single-node client otherwise.
+func universalOptions(cfg Config) (*redis.UniversalOptions, error) {
+	addrs := cfg.Addrs
+	if len(addrs) == 0 {
+		addrs = []string{fmt.Sprintf("%s:%s", cfg.Host, cfg.Port)}
+	}
+
+	options := &redis.UniversalOptions{
+		Addrs:            addrs,
+		MasterName:       cfg.MasterName,
+		IsClusterMode:    cfg.Cluster && cfg.MasterName == "",
+		Username:         cfg.Username,
+		Password:         cfg.Password,
+		SentinelPassword: cfg.SentinelPassword,
+		DB:               cfg.DB,
+	}
+
+	if cfg.TLS.Enabled {
+		tlsConfig, err := loadTLSConfig(cfg.TLS)
+		if err != nil {
+			return nil, err
+		}
+		options.TLSConfig = tlsConfig
+	}
+	return options, nil
+}
+
+func loadTLSConfig(cfg TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read redis CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("redis CA file %s contains no certificates", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load redis client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// enableLocal adds the in-process tier and subscribes to invalidations.
+func (c *RedisCache) enableLocal(size int, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = defaultLocalTTL
+	}
+	id := make([]byte, 8)
+	_, _ = rand.Read(id)
+
+	c.local = newLocalCache(size, ttl)
+	c.instanceID = hex.EncodeToString(id)
+	c.pubsub = c.client.Subscribe(context.Background(), c.invalidationChannel())
+	go c.listen(c.pubsub.ChannelWithSubscriptions())
+}
+
+// listen drops local entries invalidated by other instances. The subscription
+// is confirmed again after every reconnect; messages may have been missed
+// while disconnected, so the local tier is cleared.
+func (c *RedisCache) listen(messages <-chan interface{}) {
+	for message := range messages {
+		switch msg := message.(type) {
+		case *redis.Subscription:
+			if msg.Kind == "subscribe" {
+				c.local.clear()
+			}
+		case *redis.Message:
+			var event invalidation
+			if err := json.Unmarshal([]byte(msg.Payload), &event); err != nil {
+				c.local.clear()
+				continue
+			}
+			if event.Origin != c.instanceID {
+				c.local.delete(event.Keys...)
+			}
+		}
+	}
+}
+
+// Close releases the Redis connection and stops listening for invalidations.
+func (c *RedisCache) Close() error {
+	if c == nil {
+		return nil
+	}
+	c.closeOnce.Do(func() { close(c.closed) })
+	if c.pubsub != nil {
+		_ = c.pubsub.Close()
+	}
+	return c.client.Close()
+}
+
+// Enabled returns whether Redis is currently reachable.
+func (c *RedisCache) Enabled() bool {
+	return c != nil && c.healthy.Load()
+}
+
+// Get retrieves a cached value into dest. Returns false when missing/disabled.
+func (c *RedisCache) Get(ctx context.Context, key string, dest interface{}) (bool, error) {
+	if !c.Enabled() {
+		return false, nil
+	}
+
+	key = c.key(key)
+	payload, found, err := c.getPayload(ctx, key)
+	if err != nil || !found {
+		return false, err
+	}
+
+	if err := c.codec.Decode(payload, dest); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// getPayload reads a prefixed key from the local tier, falling back to Redis.
+func (c *RedisCache) getPayload(ctx context.Context, key string) ([]byte, bool, error) {
+	if c.local == nil {
+		value, err := c.client.Get(ctx, key).Bytes()
+		return c.redisResult(value, err)
+	}
+
+	if payload, ok := c.local.get(key); ok {
+		c.observe(TierLocal, ResultHit)
+		return payload, true, nil
+	}
+	c.observe(TierLocal, ResultMiss)
+
+	// Read the remaining TTL in the same round trip so the local copy never
+	// outlives the Redis entry.
+	version := c.local.version()
+	pipe := c.client.Pipeline()
+	get := pipe.Get(ctx, key)
+	ttl := pipe.PTTL(ctx, key)
+	_, _ = pipe.Exec(ctx)
+
+	value, err := get.Bytes()
+	payload, found, err := c.redisResult(value, err)
+	if found {
+		c.local.setAt(key, payload, ttl.Val(), version)
+	}
+	return payload, found, err
+}
+
+func (c *RedisCache) redisResult(value []byte, err error) ([]byte, bool, error) {
+	switch {
+	case err == redis.Nil:
+		c.observe(TierRedis, ResultMiss)
+		return nil, false, nil
+	case err != nil:
+		c.observe(TierRedis, ResultError)
+		return nil, false, c.track(err)
+	}
+	c.observe(TierRedis, ResultHit)
+	return value, true, nil
+}
+
+// Set stores a value. When ttl <= 0, default TTL is used.
+func (c *RedisCache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	if !c.Enabled() {
+		return nil
+	}
+
+	if ttl <= 0 {
+		ttl = c.defaultTTL
+	}
+
+	payload, err := c.codec.Encode(value)
+	if err != nil {
+		return err
+	}
+
+	key = c.key(key)
+	if err := c.client.Set(ctx, key, payload, ttl).Err(); err != nil {
+		return c.track(err)
+	}
+	if c.local != nil {
+		c.local.set(key, payload, ttl)
+		c.publish(ctx, key)
+	}
+	return nil
+}
+
+// Delete removes keys from cache. It is attempted even while the cache is
+// disabled; keys that could not be removed are removed on reconnect.
+func (c *RedisCache) Delete(ctx context.Context, keys ...string) error {
+	if c == nil || len(keys) == 0 {
+		return nil
+	}
+
+	prefixed := make([]string, 0, len(keys))
+	for _, key := range keys {
+		prefixed = append(prefixed, c.key(key))
+	}
+
+	// One DEL per key so keys in different cluster slots can be removed together.
+	pipe := c.client.Pipeline()
+	for _, key := range prefixed {
+		pipe.Del(ctx, key)
+	}
+	_, err := pipe.Exec(ctx)
+	if err = c.track(err); err != nil && !c.Enabled() {
+		c.deferInvalidation(prefixed, nil)
+	}
+	if c.local != nil {
+		c.local.delete(prefixed...)
+		c.publish(ctx, prefixed...)
+	}
+	return err
+}
+
+// InvalidateTags increments the version of each tag in one transaction. In
+// Cluster mode the transaction is split per slot. Like Delete it is attempted
+// even while the cache is disabled, and replayed on reconnect if it fails.
+func (c *RedisCache) InvalidateTags(ctx context.Context, tags ...string) error {
+	if c == nil || len(tags) == 0 {
+		return nil
+	}
+
+	tagKeys := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tagKeys = append(tagKeys, c.key(tagKey(tag)))
+	}
+
+	pipe := c.client.TxPipeline()
+	for _, key := range tagKeys {
+		pipe.Incr(ctx, key)
+	}
+	_, err := pipe.Exec(ctx)
+	if err = c.track(err); err != nil && !c.Enabled() {
+		c.deferInvalidation(nil, tagKeys)
+	}
+	return err
+}
+
+// deferInvalidation records invalidations that did not reach an unreachable
+// Redis. Replaying them is safe: deleting a key twice, or bumping a tag twice,
+// only costs a cache miss.
+func (c *RedisCache) deferInvalidation(keys, tagKeys []string) {
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+	if c.pendingKeys == nil {
+		c.pendingKeys = map[string]struct{}{}
+		c.pendingTags = map[string]struct{}{}
+	}
+	for _, key := range keys {
+		c.pendingKeys[key] = struct{}{}
+	}
+	for _, key := range tagKeys {
+		c.pendingTags[key] = struct{}{}
+	}
+}
+
+// replayInvalidations applies the deferred invalidations and forgets them once
+// Redis has accepted them.
+func (c *RedisCache) replayInvalidations(ctx context.Context) error {
+	c.pendingMu.Lock()
+	keys := make([]string, 0, len(c.pendingKeys))
+	for key := range c.pendingKeys {
+		keys = append(keys, key)
+	}
+	tagKeys := make([]string, 0, len(c.pendingTags))
+	for key := range c.pendingTags {
+		tagKeys = append(tagKeys, key)
+	}
+	c.pendingMu.Unlock()
+
+	if len(keys) == 0 && len(tagKeys) == 0 {
+		return nil
+	}
+
+	pipe := c.client.Pipeline()
+	for _, key := range keys {
+		pipe.Del(ctx, key)
+	}
+	for _, key := range tagKeys {
+		pipe.Incr(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+	if c.local != nil && len(keys) > 0 {
+		c.publish(ctx, keys...)
+	}
+
+	c.pendingMu.Lock()
+	for _, key := range keys {
+		delete(c.pendingKeys, key)
+	}
+	for _, key := range tagKeys {
+		delete(c.pendingTags, key)
+	}
+	c.pendingMu.Unlock()
+	return nil
+}
+
+// tagVersions reads each tag version with its own GET, rather than MGET, so
+// tags hashing to different cluster slots work.
+func (c *RedisCache) tagVersions(ctx context.Context, tags []string) ([]string, error) {
+	pipe := c.client.Pipeline()
+	gets := make([]*redis.StringCmd, len(tags))
+	for i, tag := range tags {
+		gets[i] = pipe.Get(ctx, c.key(tagKey(tag)))
+	}
+	_, _ = pipe.Exec(ctx)
+
+	versions := make([]string, len(tags))
+	for i, get := range gets {
+		version, err := get.Result()
+		switch {
+		case err == redis.Nil:
+			version = "0"
+		case err != nil:
+			return nil, c.track(err)
+		}
+		versions[i] = version
+	}
+	return versions, nil
+}
+
+// track marks Redis unreachable when err is a connection failure rather than
+// a reply from Redis or the caller giving up, and returns err.
+func (c *RedisCache) track(err error) error {
+	var replyErr redis.Error
+	if err == nil || err == redis.Nil || errors.As(err, &replyErr) ||
+		errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	c.markDown()
+	return err
+}
+
+// markDown disables the cache and pings Redis until it answers again.
+func (c *RedisCache) markDown() {
+	c.healthy.Store(false)
+	if !c.retrying.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(reconnectInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-c.closed:
+				return
+			case <-ticker.C:
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), reconnectInterval)
+			err := c.client.Ping(ctx).Err()
+			if err == nil {
+				// Entries whose invalidation failed must not be served again.
+				err = c.replayInvalidations(ctx)
+			}
+			cancel()
+			if err == nil {
+				// Invalidations published while Redis was unreachable were lost.
+				if c.local != nil {
+					c.local.clear()
+				}
+				// Allow the next failure to start a new retry loop before
+				// commands resume.
+				c.retrying.Store(false)
+				c.healthy.Store(true)
+				return
+			}
+		}
+	}()
+}
+
+// publish tells other instances to drop their local copies of keys.
+func (c *RedisCache) publish(ctx context.Context, keys ...string) {
+	payload, err := json.Marshal(invalidation{Origin: c.instanceID, Keys: keys})
+	if err != nil {
+		return
+	}
+	_ = c.client.Publish(ctx, c.invalidationChannel(), payload).Err()
+}
+
+func (c *RedisCache) invalidationChannel() string {
+	return c.key("cache:invalidate")
+}
+
+func (c *RedisCache) observe(tier, result string) {
+	if c.observer != nil {
+		c.observer(tier, result)
+	}
+}
+
+func (c *RedisCache) key(key string) string {
+	return prefixKey(c.prefix, key)
+}
@@ -0,0 +1,237 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRedisCacheStartsDisabledWhenUnreachable(t *testing.T) {
+	// Reserve a port and release it so nothing is listening there.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	listener.Close()
+
+	c, err := New(Config{Enabled: true, Host: "127.0.0.1", Port: port, DefaultTTL: time.Minute}, "test")
+	if err == nil {
+		t.Fatal("expected a connection error")
+	}
+	defer c.Close()
+
+	if c.Enabled() {
+		t.Fatal("expected the cache to report disabled while Redis is unreachable")
+	}
+	if _, ok := c.(*RedisCache); !ok {
+		t.Fatalf("expected a Redis cache that keeps reconnecting, got %T", c)
+	}
+
+	value, err := GetOrLoad(context.Background(), c, "key", LoadOptions{}, func(ctx context.Context) (string, error) {
+		return "loaded", nil
+	})
+	if err != nil || value != "loaded" {
+		t.Fatalf("expected loads to bypass the cache, got %q (%v)", value, err)
+	}
+}
+
+func TestRedisCacheReplaysInvalidationsOnReconnect(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := listener.Addr().String()
+	_, port, _ := net.SplitHostPort(addr)
+	listener.Close()
+
+	c, err := NewRedisCache(Config{Host: "127.0.0.1", Port: port, DefaultTTL: time.Minute}, "test")
+	if err == nil {
+		t.Fatal("expected a connection error")
+	}
+	defer c.Close()
+
+	ctx := context.Background()
+	if err := c.Delete(ctx, "user:1"); err == nil {
+		t.Fatal("expected the delete to fail while Redis is unreachable")
+	}
+	if err := c.InvalidateTags(ctx, "users"); err == nil {
+		t.Fatal("expected the tag invalidation to fail while Redis is unreachable")
+	}
+
+	server := startFakeRedis(t, addr)
+	deadline := time.Now().Add(3 * reconnectInterval)
+	for !c.Enabled() && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	if !c.Enabled() {
+		t.Fatal("expected the cache to reconnect")
+	}
+
+	if !server.received("DEL test:user:1") || !server.received("INCR test:tag:users") {
+		t.Fatalf("expected deferred invalidations to be replayed before enabling, got %v", server.commands())
+	}
+}
+
+// fakeRedis answers just enough of RESP for the commands RedisCache sends,
+// and records them.
+type fakeRedis struct {
+	mu   sync.Mutex
+	seen []string
+}
+
+func startFakeRedis(t *testing.T, addr string) *fakeRedis {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	server := &fakeRedis{}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+func (s *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	queued := 0
+	inMulti := false
+
+	for {
+		args, err := readCommand(reader)
+		if err != nil {
+			return
+		}
+		name := strings.ToUpper(args[0])
+		s.mu.Lock()
+		s.seen = append(s.seen, strings.Join(append([]string{name}, args[1:]...), " "))
+		s.mu.Unlock()
+
+		var reply string
+		switch {
+		case name == "HELLO":
+			reply = "-ERR unknown command 'HELLO'\r\n"
+		case name == "MULTI":
+			inMulti, queued = true, 0
+			reply = "+OK\r\n"
+		case name == "EXEC":
+			reply = fmt.Sprintf("*%d\r\n%s", queued, strings.Repeat(":1\r\n", queued))
+			inMulti = false
+		case inMulti:
+			queued++
+			reply = "+QUEUED\r\n"
+		case name == "PING":
+			reply = "+PONG\r\n"
+		case name == "DEL" || name == "INCR" || name == "PUBLISH":
+			reply = ":1\r\n"
+		default:
+			reply = "+OK\r\n"
+		}
+		if _, err := conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+func (s *fakeRedis) received(command string) bool {
+	for _, seen := range s.commands() {
+		if seen == command {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *fakeRedis) commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.seen...)
+}
+
+// readCommand reads one RESP array of bulk strings.
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil || count < 1 {
+		return nil, fmt.Errorf("unexpected command header %q", line)
+	}
+
+	args := make([]string, count)
+	for i := range args {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(header, "$")))
+		if err != nil {
+			return nil, fmt.Errorf("unexpected bulk header %q", header)
+		}
+		value := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, value); err != nil {
+			return nil, err
+		}
+		args[i] = string(value[:size])
+	}
+	return args, nil
+}
+
+func TestUniversalOptionsSelectsTopology(t *testing.T) {
+	single, err := universalOptions(Config{Host: "redis", Port: "6379", DB: 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(single.Addrs) != 1 || single.Addrs[0] != "redis:6379" || single.DB != 2 {
+		t.Errorf("expected single node redis:6379 db 2, got %v db %d", single.Addrs, single.DB)
+	}
+
+	sentinel, err := universalOptions(Config{Addrs: []string{"s1:26379", "s2:26379"}, MasterName: "mymaster", Cluster: true, SentinelPassword: "secret"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sentinel.MasterName != "mymaster" || sentinel.IsClusterMode || sentinel.SentinelPassword != "secret" {
+		t.Errorf("expected a sentinel failover client, got %+v", sentinel)
+	}
+
+	cluster, err := universalOptions(Config{Addrs: []string{"cfg.cache.local:6379"}, Cluster: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cluster.IsClusterMode {
+		t.Error("expected cluster mode for a single configuration endpoint")
+	}
+}
+
+func TestUniversalOptionsLoadsTLS(t *testing.T) {
+	options, err := universalOptions(Config{Host: "redis", Port: "6380", TLS: TLSConfig{Enabled: true, ServerName: "redis.internal"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if options.TLSConfig == nil || options.TLSConfig.ServerName != "redis.internal" {
+		t.Fatalf("expected TLS with server name, got %+v", options.TLSConfig)
+	}
+
+	if _, err := New(Config{Enabled: true, TLS: TLSConfig{Enabled: true, CAFile: "does-not-exist.pem"}}, "test"); err == nil {
+		t.Error("expected an error for a missing CA file")
+	}
+}
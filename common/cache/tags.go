@@ -0,0 +1,38 @@
+package cache
+
+import (
+	"context"
+	"strings"
+)
+
+// Tags group cached entries so they can be invalidated together, for example
+// every cached page of a list. Each tag has a version that is folded into the
+// keys of the entries tagged with it. Invalidating a tag increments its
+// version, which atomically orphans every entry written under the previous
+// one; orphaned entries are left to expire.
+
+// taggedKey returns key qualified with the current version of each tag.
+func taggedKey(ctx context.Context, c Cache, key string, tags []string) (string, error) {
+	if !c.Enabled() || len(tags) == 0 {
+		return key, nil
+	}
+
+	versions, err := c.tagVersions(ctx, tags)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString(key)
+	for i, tag := range tags {
+		b.WriteString("|")
+		b.WriteString(tag)
+		b.WriteString("@")
+		b.WriteString(versions[i])
+	}
+	return b.String(), nil
+}
+
+func tagKey(tag string) string {
+	return "tag:" + tag
+}
@@ -3,7 +3,8 @@ package circuitbreaker
 import (
 	"context"
 	"github.com/RashadTanjim/enterprise-microservice-system/common/errors"
-	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sony/gobreaker"
@@ -11,8 +12,17 @@ import (
 
 // CircuitBreaker wraps gobreaker with custom configuration
 type CircuitBreaker struct {
-	breaker     *gobreaker.CircuitBreaker
+	breaker     *gobreaker.TwoStepCircuitBreaker
 	serviceName string
+	strategies  []TripStrategy
+	isFailure   func(err error) bool
+	onChange    func(name string, from gobreaker.State, to gobreaker.State)
+	override    atomic.Int32
+
+	// reportMu serializes outcome reports so ReadyToTrip sees the decision
+	// made for the call being reported.
+	reportMu sync.Mutex
+	trip     bool
 }
 
 // Config holds circuit breaker configuration
@@ -20,8 +30,28 @@ type Config struct {
 	MaxRequests uint32        // Max requests allowed in half-open state
 	Interval    time.Duration // Time period for counting failures
 	Timeout     time.Duration // Time to wait before transitioning from open to half-open
+	// Strategies decide when the breaker opens; it opens as soon as any of
+	// them trips. Empty uses FailureRatio(3, 0.5).
+	Strategies []TripStrategy
+	// IsFailure classifies call errors; errors it rejects count as
+	// successes. Nil uses IsFailure.
+	IsFailure func(err error) bool
+	// OnStateChange, when set, is called on every state change, including
+	// those made with Force. It may run while the breaker is locked, so it
+	// must not call the breaker's methods.
+	OnStateChange func(name string, from gobreaker.State, to gobreaker.State)
 }
 
+// Override pins a breaker in one state regardless of call outcomes.
+type Override int32
+
+// Overrides accepted by Force.
+const (
+	OverrideNone Override = iota
+	OverrideOpen
+	OverrideClosed
+)
+
 // DefaultConfig returns default circuit breaker configuration
 func DefaultConfig() Config {
 	return Config{
@@ -31,32 +61,56 @@ func DefaultConfig() Config {
 	}
 }
 
-// New creates a new circuit breaker
+// New creates a new circuit breaker and adds it to the registry, replacing
+// any breaker registered under the same name.
 func New(serviceName string, config Config) *CircuitBreaker {
+	cb := &CircuitBreaker{
+		serviceName: serviceName,
+		strategies:  config.Strategies,
+		isFailure:   config.IsFailure,
+		onChange:    config.OnStateChange,
+	}
+	if len(cb.strategies) == 0 {
+		cb.strategies = []TripStrategy{FailureRatio(3, 0.5)}
+	}
+	if cb.isFailure == nil {
+		cb.isFailure = IsFailure
+	}
+
 	settings := gobreaker.Settings{
 		Name:        serviceName,
 		MaxRequests: config.MaxRequests,
 		Interval:    config.Interval,
 		Timeout:     config.Timeout,
+		// Only called while reporting a failure, with reportMu held.
 		ReadyToTrip: func(counts gobreaker.Counts) bool {
-			// Open circuit if failure rate >= 50% and at least 3 requests
-			failureRatio := float64(counts.TotalFailures) / float64(counts.Requests)
-			return counts.Requests >= 3 && failureRatio >= 0.5
+			return cb.trip
 		},
 		OnStateChange: func(name string, from gobreaker.State, to gobreaker.State) {
-			fmt.Printf("[CircuitBreaker] %s: State changed from %s to %s\n", name, from.String(), to.String())
+			for _, strategy := range cb.strategies {
+				strategy.Reset()
+			}
+			// While forced the reported state does not follow gobreaker
+			if cb.Override() == OverrideNone {
+				cb.notify(from, to)
+			}
 		},
 	}
-
-	return &CircuitBreaker{
-		breaker:     gobreaker.NewCircuitBreaker(settings),
-		serviceName: serviceName,
-	}
+	cb.breaker = gobreaker.NewTwoStepCircuitBreaker(settings)
+	register(cb)
+	return cb
 }
 
 // Execute runs the given function with circuit breaker protection
-func (cb *CircuitBreaker) Execute(fn func() (interface{}, error)) (interface{}, error) {
-	result, err := cb.breaker.Execute(fn)
+func (cb *CircuitBreaker) Execute(fn func() (interface{}, error)) (result interface{}, err error) {
+	switch cb.Override() {
+	case OverrideOpen:
+		return nil, errors.NewCircuitOpen(cb.serviceName)
+	case OverrideClosed:
+		return fn()
+	}
+
+	done, err := cb.breaker.Allow()
 	if err != nil {
 		// Check if error is due to open circuit
 		if err == gobreaker.ErrOpenState {
@@ -64,9 +118,51 @@ func (cb *CircuitBreaker) Execute(fn func() (interface{}, error)) (interface{},
 		}
 		return nil, err
 	}
+
+	start := time.Now()
+	defer func() {
+		if e := recover(); e != nil {
+			cb.report(done, true, time.Since(start))
+			panic(e)
+		}
+	}()
+
+	result, err = fn()
+	cb.report(done, err != nil && cb.isFailure(err), time.Since(start))
+	if err != nil {
+		return nil, err
+	}
 	return result, nil
 }
 
+// report records the outcome of a call. While the breaker is closed every
+// strategy sees the call; a call that trips one is reported as a failure so
+// the breaker opens even when the call itself succeeded.
+func (cb *CircuitBreaker) report(done func(success bool), failure bool, elapsed time.Duration) {
+	cb.reportMu.Lock()
+	defer cb.reportMu.Unlock()
+
+	cb.trip = false
+	if cb.breaker.State() == gobreaker.StateClosed {
+		counts := cb.breaker.Counts()
+		if failure {
+			counts.TotalFailures++
+			counts.ConsecutiveFailures++
+			counts.ConsecutiveSuccesses = 0
+		} else {
+			counts.TotalSuccesses++
+			counts.ConsecutiveSuccesses++
+			counts.ConsecutiveFailures = 0
+		}
+		for _, strategy := range cb.strategies {
+			if strategy.Record(counts, failure, elapsed) {
+				cb.trip = true
+			}
+		}
+	}
+	done(!failure && !cb.trip)
+}
+
 // ExecuteWithContext runs the given function with circuit breaker protection and context
 func (cb *CircuitBreaker) ExecuteWithContext(ctx context.Context, fn func() (interface{}, error)) (interface{}, error) {
 	// Check context before executing
@@ -79,15 +175,49 @@ func (cb *CircuitBreaker) ExecuteWithContext(ctx context.Context, fn func() (int
 	return cb.Execute(fn)
 }
 
+// Name returns the name of the service the breaker protects
+func (cb *CircuitBreaker) Name() string {
+	return cb.serviceName
+}
+
 // State returns the current state of the circuit breaker
 func (cb *CircuitBreaker) State() gobreaker.State {
-	return cb.breaker.State()
+	switch cb.Override() {
+	case OverrideOpen:
+		return gobreaker.StateOpen
+	case OverrideClosed:
+		return gobreaker.StateClosed
+	default:
+		return cb.breaker.State()
+	}
+}
+
+// Force pins the breaker open or closed until it is forced again with
+// OverrideNone. A breaker forced closed passes every call through without
+// recording it; one forced open rejects every call.
+func (cb *CircuitBreaker) Force(override Override) {
+	from := cb.State()
+	cb.override.Store(int32(override))
+	if to := cb.State(); to != from {
+		cb.notify(from, to)
+	}
+}
+
+// Override returns the override set with Force
+func (cb *CircuitBreaker) Override() Override {
+	return Override(cb.override.Load())
 }
 
 // StateAsFloat returns the state as a float for metrics
 // 0 = Closed, 1 = Half-Open, 2 = Open
 func (cb *CircuitBreaker) StateAsFloat() float64 {
-	switch cb.breaker.State() {
+	return StateValue(cb.State())
+}
+
+// StateValue converts a state to the value used for metrics
+// 0 = Closed, 1 = Half-Open, 2 = Open
+func StateValue(state gobreaker.State) float64 {
+	switch state {
 	case gobreaker.StateClosed:
 		return 0
 	case gobreaker.StateHalfOpen:
@@ -103,3 +233,36 @@ func (cb *CircuitBreaker) StateAsFloat() float64 {
 func (cb *CircuitBreaker) Counts() gobreaker.Counts {
 	return cb.breaker.Counts()
 }
+
+// Snapshot describes the state of a breaker
+type Snapshot struct {
+	Name                 string `json:"name"`
+	State                string `json:"state"`
+	Forced               bool   `json:"forced"`
+	Requests             uint32 `json:"requests"`
+	TotalSuccesses       uint32 `json:"total_successes"`
+	TotalFailures        uint32 `json:"total_failures"`
+	ConsecutiveSuccesses uint32 `json:"consecutive_successes"`
+	ConsecutiveFailures  uint32 `json:"consecutive_failures"`
+}
+
+// Snapshot returns the current state and counts of the breaker
+func (cb *CircuitBreaker) Snapshot() Snapshot {
+	counts := cb.Counts()
+	return Snapshot{
+		Name:                 cb.serviceName,
+		State:                cb.State().String(),
+		Forced:               cb.Override() != OverrideNone,
+		Requests:             counts.Requests,
+		TotalSuccesses:       counts.TotalSuccesses,
+		TotalFailures:        counts.TotalFailures,
+		ConsecutiveSuccesses: counts.ConsecutiveSuccesses,
+		ConsecutiveFailures:  counts.ConsecutiveFailures,
+	}
+}
+
+func (cb *CircuitBreaker) notify(from, to gobreaker.State) {
+	if cb.onChange != nil {
+		cb.onChange(cb.serviceName, from, to)
+	}
+}
@@ -0,0 +1,269 @@
+package circuitbreaker
+
+import (
+	"context"
+	stderrors "errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/RashadTanjim/enterprise-microservice-system/common/cache"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/errors"
+
+	"github.com/sony/gobreaker"
+)
+
+var errUnavailable = errors.New(errors.ErrCodeServiceUnavail, "unavailable", nil)
+
+func call(cb *CircuitBreaker, err error, delay time.Duration) error {
+	_, callErr := cb.Execute(func() (interface{}, error) {
+		time.Sleep(delay)
+		return nil, err
+	})
+	return callErr
+}
+
+func TestDefaultStrategyIgnoresClientErrors(t *testing.T) {
+	cb := New("users", Config{MaxRequests: 1, Interval: time.Minute, Timeout: time.Minute})
+
+	for i := 0; i < 10; i++ {
+		_ = call(cb, errors.NewNotFound("user"), 0)
+	}
+	if cb.State() != gobreaker.StateClosed {
+		t.Fatalf("expected not found errors to keep the breaker closed, got %s", cb.State())
+	}
+
+	for i := 0; i < 10; i++ {
+		_ = call(cb, errUnavailable, 0)
+	}
+	if cb.State() != gobreaker.StateOpen {
+		t.Fatalf("expected failures to open the breaker, got %s", cb.State())
+	}
+
+	var appErr *errors.AppError
+	if err := call(cb, nil, 0); !stderrors.As(err, &appErr) || appErr.Code != errors.ErrCodeCircuitOpen {
+		t.Fatalf("expected circuit open error, got %v", err)
+	}
+}
+
+func TestConsecutiveFailures(t *testing.T) {
+	cb := New("users", Config{Timeout: time.Minute, Strategies: []TripStrategy{ConsecutiveFailures(3)}})
+
+	_ = call(cb, errUnavailable, 0)
+	_ = call(cb, errUnavailable, 0)
+	_ = call(cb, nil, 0)
+	_ = call(cb, errUnavailable, 0)
+	_ = call(cb, errUnavailable, 0)
+	if cb.State() != gobreaker.StateClosed {
+		t.Fatalf("expected a success to reset the streak, got %s", cb.State())
+	}
+
+	_ = call(cb, errUnavailable, 0)
+	if cb.State() != gobreaker.StateOpen {
+		t.Fatalf("expected three failures in a row to open the breaker, got %s", cb.State())
+	}
+}
+
+func TestFailureRateUsesSlidingWindow(t *testing.T) {
+	cb := New("users", Config{Timeout: time.Minute, Strategies: []TripStrategy{FailureRate(4, 4, 0.5)}})
+
+	_ = call(cb, errUnavailable, 0)
+	_ = call(cb, nil, 0)
+	_ = call(cb, nil, 0)
+	if cb.State() != gobreaker.StateClosed {
+		t.Fatalf("expected the breaker to wait for the minimum calls, got %s", cb.State())
+	}
+
+	// Window: fail, ok, ok, ok -> 25%; then ok, ok, ok, fail -> 25%.
+	_ = call(cb, nil, 0)
+	_ = call(cb, errUnavailable, 0)
+	if cb.State() != gobreaker.StateClosed {
+		t.Fatalf("expected old failures to leave the window, got %s", cb.State())
+	}
+
+	_ = call(cb, errUnavailable, 0)
+	if cb.State() != gobreaker.StateOpen {
+		t.Fatalf("expected half of the last calls failing to open the breaker, got %s", cb.State())
+	}
+}
+
+func TestSlowCallRateTripsOnSlowSuccesses(t *testing.T) {
+	cb := New("users", Config{
+		Timeout:    time.Minute,
+		Strategies: []TripStrategy{SlowCallRate(2, 2, 20*time.Millisecond, 1)},
+	})
+
+	if err := call(cb, nil, 30*time.Millisecond); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := call(cb, nil, 30*time.Millisecond); err != nil {
+		t.Fatalf("expected the slow call itself to succeed, got %v", err)
+	}
+	if cb.State() != gobreaker.StateOpen {
+		t.Fatalf("expected slow calls to open the breaker, got %s", cb.State())
+	}
+}
+
+func TestIsFailure(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{context.Canceled, false},
+		{errors.NewNotFound("user"), false},
+		{errors.NewValidation("bad"), false},
+		{errors.NewInternal("boom", nil), true},
+		{context.DeadlineExceeded, true},
+		{errUnavailable, true},
+	}
+	for _, tc := range cases {
+		if got := IsFailure(tc.err); got != tc.want {
+			t.Errorf("IsFailure(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestForceAndStateChangeHook(t *testing.T) {
+	var transitions []string
+	cb := New("force-test", Config{
+		Timeout:    time.Minute,
+		Strategies: []TripStrategy{ConsecutiveFailures(1)},
+		OnStateChange: func(name string, from gobreaker.State, to gobreaker.State) {
+			transitions = append(transitions, name+":"+from.String()+"->"+to.String())
+		},
+	})
+
+	cb.Force(OverrideOpen)
+	calls := 0
+	if err := call(cb, nil, 0); err == nil {
+		t.Fatal("expected a breaker forced open to reject calls")
+	}
+	if _, err := cb.Execute(func() (interface{}, error) { calls++; return nil, nil }); err == nil || calls != 0 {
+		t.Fatalf("expected the call not to run, got %d calls (%v)", calls, err)
+	}
+
+	cb.Force(OverrideClosed)
+	for i := 0; i < 3; i++ {
+		_ = call(cb, errUnavailable, 0)
+	}
+	if snapshot := cb.Snapshot(); snapshot.State != "closed" || !snapshot.Forced || snapshot.Requests != 0 {
+		t.Fatalf("expected failures to pass through a breaker forced closed, got %+v", snapshot)
+	}
+
+	cb.Force(OverrideNone)
+	_ = call(cb, errUnavailable, 0)
+	if cb.State() != gobreaker.StateOpen {
+		t.Fatalf("expected the breaker to trip once released, got %s", cb.State())
+	}
+
+	want := []string{"force-test:closed->open", "force-test:open->closed", "force-test:closed->open"}
+	if len(transitions) != len(want) {
+		t.Fatalf("expected transitions %v, got %v", want, transitions)
+	}
+	for i := range want {
+		if transitions[i] != want[i] {
+			t.Fatalf("expected transitions %v, got %v", want, transitions)
+		}
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	first := New("registry-b", Config{})
+	second := New("registry-a", Config{})
+
+	if cb, ok := Lookup("registry-b"); !ok || cb != first {
+		t.Fatal("expected to find the registered breaker")
+	}
+	if _, ok := Lookup("registry-missing"); ok {
+		t.Fatal("expected an unknown breaker not to be found")
+	}
+
+	replacement := New("registry-b", Config{})
+	var breakers []*CircuitBreaker
+	for _, cb := range List() {
+		if cb.Name() == "registry-a" || cb.Name() == "registry-b" {
+			breakers = append(breakers, cb)
+		}
+	}
+	if len(breakers) != 2 || breakers[0] != second || breakers[1] != replacement {
+		t.Fatalf("expected breakers ordered by name with the latest registration, got %v", breakers)
+	}
+}
+
+func TestOverrideStoreSharesForcedStates(t *testing.T) {
+	shared, err := cache.NewMemoryCache(cache.Config{DefaultTTL: time.Minute})
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	cb := New("override-test", Config{})
+	ctx := context.Background()
+
+	origin := NewOverrideStore(shared, time.Hour)
+	defer origin.Close()
+	if err := origin.Force(ctx, cb, OverrideOpen); err != nil {
+		t.Fatalf("Force() error = %v", err)
+	}
+
+	// Another replica has not seen the override yet.
+	cb.Force(OverrideNone)
+	replica := NewOverrideStore(shared, time.Hour)
+	defer replica.Close()
+	replica.Sync(ctx)
+	if cb.Override() != OverrideOpen {
+		t.Fatalf("expected the stored override to be applied, got %v", cb.Override())
+	}
+
+	local := NewOverrideStore(nil, time.Hour)
+	defer local.Close()
+	if err := local.Force(ctx, cb, OverrideClosed); !stderrors.Is(err, ErrOverrideNotShared) || cb.Override() != OverrideClosed {
+		t.Fatalf("expected a local-only override to be applied and reported, got %v (%v)", cb.Override(), err)
+	}
+}
+
+// unreachableCache reports itself disabled while down, like a Redis cache
+// that lost its connection.
+type unreachableCache struct {
+	cache.Cache
+	down atomic.Bool
+}
+
+func (c *unreachableCache) Enabled() bool {
+	return !c.down.Load() && c.Cache.Enabled()
+}
+
+func TestOverrideStoreWritesPendingOverrideOnRecovery(t *testing.T) {
+	memory, err := cache.NewMemoryCache(cache.Config{DefaultTTL: time.Minute})
+	if err != nil {
+		t.Fatalf("failed to create cache: %v", err)
+	}
+	shared := &unreachableCache{Cache: memory}
+	cb := New("override-pending-test", Config{})
+	ctx := context.Background()
+
+	store := NewOverrideStore(shared, time.Hour)
+	defer store.Close()
+	if err := store.Force(ctx, cb, OverrideClosed); err != nil {
+		t.Fatalf("Force() error = %v", err)
+	}
+
+	shared.down.Store(true)
+	if err := store.Force(ctx, cb, OverrideOpen); !stderrors.Is(err, ErrOverrideNotShared) {
+		t.Fatalf("expected the override to be reported as not shared, got %v", err)
+	}
+
+	// Once the cache is back the older stored value must not win.
+	shared.down.Store(false)
+	store.Sync(ctx)
+	if cb.Override() != OverrideOpen {
+		t.Fatalf("expected the pending override to be kept, got %v", cb.Override())
+	}
+
+	cb.Force(OverrideNone)
+	replica := NewOverrideStore(shared, time.Hour)
+	defer replica.Close()
+	replica.Sync(ctx)
+	if cb.Override() != OverrideOpen {
+		t.Fatalf("expected the pending override to reach other replicas, got %v", cb.Override())
+	}
+}
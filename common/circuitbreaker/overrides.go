@@ -0,0 +1,142 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/RashadTanjim/enterprise-microservice-system/common/cache"
+)
+
+// overrideTTL is how long a forced state stays in the shared store, so a
+// replica started within that time still picks it up.
+const overrideTTL = 30 * 24 * time.Hour
+
+// ErrOverrideNotShared is returned by OverrideStore.Force when the override
+// was applied in this process but could not be stored for other replicas yet.
+var ErrOverrideNotShared = errors.New("circuit breaker override applied to this instance only")
+
+// OverrideStore shares forced states between replicas. Force applies an
+// override locally and records it in a shared cache; every replica polls the
+// cache and applies the overrides recorded by others. An override that could
+// not be recorded stays pending: it is written on the first sync after the
+// cache recovers, and until then the stored value does not replace it.
+// Breakers forced through CircuitBreaker.Force directly stay local.
+type OverrideStore struct {
+	cache     cache.Cache
+	interval  time.Duration
+	closed    chan struct{}
+	closeOnce sync.Once
+
+	mu      sync.Mutex
+	pending map[string]Override
+}
+
+// NewOverrideStore creates a store on top of c, which should be Redis so
+// replicas see each other's overrides, and starts applying stored overrides
+// to the registered breakers every interval.
+func NewOverrideStore(c cache.Cache, interval time.Duration) *OverrideStore {
+	if c == nil {
+		c = cache.NewNoopCache()
+	}
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	s := &OverrideStore{
+		cache:    c,
+		interval: interval,
+		closed:   make(chan struct{}),
+		pending:  make(map[string]Override),
+	}
+	go s.run()
+	return s
+}
+
+// Force pins cb in this process and records the override for the other
+// replicas. The local override is applied even when recording fails, and is
+// then kept pending until Sync can record it.
+func (s *OverrideStore) Force(ctx context.Context, cb *CircuitBreaker, override Override) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cb.Force(override)
+	if err := s.store(ctx, cb.Name(), override); err != nil {
+		s.pending[cb.Name()] = override
+		return err
+	}
+	delete(s.pending, cb.Name())
+	return nil
+}
+
+// Sync records pending overrides, then applies the stored override of every
+// registered breaker. Breakers without a stored override, or whose own
+// override is still pending, keep their current state.
+func (s *OverrideStore) Sync(ctx context.Context) {
+	if !s.cache.Enabled() {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for name, override := range s.pending {
+		if err := s.store(ctx, name, override); err == nil {
+			delete(s.pending, name)
+		}
+	}
+	for _, cb := range List() {
+		if _, ok := s.pending[cb.Name()]; ok {
+			continue
+		}
+		var override Override
+		found, err := s.cache.Get(ctx, overrideKey(cb.Name()), &override)
+		if err != nil || !found {
+			continue
+		}
+		if override != cb.Override() {
+			cb.Force(override)
+		}
+	}
+}
+
+// store records override for the breaker called name.
+func (s *OverrideStore) store(ctx context.Context, name string, override Override) error {
+	if !s.cache.Enabled() {
+		return ErrOverrideNotShared
+	}
+	if err := s.cache.Set(ctx, overrideKey(name), override, overrideTTL); err != nil {
+		return errors.Join(ErrOverrideNotShared, err)
+	}
+	return nil
+}
+
+// Close stops applying stored overrides. It does not close the cache.
+func (s *OverrideStore) Close() {
+	if s == nil {
+		return
+	}
+	s.closeOnce.Do(func() { close(s.closed) })
+}
+
+func (s *OverrideStore) run() {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), s.interval)
+		s.Sync(ctx)
+		cancel()
+
+		select {
+		case <-s.closed:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func overrideKey(name string) string {
+	return "override:" + name
+}
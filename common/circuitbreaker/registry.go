@@ -0,0 +1,41 @@
+package circuitbreaker
+
+import (
+	"sort"
+	"sync"
+)
+
+// registry holds every breaker created in the process by name.
+var registry = struct {
+	sync.RWMutex
+	breakers map[string]*CircuitBreaker
+}{breakers: map[string]*CircuitBreaker{}}
+
+func register(cb *CircuitBreaker) {
+	registry.Lock()
+	defer registry.Unlock()
+	registry.breakers[cb.serviceName] = cb
+}
+
+// Lookup returns the breaker registered for serviceName
+func Lookup(serviceName string) (*CircuitBreaker, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	cb, ok := registry.breakers[serviceName]
+	return cb, ok
+}
+
+// List returns every registered breaker ordered by name
+func List() []*CircuitBreaker {
+	registry.RLock()
+	breakers := make([]*CircuitBreaker, 0, len(registry.breakers))
+	for _, cb := range registry.breakers {
+		breakers = append(breakers, cb)
+	}
+	registry.RUnlock()
+
+	sort.Slice(breakers, func(i, j int) bool {
+		return breakers[i].serviceName < breakers[j].serviceName
+	})
+	return breakers
+}
@@ -0,0 +1,169 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	apperrors "github.com/RashadTanjim/enterprise-microservice-system/common/errors"
+
+	"github.com/sony/gobreaker"
+)
+
+// TripStrategy decides when a closed breaker opens. A strategy keeps state,
+// so each breaker needs its own instance.
+type TripStrategy interface {
+	// Record is called after every call made while the breaker is closed.
+	// counts already include the call. It reports whether the breaker
+	// should open.
+	Record(counts gobreaker.Counts, failure bool, elapsed time.Duration) bool
+	// Reset forgets recorded calls. It is called on every state change.
+	Reset()
+}
+
+// FailureRatio opens the breaker on a failure once at least minRequests calls
+// were made in the current Interval and the failure ratio reaches ratio.
+// FailureRatio(3, 0.5) is the default strategy.
+func FailureRatio(minRequests uint32, ratio float64) TripStrategy {
+	return &failureRatio{minRequests: minRequests, ratio: ratio}
+}
+
+type failureRatio struct {
+	minRequests uint32
+	ratio       float64
+}
+
+func (s *failureRatio) Record(counts gobreaker.Counts, failure bool, elapsed time.Duration) bool {
+	if !failure || counts.Requests < s.minRequests {
+		return false
+	}
+	return float64(counts.TotalFailures)/float64(counts.Requests) >= s.ratio
+}
+
+func (s *failureRatio) Reset() {}
+
+// ConsecutiveFailures opens the breaker after n failures in a row.
+func ConsecutiveFailures(n uint32) TripStrategy {
+	return &consecutiveFailures{n: n}
+}
+
+type consecutiveFailures struct {
+	n uint32
+}
+
+func (s *consecutiveFailures) Record(counts gobreaker.Counts, failure bool, elapsed time.Duration) bool {
+	return failure && counts.ConsecutiveFailures >= s.n
+}
+
+func (s *consecutiveFailures) Reset() {}
+
+// FailureRate opens the breaker when at least rate of the last window calls
+// failed, once minCalls calls have been recorded.
+func FailureRate(window, minCalls int, rate float64) TripStrategy {
+	return &failureRate{window: newSlidingWindow(window, minCalls, rate)}
+}
+
+type failureRate struct {
+	window *slidingWindow
+}
+
+func (s *failureRate) Record(counts gobreaker.Counts, failure bool, elapsed time.Duration) bool {
+	return s.window.add(failure)
+}
+
+func (s *failureRate) Reset() {
+	s.window.reset()
+}
+
+// SlowCallRate opens the breaker when at least rate of the last window calls
+// took slowCall or longer, once minCalls calls have been recorded. Failed
+// calls are counted by their duration like any other.
+func SlowCallRate(window, minCalls int, slowCall time.Duration, rate float64) TripStrategy {
+	return &slowCallRate{window: newSlidingWindow(window, minCalls, rate), slowCall: slowCall}
+}
+
+type slowCallRate struct {
+	window   *slidingWindow
+	slowCall time.Duration
+}
+
+func (s *slowCallRate) Record(counts gobreaker.Counts, failure bool, elapsed time.Duration) bool {
+	return s.window.add(elapsed >= s.slowCall)
+}
+
+func (s *slowCallRate) Reset() {
+	s.window.reset()
+}
+
+// slidingWindow tracks which of the last calls matched a condition.
+type slidingWindow struct {
+	mu       sync.Mutex
+	outcomes []bool
+	next     int
+	recorded int
+	matched  int
+	minCalls int
+	rate     float64
+}
+
+func newSlidingWindow(size, minCalls int, rate float64) *slidingWindow {
+	if size <= 0 {
+		size = 1
+	}
+	if minCalls > size {
+		minCalls = size
+	}
+	return &slidingWindow{outcomes: make([]bool, size), minCalls: minCalls, rate: rate}
+}
+
+// add records an outcome and reports whether the matched rate reached the
+// threshold.
+func (w *slidingWindow) add(matched bool) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.recorded == len(w.outcomes) {
+		if w.outcomes[w.next] {
+			w.matched--
+		}
+	} else {
+		w.recorded++
+	}
+	w.outcomes[w.next] = matched
+	if matched {
+		w.matched++
+	}
+	w.next = (w.next + 1) % len(w.outcomes)
+
+	return w.recorded >= w.minCalls && float64(w.matched)/float64(w.recorded) >= w.rate
+}
+
+func (w *slidingWindow) reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.next, w.recorded, w.matched = 0, 0, 0
+}
+
+// IsFailure is the default error classifier. Errors that mean the dependency
+// answered correctly, such as not found or validation errors, and calls
+// canceled by the caller do not count as failures.
+func IsFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) {
+		switch appErr.Code {
+		case apperrors.ErrCodeNotFound,
+			apperrors.ErrCodeBadRequest,
+			apperrors.ErrCodeValidation,
+			apperrors.ErrCodeConflict,
+			apperrors.ErrCodeUnauthorized,
+			apperrors.ErrCodeForbidden:
+			return false
+		}
+	}
+	return true
+}
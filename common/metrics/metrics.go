@@ -11,10 +11,16 @@ import (
 
 // Metrics holds all Prometheus metrics
 type Metrics struct {
-	RequestsTotal   *prometheus.CounterVec
-	RequestDuration *prometheus.HistogramVec
-	ErrorsTotal     *prometheus.CounterVec
-	CircuitState    *prometheus.GaugeVec
+	RequestsTotal      *prometheus.CounterVec
+	RequestDuration    *prometheus.HistogramVec
+	ErrorsTotal        *prometheus.CounterVec
+	CircuitState       *prometheus.GaugeVec
+	CircuitTransitions *prometheus.CounterVec
+	RevocationCheck    *prometheus.HistogramVec
+	CacheLookups       *prometheus.CounterVec
+	BulkheadInFlight   *prometheus.GaugeVec
+	BulkheadQueued     *prometheus.GaugeVec
+	BulkheadRejections *prometheus.CounterVec
 }
 
 // NewMetrics creates and registers Prometheus metrics
@@ -49,6 +55,49 @@ func NewMetrics(serviceName string) *Metrics {
 			},
 			[]string{"service"},
 		),
+		CircuitTransitions: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: serviceName + "_circuit_breaker_transitions_total",
+				Help: "Circuit breaker state changes by service and states (closed, half-open, open)",
+			},
+			[]string{"service", "from", "to"},
+		),
+		RevocationCheck: promauto.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    serviceName + "_token_revocation_check_duration_seconds",
+				Help:    "Token revocation check latency in seconds by result (valid, revoked, error)",
+				Buckets: prometheus.ExponentialBuckets(0.0001, 2, 14),
+			},
+			[]string{"result"},
+		),
+		CacheLookups: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: serviceName + "_cache_lookups_total",
+				Help: "Cache lookups by tier (local, redis) and result (hit, miss, error)",
+			},
+			[]string{"tier", "result"},
+		),
+		BulkheadInFlight: promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: serviceName + "_bulkhead_in_flight",
+				Help: "Calls running inside a dependency's bulkhead",
+			},
+			[]string{"service"},
+		),
+		BulkheadQueued: promauto.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: serviceName + "_bulkhead_queued",
+				Help: "Calls waiting for a slot in a dependency's bulkhead",
+			},
+			[]string{"service"},
+		),
+		BulkheadRejections: promauto.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: serviceName + "_bulkhead_rejections_total",
+				Help: "Calls rejected by a dependency's bulkhead by reason (queue_full, timeout)",
+			},
+			[]string{"service", "reason"},
+		),
 	}
 
 	return metrics
@@ -93,3 +142,29 @@ func (m *Metrics) RecordError(errorType string) {
 func (m *Metrics) SetCircuitState(service string, state float64) {
 	m.CircuitState.WithLabelValues(service).Set(state)
 }
+
+// ObserveCircuitTransition records a circuit breaker state change
+func (m *Metrics) ObserveCircuitTransition(service, from, to string) {
+	m.CircuitTransitions.WithLabelValues(service, from, to).Inc()
+}
+
+// ObserveRevocationCheck records the latency and result of a token revocation check
+func (m *Metrics) ObserveRevocationCheck(result string, duration time.Duration) {
+	m.RevocationCheck.WithLabelValues(result).Observe(duration.Seconds())
+}
+
+// ObserveCacheLookup records a cache lookup in one tier
+func (m *Metrics) ObserveCacheLookup(tier, result string) {
+	m.CacheLookups.WithLabelValues(tier, result).Inc()
+}
+
+// ObserveBulkhead records the running and waiting calls of a bulkhead
+func (m *Metrics) ObserveBulkhead(service string, inFlight, queued int) {
+	m.BulkheadInFlight.WithLabelValues(service).Set(float64(inFlight))
+	m.BulkheadQueued.WithLabelValues(service).Set(float64(queued))
+}
+
+// ObserveBulkheadRejection records a call rejected by a bulkhead
+func (m *Metrics) ObserveBulkheadRejection(service, reason string) {
+	m.BulkheadRejections.WithLabelValues(service, reason).Inc()
+}
@@ -11,36 +11,161 @@ import (
 )
 
 const (
-	contextKeyAuthClaims  = "auth_claims"
-	contextKeyAuthRoles   = "auth_roles"
-	contextKeyAuthSubject = "auth_subject"
+	contextKeyAuthClaims      = "auth_claims"
+	contextKeyAuthRoles       = "auth_roles"
+	contextKeyAuthSubject     = "auth_subject"
+	contextKeyAuthPermissions = "auth_permissions"
+	contextKeyAuthMethod      = "auth_method"
+	contextKeyAuthActor       = "auth_actor"
+	// Roles and permissions withheld because the token is not MFA-verified.
+	contextKeyMFAPendingRoles       = "auth_mfa_pending_roles"
+	contextKeyMFAPendingPermissions = "auth_mfa_pending_permissions"
 )
 
-// AuthMiddleware validates JWT tokens and stores claims in the request context.
+// Authentication methods reported by GetAuthMethod.
+const (
+	AuthMethodJWT    = "jwt"
+	AuthMethodAPIKey = "api_key"
+)
+
+var defaultPermissions = auth.NewRolePermissions(auth.DefaultRoleGrants())
+
+var errMFARequired = apperrors.New(apperrors.ErrCodeForbidden, "multi-factor authentication required", nil)
+
+// AuthMiddleware authenticates a Bearer JWT or, when no Authorization header is
+// sent, an X-API-Key header, and stores the caller's claims in the request context.
+// The claims, and the bearer token itself, are also attached to the request's
+// context.Context (see auth.ClaimsFromContext and auth.TokenFromContext) so
+// outbound clients can delegate on the caller's behalf.
 func AuthMiddleware(cfg auth.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		token, err := extractBearerToken(c.GetHeader("Authorization"))
+		var (
+			claims *auth.Claims
+			token  string
+			method string
+			err    error
+		)
+		if c.GetHeader("Authorization") == "" && c.GetHeader(auth.APIKeyHeader) != "" {
+			method = AuthMethodAPIKey
+			claims, err = authenticateAPIKey(c, cfg)
+		} else {
+			method = AuthMethodJWT
+			token, claims, err = authenticateBearer(c, cfg)
+		}
 		if err != nil {
-			response.Error(c, apperrors.New(apperrors.ErrCodeUnauthorized, err.Error(), nil))
+			response.Error(c, err)
 			c.Abort()
 			return
 		}
 
-		claims, err := auth.ParseToken(cfg, token)
-		if err != nil {
-			response.Error(c, apperrors.New(apperrors.ErrCodeUnauthorized, "invalid or expired token", err))
-			c.Abort()
-			return
+		if cfg.Revocations != nil {
+			// Revocation lookup errors fail open; the checker reports them via metrics.
+			if revoked, _ := cfg.Revocations.IsRevoked(c.Request.Context(), claims); revoked {
+				response.Error(c, apperrors.New(apperrors.ErrCodeUnauthorized, "token has been revoked", nil))
+				c.Abort()
+				return
+			}
+		}
+
+		permissions := cfg.Permissions
+		if permissions == nil {
+			permissions = defaultPermissions
+		}
+
+		roles, pending := claims.Roles, []string(nil)
+		if len(cfg.MFARequiredRoles) > 0 && !claims.MFAVerified() {
+			roles, pending = splitRoles(claims.Roles, cfg.MFARequiredRoles)
 		}
 
 		c.Set(contextKeyAuthClaims, claims)
 		c.Set(contextKeyAuthRoles, claims.Roles)
 		c.Set(contextKeyAuthSubject, claims.Subject)
+		c.Set(contextKeyAuthPermissions, permissions.Resolve(roles))
+		c.Set(contextKeyAuthMethod, method)
+		if actor := claims.ActorSubject(); actor != "" {
+			c.Set(contextKeyAuthActor, actor)
+		}
+		ctx := auth.ContextWithClaims(c.Request.Context(), claims)
+		if token != "" {
+			ctx = auth.ContextWithToken(ctx, token)
+		}
+		c.Request = c.Request.WithContext(ctx)
+		if len(pending) > 0 {
+			c.Set(contextKeyMFAPendingRoles, pending)
+			c.Set(contextKeyMFAPendingPermissions, permissions.Resolve(pending))
+		}
 		c.Next()
 	}
 }
 
-// RequireRoles enforces role-based authorization for handlers.
+func authenticateBearer(c *gin.Context, cfg auth.Config) (string, *auth.Claims, error) {
+	token, err := extractBearerToken(c.GetHeader("Authorization"))
+	if err != nil {
+		return "", nil, apperrors.New(apperrors.ErrCodeUnauthorized, err.Error(), nil)
+	}
+
+	claims, err := auth.ParseToken(cfg, token)
+	if err != nil {
+		return "", nil, apperrors.New(apperrors.ErrCodeUnauthorized, "invalid or expired token", err)
+	}
+	return token, claims, nil
+}
+
+func authenticateAPIKey(c *gin.Context, cfg auth.Config) (*auth.Claims, error) {
+	if cfg.APIKeys == nil {
+		return nil, apperrors.New(apperrors.ErrCodeUnauthorized, "api keys are not accepted", nil)
+	}
+
+	principal, err := cfg.APIKeys.VerifyAPIKey(c.Request.Context(), c.GetHeader(auth.APIKeyHeader))
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidAPIKey) {
+			return nil, apperrors.New(apperrors.ErrCodeUnauthorized, "invalid or expired api key", nil)
+		}
+		return nil, apperrors.New(apperrors.ErrCodeServiceUnavail, "api key verification unavailable", err)
+	}
+	return principal.Claims(), nil
+}
+
+// RequirePermission enforces that the caller's roles grant at least one of the
+// given permissions. Permissions that would only be granted by a role awaiting
+// MFA verification are rejected with a distinct message.
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if len(permissions) == 0 {
+			c.Next()
+			return
+		}
+
+		if _, exists := c.Get(contextKeyAuthPermissions); !exists {
+			response.Error(c, apperrors.New(apperrors.ErrCodeUnauthorized, "missing authentication context", nil))
+			c.Abort()
+			return
+		}
+
+		for _, permission := range permissions {
+			if HasPermission(c, permission) {
+				c.Next()
+				return
+			}
+		}
+
+		pending := c.GetStringSlice(contextKeyMFAPendingPermissions)
+		for _, permission := range permissions {
+			if auth.Grants(pending, permission) {
+				response.Error(c, errMFARequired)
+				c.Abort()
+				return
+			}
+		}
+
+		response.Error(c, apperrors.New(apperrors.ErrCodeForbidden, "insufficient permissions", nil))
+		c.Abort()
+	}
+}
+
+// RequireRoles enforces role-based authorization for handlers. Prefer
+// RequirePermission so new roles can be granted access through configuration.
+// Roles listed in auth.Config.MFARequiredRoles only count for MFA-verified tokens.
 func RequireRoles(roles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if len(roles) == 0 {
@@ -62,8 +187,13 @@ func RequireRoles(roles ...string) gin.HandlerFunc {
 			return
 		}
 
-		if !hasAnyRole(assignedRoles, roles) {
-			response.Error(c, apperrors.New(apperrors.ErrCodeForbidden, "insufficient permissions", nil))
+		verified, pending := splitRoles(assignedRoles, c.GetStringSlice(contextKeyMFAPendingRoles))
+		if !hasAnyRole(verified, roles) {
+			if hasAnyRole(pending, roles) {
+				response.Error(c, errMFARequired)
+			} else {
+				response.Error(c, apperrors.New(apperrors.ErrCodeForbidden, "insufficient permissions", nil))
+			}
 			c.Abort()
 			return
 		}
@@ -90,6 +220,18 @@ func extractBearerToken(headerValue string) (string, error) {
 	return token, nil
 }
 
+// splitRoles separates roles into those not listed in withheld and those that are.
+func splitRoles(roles, withheld []string) (kept, removed []string) {
+	for _, role := range roles {
+		if hasAnyRole([]string{role}, withheld) {
+			removed = append(removed, role)
+		} else {
+			kept = append(kept, role)
+		}
+	}
+	return kept, removed
+}
+
 func hasAnyRole(assigned []string, required []string) bool {
 	for _, role := range assigned {
 		for _, needed := range required {
@@ -122,3 +264,58 @@ func GetAuthRoles(c *gin.Context) ([]string, bool) {
 	roles, ok := value.([]string)
 	return roles, ok
 }
+
+// GetAuthPermissions retrieves the permissions granted to the caller's roles.
+func GetAuthPermissions(c *gin.Context) ([]string, bool) {
+	value, exists := c.Get(contextKeyAuthPermissions)
+	if !exists {
+		return nil, false
+	}
+
+	permissions, ok := value.([]string)
+	return permissions, ok
+}
+
+// HasPermission reports whether the caller has been granted a permission.
+func HasPermission(c *gin.Context, permission string) bool {
+	permissions, _ := GetAuthPermissions(c)
+	return auth.Grants(permissions, permission)
+}
+
+// GetAuthMethod reports whether the caller authenticated with a JWT or an API key.
+func GetAuthMethod(c *gin.Context) (string, bool) {
+	value, exists := c.Get(contextKeyAuthMethod)
+	if !exists {
+		return "", false
+	}
+
+	method, ok := value.(string)
+	return method, ok
+}
+
+// GetAuthPrincipal identifies the caller for audit columns: the subject, plus
+// the acting party when the token is delegated (see auth.Claims.Principal).
+func GetAuthPrincipal(c *gin.Context) (string, bool) {
+	value, exists := c.Get(contextKeyAuthClaims)
+	if !exists {
+		return "", false
+	}
+
+	claims, ok := value.(*auth.Claims)
+	if !ok {
+		return "", false
+	}
+	return claims.Principal(), true
+}
+
+// GetAuthActor retrieves the service or client acting on behalf of the subject
+// when the caller presented a delegated token.
+func GetAuthActor(c *gin.Context) (string, bool) {
+	value, exists := c.Get(contextKeyAuthActor)
+	if !exists {
+		return "", false
+	}
+
+	actor, ok := value.(string)
+	return actor, ok
+}
@@ -1,6 +1,10 @@
 package middleware
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
 	"encoding/json"
 	"github.com/RashadTanjim/enterprise-microservice-system/common/auth"
 	"net/http"
@@ -127,3 +131,404 @@ func TestRequireRoles(t *testing.T) {
 		})
 	}
 }
+
+func TestRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := auth.Config{
+		Secret:   "test-secret",
+		Issuer:   "test-issuer",
+		Audience: "test-audience",
+		TokenTTL: time.Minute,
+		Permissions: auth.NewRolePermissions(map[string][]string{
+			"admin":   {"*"},
+			"support": {auth.PermOrdersRead, "users:*"},
+		}),
+	}
+
+	router := gin.New()
+	protected := router.Group("/protected")
+	protected.Use(AuthMiddleware(cfg))
+	protected.GET("/orders", RequirePermission(auth.PermOrdersRead), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	protected.DELETE("/users", RequirePermission(auth.PermUsersDelete), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	protected.DELETE("/orders", RequirePermission(auth.PermOrdersDelete), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	tokens := map[string]string{}
+	for _, role := range []string{"admin", "support", "user"} {
+		token, err := auth.GenerateToken(cfg, role+"-subject", []string{role})
+		if err != nil {
+			t.Fatalf("failed to generate token: %v", err)
+		}
+		tokens[role] = token
+	}
+
+	cases := []struct {
+		name       string
+		role       string
+		method     string
+		path       string
+		wantStatus int
+	}{
+		{name: "wildcard grant", role: "admin", method: http.MethodDelete, path: "/protected/orders", wantStatus: http.StatusOK},
+		{name: "exact grant", role: "support", method: http.MethodGet, path: "/protected/orders", wantStatus: http.StatusOK},
+		{name: "resource wildcard grant", role: "support", method: http.MethodDelete, path: "/protected/users", wantStatus: http.StatusOK},
+		{name: "missing grant", role: "support", method: http.MethodDelete, path: "/protected/orders", wantStatus: http.StatusForbidden},
+		{name: "unmapped role", role: "user", method: http.MethodGet, path: "/protected/orders", wantStatus: http.StatusForbidden},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tokens[tc.role])
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != tc.wantStatus {
+				t.Fatalf("expected status %d, got %d", tc.wantStatus, recorder.Code)
+			}
+		})
+	}
+}
+
+func TestMFARequiredRoles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := auth.Config{
+		Secret:           "test-secret",
+		Issuer:           "test-issuer",
+		Audience:         "test-audience",
+		TokenTTL:         time.Minute,
+		MFARequiredRoles: []string{"admin"},
+	}
+
+	router := gin.New()
+	protected := router.Group("/protected")
+	protected.Use(AuthMiddleware(cfg))
+	protected.DELETE("/users", RequirePermission(auth.PermUsersDelete), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	protected.GET("/admin", RequireRoles("admin"), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	protected.POST("/orders", RequirePermission(auth.PermOrdersCreate), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	passwordOnly, err := auth.GenerateTokenWithAMR(cfg, "1", []string{"admin", "user"}, []string{auth.AMRPassword})
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+	verified, err := auth.GenerateTokenWithAMR(cfg, "1", []string{"admin", "user"}, []string{auth.AMRPassword, auth.AMROTP, auth.AMRMFA})
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	cases := []struct {
+		name        string
+		token       string
+		method      string
+		path        string
+		wantStatus  int
+		wantMessage string
+	}{
+		{name: "admin permission without mfa", token: passwordOnly, method: http.MethodDelete, path: "/protected/users", wantStatus: http.StatusForbidden, wantMessage: "multi-factor authentication required"},
+		{name: "admin role without mfa", token: passwordOnly, method: http.MethodGet, path: "/protected/admin", wantStatus: http.StatusForbidden, wantMessage: "multi-factor authentication required"},
+		{name: "other roles still apply", token: passwordOnly, method: http.MethodPost, path: "/protected/orders", wantStatus: http.StatusOK},
+		{name: "admin permission with mfa", token: verified, method: http.MethodDelete, path: "/protected/users", wantStatus: http.StatusOK},
+		{name: "admin role with mfa", token: verified, method: http.MethodGet, path: "/protected/admin", wantStatus: http.StatusOK},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != tc.wantStatus {
+				t.Fatalf("expected status %d, got %d", tc.wantStatus, recorder.Code)
+			}
+			if tc.wantMessage != "" {
+				var resp errorResponse
+				if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+					t.Fatalf("failed to decode response: %v", err)
+				}
+				if resp.Error.Message != tc.wantMessage {
+					t.Fatalf("expected message %q, got %q", tc.wantMessage, resp.Error.Message)
+				}
+			}
+		})
+	}
+}
+
+type staticAPIKeyVerifier map[string]*auth.APIKeyPrincipal
+
+func (v staticAPIKeyVerifier) VerifyAPIKey(_ context.Context, key string) (*auth.APIKeyPrincipal, error) {
+	principal, ok := v[key]
+	if !ok {
+		return nil, auth.ErrInvalidAPIKey
+	}
+	return principal, nil
+}
+
+func TestAuthMiddlewareAPIKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	keyID, key, _, err := auth.GenerateAPIKey()
+	if err != nil {
+		t.Fatalf("failed to generate api key: %v", err)
+	}
+
+	revocations := auth.NewRevocationList(nil, time.Minute, nil)
+	cfg := auth.Config{
+		Secret:      "test-secret",
+		Issuer:      "test-issuer",
+		Audience:    "test-audience",
+		TokenTTL:    time.Minute,
+		Revocations: revocations,
+		APIKeys: staticAPIKeyVerifier{
+			key: {KeyID: keyID, Subject: "partner-acme", Roles: []string{"user"}},
+		},
+	}
+
+	router := gin.New()
+	protected := router.Group("/protected")
+	protected.Use(AuthMiddleware(cfg))
+	protected.GET("", RequirePermission(auth.PermOrdersRead), func(c *gin.Context) {
+		subject, _ := GetAuthSubject(c)
+		roles, _ := GetAuthRoles(c)
+		method, _ := GetAuthMethod(c)
+		if subject != "partner-acme" || len(roles) != 1 || method != AuthMethodAPIKey {
+			t.Errorf("unexpected auth context subject=%q roles=%v method=%q", subject, roles, method)
+		}
+		c.Status(http.StatusOK)
+	})
+
+	request := func(apiKey string) int {
+		req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+		req.Header.Set(auth.APIKeyHeader, apiKey)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	if code := request(key); code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", code)
+	}
+	if code := request(keyID + "_wrong-secret"); code != http.StatusUnauthorized {
+		t.Fatalf("expected status 401 for unknown key, got %d", code)
+	}
+
+	if err := revocations.RevokeToken(context.Background(), keyID); err != nil {
+		t.Fatalf("failed to revoke key: %v", err)
+	}
+	if code := request(key); code != http.StatusUnauthorized {
+		t.Fatalf("expected status 401 for revoked key, got %d", code)
+	}
+
+	// Revoking the owner rejects keys created before the revocation, not after.
+	verifier := cfg.APIKeys.(staticAPIKeyVerifier)
+	_, olderKey, _, _ := auth.GenerateAPIKey()
+	verifier[olderKey] = &auth.APIKeyPrincipal{KeyID: "older", Subject: "partner-acme", Roles: []string{"user"}, IssuedAt: time.Now().Add(-time.Hour)}
+	if err := revocations.RevokeSubject(context.Background(), "partner-acme"); err != nil {
+		t.Fatalf("failed to revoke subject: %v", err)
+	}
+	_, newerKey, _, _ := auth.GenerateAPIKey()
+	verifier[newerKey] = &auth.APIKeyPrincipal{KeyID: "newer", Subject: "partner-acme", Roles: []string{"user"}, IssuedAt: time.Now().Add(time.Second)}
+	if code := request(olderKey); code != http.StatusUnauthorized {
+		t.Fatalf("expected status 401 for key of revoked subject, got %d", code)
+	}
+	if code := request(newerKey); code != http.StatusOK {
+		t.Fatalf("expected status 200 for key created after the revocation, got %d", code)
+	}
+}
+
+func TestAuthMiddlewareRemoteKeySet(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	keyring, err := auth.NewKeyring("", auth.SigningKey{ID: "key-1", PrivateKey: key})
+	if err != nil {
+		t.Fatalf("failed to create keyring: %v", err)
+	}
+
+	jwksServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		set, _ := keyring.JWKS()
+		_ = json.NewEncoder(w).Encode(set)
+	}))
+	defer jwksServer.Close()
+
+	issuerCfg := auth.Config{
+		Issuer:   "test-issuer",
+		Audience: "test-audience",
+		TokenTTL: time.Minute,
+		Keyring:  keyring,
+	}
+	verifierCfg := auth.Config{
+		Issuer:   "test-issuer",
+		Audience: "test-audience",
+		KeySet:   auth.NewRemoteKeySet(jwksServer.URL, time.Minute),
+	}
+
+	token, err := auth.GenerateToken(issuerCfg, "client", []string{"admin"})
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	router := gin.New()
+	router.GET("/protected", AuthMiddleware(verifierCfg), func(c *gin.Context) {
+		// The bearer token is kept for token exchange by outbound clients.
+		if forwarded, _ := auth.TokenFromContext(c.Request.Context()); forwarded != token {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", recorder.Code)
+	}
+
+	forged, err := auth.GenerateToken(auth.Config{Secret: "guessed", Issuer: "test-issuer", Audience: "test-audience", TokenTTL: time.Minute}, "client", []string{"admin"})
+	if err != nil {
+		t.Fatalf("failed to generate token: %v", err)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/protected", nil)
+	req.Header.Set("Authorization", "Bearer "+forged)
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	if recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status 401 for HS256 token, got %d", recorder.Code)
+	}
+}
+
+func TestAuthMiddlewareRevokedToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	revocations := auth.NewRevocationList(nil, time.Minute, nil)
+	cfg := auth.Config{
+		Secret:      "test-secret",
+		Issuer:      "test-issuer",
+		Audience:    "test-audience",
+		TokenTTL:    time.Minute,
+		Revocations: revocations,
+	}
+
+	router := gin.New()
+	router.GET("/protected", AuthMiddleware(cfg), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	send := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/protected", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+
+	first, _ := auth.GenerateToken(cfg, "client-a", []string{"admin"})
+	second, _ := auth.GenerateToken(cfg, "client-a", []string{"admin"})
+	other, _ := auth.GenerateToken(cfg, "client-b", []string{"admin"})
+
+	claims, err := auth.ParseToken(cfg, first)
+	if err != nil {
+		t.Fatalf("failed to parse token: %v", err)
+	}
+	if err := revocations.RevokeToken(context.Background(), claims.ID); err != nil {
+		t.Fatalf("failed to revoke token: %v", err)
+	}
+
+	if code := send(first); code != http.StatusUnauthorized {
+		t.Fatalf("expected revoked token to be rejected, got %d", code)
+	}
+	if code := send(second); code != http.StatusOK {
+		t.Fatalf("expected sibling token to remain valid, got %d", code)
+	}
+
+	if err := revocations.RevokeSubject(context.Background(), "client-a"); err != nil {
+		t.Fatalf("failed to revoke subject: %v", err)
+	}
+
+	if code := send(second); code != http.StatusUnauthorized {
+		t.Fatalf("expected tokens of revoked subject to be rejected, got %d", code)
+	}
+	if code := send(other); code != http.StatusOK {
+		t.Fatalf("expected other subject to remain valid, got %d", code)
+	}
+
+	// iat has second precision: a token issued in a later second stays valid.
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+	fresh, _ := auth.GenerateToken(cfg, "client-a", []string{"admin"})
+	if code := send(fresh); code != http.StatusOK {
+		t.Fatalf("expected token issued after the revocation to be accepted, got %d", code)
+	}
+}
+
+func TestAuthMiddlewareDelegatedToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := auth.Config{
+		Secret:   "test-secret",
+		Issuer:   "test-issuer",
+		Audience: "test-audience",
+		TokenTTL: time.Minute,
+	}
+
+	var (
+		subject, actor, principal string
+		fromContext               *auth.Claims
+	)
+	router := gin.New()
+	router.GET("/users/:id", AuthMiddleware(cfg), RequirePermission(auth.PermUsersRead), func(c *gin.Context) {
+		subject, _ = GetAuthSubject(c)
+		actor, _ = GetAuthActor(c)
+		principal, _ = GetAuthPrincipal(c)
+		fromContext, _ = auth.ClaimsFromContext(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	caller := &auth.Claims{Roles: []string{"user"}, Act: &auth.Actor{Subject: "gateway"}}
+	caller.Subject = "42"
+	token, err := auth.GenerateDelegatedToken(cfg, caller.DelegateTo("order-service", []string{"service"}))
+	if err != nil {
+		t.Fatalf("failed to generate delegated token: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/users/42", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected the actor's roles to authorize the call, got %d", recorder.Code)
+	}
+	if subject != "42" || actor != "order-service" {
+		t.Fatalf("expected subject 42 acted on by order-service, got %q and %q", subject, actor)
+	}
+	if principal != "42 via gateway via order-service" {
+		t.Fatalf("expected the principal to list the delegation chain, got %q", principal)
+	}
+	if fromContext == nil || fromContext.Subject != "42" {
+		t.Fatalf("expected claims in the request context")
+	}
+	if chain := fromContext.Act.Chain(); len(chain) != 2 || chain[0] != "order-service" || chain[1] != "gateway" {
+		t.Fatalf("expected the prior actor to be preserved, got %v", chain)
+	}
+}
@@ -43,6 +43,13 @@ func LoggerMiddleware(log *logger.Logger) gin.HandlerFunc {
 			fields = append(fields, zap.String("request_id", requestID.(string)))
 		}
 
+		if subject, ok := GetAuthSubject(c); ok {
+			fields = append(fields, zap.String("subject", subject))
+		}
+		if actor, ok := GetAuthActor(c); ok {
+			fields = append(fields, zap.String("acted_by", actor))
+		}
+
 		if statusCode >= 500 {
 			log.Error("Server error", fields...)
 		} else if statusCode >= 400 {
@@ -0,0 +1,239 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	apperrors "github.com/RashadTanjim/enterprise-microservice-system/common/errors"
+)
+
+// Config holds retry policy configuration.
+type Config struct {
+	MaxAttempts int           // Attempts including the first; 1 or less disables retries
+	BaseDelay   time.Duration // Backoff cap before the first retry, doubled for each later retry
+	MaxDelay    time.Duration // Upper bound for the backoff cap
+	// MaxRetryAfter bounds how long a Retry-After header may delay a retry;
+	// responses asking for longer are not retried.
+	MaxRetryAfter time.Duration
+	// BudgetRatio is the number of retry tokens each call earns and
+	// BudgetBurst the most that can be saved; a retry spends one token.
+	// A BudgetRatio of 0 disables the budget.
+	BudgetRatio float64
+	BudgetBurst float64
+}
+
+// DefaultConfig returns default retry configuration: up to 3 attempts and
+// retries worth at most 10% of calls once the initial burst of 10 is spent.
+func DefaultConfig() Config {
+	return Config{
+		MaxAttempts:   3,
+		BaseDelay:     100 * time.Millisecond,
+		MaxDelay:      2 * time.Second,
+		MaxRetryAfter: 10 * time.Second,
+		BudgetRatio:   0.1,
+		BudgetBurst:   10,
+	}
+}
+
+// Retrier retries failed calls with exponential backoff and full jitter. It
+// is safe for concurrent use; the retry budget is shared by all calls.
+type Retrier struct {
+	config Config
+	budget *budget
+}
+
+// New creates a retrier.
+func New(config Config) *Retrier {
+	r := &Retrier{config: config}
+	if config.BudgetRatio > 0 {
+		r.budget = &budget{tokens: config.BudgetBurst, max: config.BudgetBurst, ratio: config.BudgetRatio}
+	}
+	return r
+}
+
+// Do calls fn for a request with the given HTTP method until it succeeds or
+// returns an error that is not retryable, attempts or the budget run out, or
+// ctx is done. It returns the last error.
+//
+// Network errors and responses reported with NewStatusError for 408, 429,
+// 502, 503 and 504 are retryable. Requests with a method that is not
+// idempotent are only retried when the connection could not be established,
+// so the server never saw them. An open circuit breaker is never retried:
+// wrap each attempt in the breaker, not the whole Do, so every attempt is
+// counted and an open breaker stops the retries.
+func (r *Retrier) Do(ctx context.Context, method string, fn func(ctx context.Context) error) error {
+	if r == nil {
+		return fn(ctx)
+	}
+	if r.budget != nil {
+		r.budget.deposit()
+	}
+
+	idempotent := isIdempotent(method)
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || attempt >= r.config.MaxAttempts || ctx.Err() != nil {
+			return err
+		}
+
+		retryable, retryAfter := classify(err, idempotent)
+		if !retryable || retryAfter > r.config.MaxRetryAfter {
+			return err
+		}
+		if r.budget != nil && !r.budget.withdraw() {
+			return err
+		}
+
+		delay := retryAfter
+		if delay <= 0 {
+			delay = r.backoff(attempt)
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns a random delay up to BaseDelay doubled for every attempt
+// made, capped at MaxDelay ("full jitter").
+func (r *Retrier) backoff(attempt int) time.Duration {
+	limit := r.config.MaxDelay
+	if shift := attempt - 1; shift < 32 {
+		if d := r.config.BaseDelay << shift; d > 0 && d < limit {
+			limit = d
+		}
+	}
+	if limit <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int64N(int64(limit) + 1))
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func classify(err error, idempotent bool) (bool, time.Duration) {
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) && appErr.Code == apperrors.ErrCodeCircuitOpen {
+		return false, 0
+	}
+	if errors.Is(err, context.Canceled) {
+		return false, 0
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true, 0
+	}
+	if !idempotent {
+		return false, 0
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Retryable(), statusErr.RetryAfter
+	}
+
+	var urlErr *url.Error
+	var netErr net.Error
+	if errors.As(err, &urlErr) || errors.As(err, &netErr) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true, 0
+	}
+	return false, 0
+}
+
+// StatusError reports a response with an unexpected status code.
+type StatusError struct {
+	StatusCode int
+	// RetryAfter is the delay requested by the Retry-After header, if any.
+	RetryAfter time.Duration
+}
+
+// NewStatusError reads the status code and Retry-After header of resp.
+func NewStatusError(resp *http.Response) *StatusError {
+	return &StatusError{
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+// Error implements the error interface
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d", e.StatusCode)
+}
+
+// Retryable reports whether the status is worth retrying.
+func (e *StatusError) Retryable() bool {
+	switch e.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// parseRetryAfter accepts delay seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// budget is a token bucket that every call fills by ratio and every retry
+// drains by one, so retries stay a fraction of traffic during an outage.
+type budget struct {
+	mu     sync.Mutex
+	tokens float64
+	max    float64
+	ratio  float64
+}
+
+func (b *budget) deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens += b.ratio
+	if b.tokens > b.max {
+		b.tokens = b.max
+	}
+}
+
+func (b *budget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -0,0 +1,125 @@
+package retry
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	apperrors "github.com/RashadTanjim/enterprise-microservice-system/common/errors"
+)
+
+func testConfig() Config {
+	return Config{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond, MaxRetryAfter: time.Second}
+}
+
+func countCalls(r *Retrier, method string, errs ...error) (int, error) {
+	calls := 0
+	err := r.Do(context.Background(), method, func(ctx context.Context) error {
+		calls++
+		if calls <= len(errs) {
+			return errs[calls-1]
+		}
+		return nil
+	})
+	return calls, err
+}
+
+func TestDoRetriesIdempotentRequests(t *testing.T) {
+	r := New(testConfig())
+	unavailable := &StatusError{StatusCode: http.StatusServiceUnavailable}
+
+	if calls, err := countCalls(r, http.MethodGet, unavailable, unavailable); err != nil || calls != 3 {
+		t.Fatalf("expected success on the third attempt, got %d calls (%v)", calls, err)
+	}
+	if calls, err := countCalls(r, http.MethodGet, unavailable, unavailable, unavailable); err == nil || calls != 3 {
+		t.Fatalf("expected to give up after 3 attempts, got %d calls (%v)", calls, err)
+	}
+	if calls, _ := countCalls(r, http.MethodGet, &StatusError{StatusCode: http.StatusInternalServerError}); calls != 1 {
+		t.Fatalf("expected a 500 not to be retried, got %d calls", calls)
+	}
+	if calls, _ := countCalls(r, http.MethodGet, apperrors.NewNotFound("user")); calls != 1 {
+		t.Fatalf("expected not found not to be retried, got %d calls", calls)
+	}
+	if calls, _ := countCalls(r, http.MethodGet, apperrors.NewCircuitOpen("users")); calls != 1 {
+		t.Fatalf("expected an open circuit not to be retried, got %d calls", calls)
+	}
+}
+
+func TestDoRetriesOtherMethodsOnlyWhenUnsent(t *testing.T) {
+	r := New(testConfig())
+
+	if calls, _ := countCalls(r, http.MethodPost, &StatusError{StatusCode: http.StatusServiceUnavailable}); calls != 1 {
+		t.Fatalf("expected a POST not to be retried after a response, got %d calls", calls)
+	}
+
+	dialErr := apperrors.NewInternal("failed to call", &net.OpError{Op: "dial", Err: &net.DNSError{Err: "refused"}})
+	if calls, err := countCalls(r, http.MethodPost, dialErr); err != nil || calls != 2 {
+		t.Fatalf("expected a POST to be retried after a dial error, got %d calls (%v)", calls, err)
+	}
+}
+
+func TestDoHonorsRetryAfter(t *testing.T) {
+	r := New(testConfig())
+
+	start := time.Now()
+	calls, err := countCalls(r, http.MethodGet, &StatusError{StatusCode: http.StatusTooManyRequests, RetryAfter: 50 * time.Millisecond})
+	if err != nil || calls != 2 {
+		t.Fatalf("expected a retry, got %d calls (%v)", calls, err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("expected to wait for Retry-After, waited %s", elapsed)
+	}
+
+	if calls, _ := countCalls(r, http.MethodGet, &StatusError{StatusCode: http.StatusServiceUnavailable, RetryAfter: time.Minute}); calls != 1 {
+		t.Fatalf("expected a Retry-After above the cap not to be retried, got %d calls", calls)
+	}
+}
+
+func TestBudgetLimitsRetries(t *testing.T) {
+	cfg := testConfig()
+	cfg.MaxAttempts = 2
+	cfg.BudgetRatio = 0.5
+	cfg.BudgetBurst = 1
+	r := New(cfg)
+	unavailable := &StatusError{StatusCode: http.StatusServiceUnavailable}
+
+	if calls, _ := countCalls(r, http.MethodGet, unavailable, unavailable); calls != 2 {
+		t.Fatalf("expected the burst to allow a retry, got %d calls", calls)
+	}
+	if calls, _ := countCalls(r, http.MethodGet, unavailable, unavailable); calls != 1 {
+		t.Fatalf("expected an empty budget to stop retries, got %d calls", calls)
+	}
+	if calls, _ := countCalls(r, http.MethodGet, unavailable, unavailable); calls != 2 {
+		t.Fatalf("expected two calls to earn a retry, got %d calls", calls)
+	}
+}
+
+func TestBackoffUsesFullJitter(t *testing.T) {
+	r := New(Config{BaseDelay: 10 * time.Millisecond, MaxDelay: 40 * time.Millisecond})
+	for attempt, limit := range map[int]time.Duration{1: 10 * time.Millisecond, 2: 20 * time.Millisecond, 5: 40 * time.Millisecond} {
+		for i := 0; i < 100; i++ {
+			if d := r.backoff(attempt); d < 0 || d > limit {
+				t.Fatalf("attempt %d: backoff %s outside [0, %s]", attempt, d, limit)
+			}
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cases := map[string]time.Duration{
+		"":                              0,
+		"3":                             3 * time.Second,
+		"-1":                            0,
+		"soon":                          0,
+		"Mon, 01 Jan 2024 00:00:30 GMT": 30 * time.Second,
+		"Sun, 31 Dec 2023 23:59:00 GMT": 0,
+	}
+	for value, want := range cases {
+		if got := parseRetryAfter(value, now); got != want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", value, got, want)
+		}
+	}
+}
@@ -58,25 +58,51 @@ func main() {
 	}
 	log.Info("Database migration completed")
 
+	// Initialize metrics
+	metricsCollector := metrics.NewMetrics("audit_log_service")
+
 	// Initialize dependencies
 	auditRepo := repository.NewAuditLogRepository(db)
-	auditCache, err := cache.NewRedisCache(cache.Config{
-		Enabled:    cfg.Redis.Enabled,
-		Host:       cfg.Redis.Host,
-		Port:       cfg.Redis.Port,
-		Password:   cfg.Redis.Password,
-		DB:         cfg.Redis.DB,
-		DefaultTTL: cfg.Redis.DefaultTTL,
-	}, "audit-log-service")
+	cacheConfig := cache.Config{
+		Enabled:          cfg.Redis.Enabled,
+		Backend:          cfg.Redis.Backend,
+		Host:             cfg.Redis.Host,
+		Port:             cfg.Redis.Port,
+		Addrs:            cfg.Redis.Addrs,
+		MasterName:       cfg.Redis.MasterName,
+		Cluster:          cfg.Redis.Cluster,
+		Username:         cfg.Redis.Username,
+		Password:         cfg.Redis.Password,
+		SentinelPassword: cfg.Redis.SentinelPassword,
+		DB:               cfg.Redis.DB,
+		TLS: cache.TLSConfig{
+			Enabled:            cfg.Redis.TLSEnabled,
+			CAFile:             cfg.Redis.TLSCAFile,
+			CertFile:           cfg.Redis.TLSCertFile,
+			KeyFile:            cfg.Redis.TLSKeyFile,
+			ServerName:         cfg.Redis.TLSServerName,
+			InsecureSkipVerify: cfg.Redis.TLSInsecureSkipVerify,
+		},
+		DefaultTTL:           cfg.Redis.DefaultTTL,
+		Format:               cfg.Redis.Format,
+		Compression:          cfg.Redis.Compression,
+		CompressionThreshold: cfg.Redis.CompressionThreshold,
+		MaxEntries:           cfg.Redis.MaxEntries,
+	}
+
+	// The service cache may keep hot entries in process; the shared auth
+	// cache always reads Redis so revocations apply immediately.
+	serviceCacheConfig := cacheConfig
+	serviceCacheConfig.LocalSize = cfg.Redis.LocalSize
+	serviceCacheConfig.LocalTTL = cfg.Redis.LocalTTL
+	serviceCacheConfig.Observer = metricsCollector.ObserveCacheLookup
+	auditCache, err := cache.New(serviceCacheConfig, "audit-log-service")
 	if err != nil {
-		log.Warn("Redis cache disabled", zap.Error(err))
+		log.Warn("Redis unavailable, caching paused until it reconnects", zap.Error(err))
 	}
 	auditService := service.NewAuditLogService(auditRepo, auditCache)
 	auditHandler := handler.NewAuditLogHandler(auditService, log)
 
-	// Initialize metrics
-	metricsCollector := metrics.NewMetrics("audit_log_service")
-
 	// Initialize rate limiter
 	rateLimiter := middleware.NewRateLimiter(cfg.Server.RateLimit, cfg.Server.RateLimit*2)
 
@@ -86,6 +112,41 @@ func main() {
 		Audience: cfg.Auth.Audience,
 		TokenTTL: cfg.Auth.TokenTTL,
 	}
+	if cfg.Auth.JWKSURL != "" {
+		authConfig.KeySet = auth.NewRemoteKeySet(cfg.Auth.JWKSURL, cfg.Auth.JWKSRefresh)
+		log.Info("JWKS token verification enabled", zap.String("jwks_url", cfg.Auth.JWKSURL))
+	}
+	if authConfig.KeySet == nil && authConfig.Secret == "" {
+		log.Fatal("Token verification is not configured; set AUTH_JWKS_URL or AUTH_JWT_SECRET")
+	}
+
+	// Shared revocation list written by user-service
+	revocationCache, err := cache.New(cacheConfig, "auth")
+	if err != nil {
+		log.Warn("Redis unavailable, token revocations kept in memory until it reconnects", zap.Error(err))
+	}
+	authConfig.Revocations = auth.NewRevocationList(revocationCache, cfg.Auth.TokenTTL, metricsCollector.ObserveRevocationCheck)
+
+	permissions, err := auth.LoadRolePermissions(cfg.Auth.PermissionsFile)
+	if err != nil {
+		log.Fatal("Failed to load role permissions", zap.Error(err))
+	}
+	authConfig.Permissions = permissions
+	if cfg.Auth.PermissionsFile != "" {
+		log.Info("Role permissions loaded",
+			zap.String("file", cfg.Auth.PermissionsFile),
+			zap.Strings("roles", permissions.Roles()),
+		)
+	}
+	authConfig.MFARequiredRoles = cfg.Auth.MFARequiredRoles
+	if len(cfg.Auth.MFARequiredRoles) > 0 {
+		log.Info("MFA required for roles", zap.Strings("roles", cfg.Auth.MFARequiredRoles))
+	}
+
+	if cfg.Auth.APIKeyVerifyURL != "" {
+		authConfig.APIKeys = auth.NewRemoteAPIKeyVerifier(cfg.Auth.APIKeyVerifyURL, cfg.Auth.APIKeyCacheTTL)
+		log.Info("API key authentication enabled", zap.String("verify_url", cfg.Auth.APIKeyVerifyURL))
+	}
 
 	// Setup router
 	routerSetup := api.NewRouter(auditHandler, log, metricsCollector, rateLimiter, authConfig)
@@ -130,6 +191,10 @@ func main() {
 		sqlDB.Close()
 	}
 
+	if err := auditCache.Close(); err != nil {
+		log.Warn("Failed to close Redis cache", zap.Error(err))
+	}
+
 	log.Info("Server exited")
 }
 
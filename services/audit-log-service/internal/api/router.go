@@ -73,11 +73,11 @@ func (r *Router) Setup() *gin.Engine {
 
 	auditLogs := protected.Group("/audit-logs")
 	{
-		auditLogs.POST("", middleware.RequireRoles("admin", "user", "service"), r.handler.CreateAuditLog)
-		auditLogs.GET("", middleware.RequireRoles("admin", "user"), r.handler.ListAuditLogs)
-		auditLogs.GET("/:id", middleware.RequireRoles("admin", "user"), r.handler.GetAuditLog)
-		auditLogs.PUT("/:id", middleware.RequireRoles("admin"), r.handler.UpdateAuditLog)
-		auditLogs.DELETE("/:id", middleware.RequireRoles("admin"), r.handler.DeleteAuditLog)
+		auditLogs.POST("", middleware.RequirePermission(auth.PermAuditCreate), r.handler.CreateAuditLog)
+		auditLogs.GET("", middleware.RequirePermission(auth.PermAuditRead), r.handler.ListAuditLogs)
+		auditLogs.GET("/:id", middleware.RequirePermission(auth.PermAuditRead), r.handler.GetAuditLog)
+		auditLogs.PUT("/:id", middleware.RequirePermission(auth.PermAuditUpdate), r.handler.UpdateAuditLog)
+		auditLogs.DELETE("/:id", middleware.RequirePermission(auth.PermAuditDelete), r.handler.DeleteAuditLog)
 	}
 
 	return router
@@ -41,20 +41,49 @@ type LogConfig struct {
 
 // AuthConfig holds authentication configuration
 type AuthConfig struct {
-	Secret   string
-	Issuer   string
-	Audience string
-	TokenTTL time.Duration
+	// Secret verifies HS256 tokens; it may be empty once JWKSURL is set.
+	Secret      string
+	Issuer      string
+	Audience    string
+	TokenTTL    time.Duration
+	JWKSURL     string
+	JWKSRefresh time.Duration
+	// PermissionsFile is a JSON role to permission mapping; empty uses the built-in roles.
+	PermissionsFile string
+	// MFARequiredRoles only grant their permissions to tokens issued after MFA.
+	MFARequiredRoles []string
+	// APIKeyVerifyURL is the user-service endpoint that resolves X-API-Key headers; empty disables API keys.
+	APIKeyVerifyURL string
+	APIKeyCacheTTL  time.Duration
 }
 
 // RedisConfig holds Redis cache configuration
 type RedisConfig struct {
-	Enabled    bool
-	Host       string
-	Port       string
-	Password   string
-	DB         int
-	DefaultTTL time.Duration
+	Enabled bool
+	Backend string
+	Host    string
+	Port    string
+	// Addrs and MasterName select Sentinel or Cluster instead of Host/Port
+	Addrs                 []string
+	MasterName            string
+	Cluster               bool
+	Username              string
+	Password              string
+	SentinelPassword      string
+	DB                    int
+	TLSEnabled            bool
+	TLSCAFile             string
+	TLSCertFile           string
+	TLSKeyFile            string
+	TLSServerName         string
+	TLSInsecureSkipVerify bool
+	DefaultTTL            time.Duration
+	Format                string
+	Compression           string
+	CompressionThreshold  int
+	LocalSize             int
+	LocalTTL              time.Duration
+	MaxEntries            int
 }
 
 // Load loads configuration from environment variables
@@ -82,6 +111,36 @@ func Load() (*Config, error) {
 		cacheDB = 0
 	}
 
+	localCacheSize, err := strconv.Atoi(getEnv("REDIS_LOCAL_CACHE_SIZE", "0"))
+	if err != nil {
+		localCacheSize = 0
+	}
+
+	localCacheTTLSeconds, err := strconv.Atoi(getEnv("REDIS_LOCAL_CACHE_TTL_SECONDS", "30"))
+	if err != nil {
+		localCacheTTLSeconds = 30
+	}
+
+	memoryCacheEntries, err := strconv.Atoi(getEnv("CACHE_MEMORY_MAX_ENTRIES", "10000"))
+	if err != nil {
+		memoryCacheEntries = 10000
+	}
+
+	compressionThreshold, err := strconv.Atoi(getEnv("CACHE_COMPRESSION_THRESHOLD_BYTES", "1024"))
+	if err != nil {
+		compressionThreshold = 1024
+	}
+
+	jwksRefreshSeconds, err := strconv.Atoi(getEnv("AUTH_JWKS_REFRESH_SECONDS", "300"))
+	if err != nil {
+		jwksRefreshSeconds = 300
+	}
+
+	apiKeyCacheSeconds, err := strconv.Atoi(getEnv("AUTH_API_KEY_CACHE_SECONDS", "30"))
+	if err != nil {
+		apiKeyCacheSeconds = 30
+	}
+
 	config := &Config{
 		Server: ServerConfig{
 			Port:      getEnv("AUDIT_LOG_SERVICE_PORT", "8083"),
@@ -98,18 +157,42 @@ func Load() (*Config, error) {
 			Level: getEnv("AUDIT_LOG_SERVICE_LOG_LEVEL", "info"),
 		},
 		Auth: AuthConfig{
-			Secret:   getEnv("AUTH_JWT_SECRET", "change-me"),
-			Issuer:   getEnv("AUTH_JWT_ISSUER", "enterprise-microservice-system"),
-			Audience: getEnv("AUTH_JWT_AUDIENCE", "enterprise-microservice-system"),
-			TokenTTL: time.Duration(tokenTTLMinutes) * time.Minute,
+			Secret:           getEnvOrEmpty("AUTH_JWT_SECRET", "change-me"),
+			Issuer:           getEnv("AUTH_JWT_ISSUER", "enterprise-microservice-system"),
+			Audience:         getEnv("AUTH_JWT_AUDIENCE", "enterprise-microservice-system"),
+			TokenTTL:         time.Duration(tokenTTLMinutes) * time.Minute,
+			JWKSURL:          getEnv("AUTH_JWKS_URL", ""),
+			JWKSRefresh:      time.Duration(jwksRefreshSeconds) * time.Second,
+			PermissionsFile:  getEnv("AUTH_PERMISSIONS_FILE", ""),
+			MFARequiredRoles: getEnvList("AUTH_MFA_REQUIRED_ROLES", nil),
+			APIKeyVerifyURL:  getEnv("AUTH_API_KEY_VERIFY_URL", ""),
+			APIKeyCacheTTL:   time.Duration(apiKeyCacheSeconds) * time.Second,
 		},
 		Redis: RedisConfig{
-			Enabled:    getEnvBool("REDIS_ENABLED", true),
-			Host:       getEnv("REDIS_HOST", "localhost"),
-			Port:       getEnv("REDIS_PORT", "6379"),
-			Password:   getEnv("REDIS_PASSWORD", ""),
-			DB:         cacheDB,
-			DefaultTTL: time.Duration(cacheTTLSeconds) * time.Second,
+			Enabled:               getEnvBool("REDIS_ENABLED", true),
+			Backend:               getEnv("CACHE_BACKEND", "redis"),
+			Host:                  getEnv("REDIS_HOST", "localhost"),
+			Port:                  getEnv("REDIS_PORT", "6379"),
+			Addrs:                 getEnvList("REDIS_ADDRS", nil),
+			MasterName:            getEnv("REDIS_MASTER_NAME", ""),
+			Cluster:               getEnvBool("REDIS_CLUSTER", false),
+			Username:              getEnv("REDIS_USERNAME", ""),
+			Password:              getEnv("REDIS_PASSWORD", ""),
+			SentinelPassword:      getEnv("REDIS_SENTINEL_PASSWORD", ""),
+			DB:                    cacheDB,
+			TLSEnabled:            getEnvBool("REDIS_TLS_ENABLED", false),
+			TLSCAFile:             getEnv("REDIS_TLS_CA_FILE", ""),
+			TLSCertFile:           getEnv("REDIS_TLS_CERT_FILE", ""),
+			TLSKeyFile:            getEnv("REDIS_TLS_KEY_FILE", ""),
+			TLSServerName:         getEnv("REDIS_TLS_SERVER_NAME", ""),
+			TLSInsecureSkipVerify: getEnvBool("REDIS_TLS_INSECURE_SKIP_VERIFY", false),
+			DefaultTTL:            time.Duration(cacheTTLSeconds) * time.Second,
+			Format:                getEnv("CACHE_FORMAT", "json"),
+			Compression:           getEnv("CACHE_COMPRESSION", "none"),
+			CompressionThreshold:  compressionThreshold,
+			LocalSize:             localCacheSize,
+			LocalTTL:              time.Duration(localCacheTTLSeconds) * time.Second,
+			MaxEntries:            memoryCacheEntries,
 		},
 	}
 
@@ -131,6 +214,36 @@ func getEnv(key, defaultValue string) string {
 	return value
 }
 
+// getEnvOrEmpty is getEnv for values that may be deliberately set to empty.
+func getEnvOrEmpty(key, defaultValue string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return strings.TrimSpace(value)
+	}
+	return defaultValue
+}
+
+func getEnvList(key string, defaultValues []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValues
+	}
+
+	parts := strings.Split(value, ",")
+	result := make([]string, 0, len(parts))
+	for _, part := range parts {
+		trimmed := strings.TrimSpace(part)
+		if trimmed != "" {
+			result = append(result, trimmed)
+		}
+	}
+
+	if len(result) == 0 {
+		return defaultValues
+	}
+
+	return result
+}
+
 func getEnvBool(key string, defaultValue bool) bool {
 	value := strings.ToLower(strings.TrimSpace(os.Getenv(key)))
 	if value == "" {
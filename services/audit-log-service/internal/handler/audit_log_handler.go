@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"github.com/RashadTanjim/enterprise-microservice-system/common/auth"
 	"github.com/RashadTanjim/enterprise-microservice-system/common/logger"
 	"github.com/RashadTanjim/enterprise-microservice-system/common/middleware"
 	"github.com/RashadTanjim/enterprise-microservice-system/common/response"
@@ -46,6 +47,12 @@ func (h *AuditLogHandler) CreateAuditLog(c *gin.Context) {
 	}
 
 	actor := resolveActor(c)
+	if claims, ok := auth.ClaimsFromContext(c.Request.Context()); ok && claims.Act != nil && req.ActedBy == "" {
+		if req.Actor == "" || req.Actor == claims.Subject || req.Actor == actor {
+			req.Actor = claims.Subject
+			req.ActedBy = claims.Act.String()
+		}
+	}
 	entry, err := h.service.CreateAuditLog(c.Request.Context(), &req, actor)
 	if err != nil {
 		h.logger.Error("Failed to create audit log", zap.Error(err))
@@ -160,6 +167,7 @@ func (h *AuditLogHandler) DeleteAuditLog(c *gin.Context) {
 // @Param page_size query int false "Page size" default(10)
 // @Param search query string false "Search term"
 // @Param actor query string false "Filter by actor"
+// @Param acted_by query string false "Filter by the service or client that acted on behalf of the actor"
 // @Param action query string false "Filter by action"
 // @Param resource_type query string false "Filter by resource type"
 // @Param resource_id query string false "Filter by resource ID"
@@ -192,9 +200,11 @@ func (h *AuditLogHandler) ListAuditLogs(c *gin.Context) {
 	response.SuccessWithMeta(c, entries, meta)
 }
 
+// resolveActor identifies the caller for created_by/updated_by and audit
+// events. Delegated and impersonation tokens record both identities, e.g. "42 via 1".
 func resolveActor(c *gin.Context) string {
-	if subject, ok := middleware.GetAuthSubject(c); ok && subject != "" {
-		return subject
+	if principal, ok := middleware.GetAuthPrincipal(c); ok && principal != "" {
+		return principal
 	}
 	return "system"
 }
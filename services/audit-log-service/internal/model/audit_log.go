@@ -8,10 +8,12 @@ const (
 )
 
 // AuditLog represents an audit log entry in the system
-// capturing who did what on which resource.
+// capturing who did what on which resource. ActedBy is set when a service or
+// client acted on behalf of Actor with a delegated token.
 type AuditLog struct {
 	ID           uint      `gorm:"primarykey" json:"id"`
 	Actor        string    `gorm:"type:varchar(120);not null;index" json:"actor"`
+	ActedBy      string    `gorm:"type:varchar(120);index" json:"acted_by,omitempty"`
 	Action       string    `gorm:"type:varchar(100);not null;index" json:"action"`
 	ResourceType string    `gorm:"type:varchar(100);not null;index" json:"resource_type"`
 	ResourceID   string    `gorm:"type:varchar(100);not null;index" json:"resource_id"`
@@ -30,9 +32,11 @@ func (AuditLog) TableName() string {
 }
 
 // CreateAuditLogRequest represents the request to create an audit log entry
-// Actor is optional and will default to the authenticated subject.
+// Actor is optional and will default to the authenticated subject; ActedBy
+// defaults to the actor of a delegated token when Actor is its subject.
 type CreateAuditLogRequest struct {
 	Actor        string `json:"actor" binding:"omitempty,max=120"`
+	ActedBy      string `json:"acted_by" binding:"omitempty,max=120"`
 	Action       string `json:"action" binding:"required,min=2,max=100"`
 	ResourceType string `json:"resource_type" binding:"required,min=2,max=100"`
 	ResourceID   string `json:"resource_id" binding:"required,min=1,max=100"`
@@ -55,6 +59,7 @@ type ListAuditLogsQuery struct {
 	PageSize     int     `form:"page_size" binding:"omitempty,min=1,max=100"`
 	Search       string  `form:"search"`
 	Actor        string  `form:"actor"`
+	ActedBy      string  `form:"acted_by"`
 	Action       string  `form:"action"`
 	ResourceType string  `form:"resource_type"`
 	ResourceID   string  `form:"resource_id"`
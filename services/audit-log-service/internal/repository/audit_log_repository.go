@@ -82,6 +82,10 @@ func (r *auditLogRepository) List(ctx context.Context, query *model.ListAuditLog
 		db = db.Where("actor = ?", query.Actor)
 	}
 
+	if query.ActedBy != "" {
+		db = db.Where("acted_by = ?", query.ActedBy)
+	}
+
 	if query.Action != "" {
 		db = db.Where("action = ?", query.Action)
 	}
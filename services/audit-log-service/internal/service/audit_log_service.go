@@ -23,14 +23,33 @@ type AuditLogService interface {
 	ListAuditLogs(ctx context.Context, query *model.ListAuditLogsQuery) ([]*model.AuditLog, int64, error)
 }
 
+var (
+	// Missing entries are cached briefly so lookups of unknown IDs do not all
+	// reach the database.
+	auditLogCacheOptions     = cache.LoadOptions{NegativeTTL: 30 * time.Second, EarlyRefresh: 1}
+	auditLogListCacheOptions = cache.LoadOptions{TTL: 60 * time.Second, EarlyRefresh: 1, Tags: []string{auditLogListCacheTag}}
+)
+
+// auditLogListCacheTag tags every cached ListAuditLogs page.
+const auditLogListCacheTag = "audit-logs:list"
+
+// auditLogPage is the cached form of a ListAuditLogs result.
+type auditLogPage struct {
+	Entries []*model.AuditLog `json:"entries"`
+	Total   int64             `json:"total"`
+}
+
 // auditLogService implements AuditLogService
 type auditLogService struct {
 	repo  repository.AuditLogRepository
-	cache *cache.Cache
+	cache cache.Cache
 }
 
 // NewAuditLogService creates a new audit log service
-func NewAuditLogService(repo repository.AuditLogRepository, cacheClient *cache.Cache) AuditLogService {
+func NewAuditLogService(repo repository.AuditLogRepository, cacheClient cache.Cache) AuditLogService {
+	if cacheClient == nil {
+		cacheClient = cache.NewNoopCache()
+	}
 	return &auditLogService{
 		repo:  repo,
 		cache: cacheClient,
@@ -45,6 +64,7 @@ func (s *auditLogService) CreateAuditLog(ctx context.Context, req *model.CreateA
 
 	entry := &model.AuditLog{
 		Actor:        req.Actor,
+		ActedBy:      req.ActedBy,
 		Action:       req.Action,
 		ResourceType: req.ResourceType,
 		ResourceID:   req.ResourceID,
@@ -63,25 +83,25 @@ func (s *auditLogService) CreateAuditLog(ctx context.Context, req *model.CreateA
 		return nil, errors.NewInternal("failed to create audit log", err)
 	}
 
-	s.cacheSetAuditLog(ctx, entry)
+	s.invalidateAuditLog(ctx, entry.ID)
 	return entry, nil
 }
 
 // GetAuditLog retrieves an audit log entry by ID
 func (s *auditLogService) GetAuditLog(ctx context.Context, id uint) (*model.AuditLog, error) {
-	if cached := s.cacheGetAuditLog(ctx, id); cached != nil {
-		return cached, nil
-	}
-
-	entry, err := s.repo.FindByID(ctx, id)
-	if err != nil {
+	entry, err := cache.GetOrLoad(ctx, s.cache, auditLogCacheKey(id), auditLogCacheOptions, func(ctx context.Context) (*model.AuditLog, error) {
+		entry, err := s.repo.FindByID(ctx, id)
 		if err == gorm.ErrRecordNotFound {
+			return nil, cache.ErrNotFound
+		}
+		return entry, err
+	})
+	if err != nil {
+		if err == cache.ErrNotFound {
 			return nil, errors.NewNotFound("audit log")
 		}
 		return nil, errors.NewInternal("failed to get audit log", err)
 	}
-
-	s.cacheSetAuditLog(ctx, entry)
 	return entry, nil
 }
 
@@ -113,7 +133,7 @@ func (s *auditLogService) UpdateAuditLog(ctx context.Context, id uint, req *mode
 		return nil, errors.NewInternal("failed to update audit log", err)
 	}
 
-	s.cacheSetAuditLog(ctx, entry)
+	s.invalidateAuditLog(ctx, entry.ID)
 	return entry, nil
 }
 
@@ -135,7 +155,7 @@ func (s *auditLogService) DeleteAuditLog(ctx context.Context, id uint, actor str
 		return errors.NewInternal("failed to delete audit log", err)
 	}
 
-	s.cacheDeleteAuditLog(ctx, id)
+	s.invalidateAuditLog(ctx, id)
 	return nil
 }
 
@@ -143,80 +163,24 @@ func (s *auditLogService) DeleteAuditLog(ctx context.Context, id uint, actor str
 func (s *auditLogService) ListAuditLogs(ctx context.Context, query *model.ListAuditLogsQuery) ([]*model.AuditLog, int64, error) {
 	query.ApplyDefaults()
 
-	if cachedEntries, cachedTotal, ok := s.cacheGetAuditLogList(ctx, query); ok {
-		return cachedEntries, cachedTotal, nil
-	}
-
-	entries, total, err := s.repo.List(ctx, query)
+	page, err := cache.GetOrLoad(ctx, s.cache, s.auditLogListCacheKey(query), auditLogListCacheOptions, func(ctx context.Context) (auditLogPage, error) {
+		entries, total, err := s.repo.List(ctx, query)
+		return auditLogPage{Entries: entries, Total: total}, err
+	})
 	if err != nil {
 		return nil, 0, errors.NewInternal("failed to list audit logs", err)
 	}
-
-	s.cacheSetAuditLogList(ctx, query, entries, total)
-	return entries, total, nil
+	return page.Entries, page.Total, nil
 }
 
-func (s *auditLogService) cacheGetAuditLog(ctx context.Context, id uint) *model.AuditLog {
-	if s.cache == nil || !s.cache.Enabled() {
-		return nil
-	}
-
-	var entry model.AuditLog
-	found, err := s.cache.GetJSON(ctx, fmt.Sprintf("audit-log:%d", id), &entry)
-	if err != nil || !found {
-		return nil
-	}
-	return &entry
+// invalidateAuditLog drops the cached entry and every cached list page.
+func (s *auditLogService) invalidateAuditLog(ctx context.Context, id uint) {
+	_ = s.cache.Delete(ctx, auditLogCacheKey(id))
+	_ = s.cache.InvalidateTags(ctx, auditLogListCacheTag)
 }
 
-func (s *auditLogService) cacheSetAuditLog(ctx context.Context, entry *model.AuditLog) {
-	if s.cache == nil || !s.cache.Enabled() || entry == nil {
-		return
-	}
-
-	_ = s.cache.SetJSON(ctx, fmt.Sprintf("audit-log:%d", entry.ID), entry, 0)
-}
-
-func (s *auditLogService) cacheDeleteAuditLog(ctx context.Context, id uint) {
-	if s.cache == nil || !s.cache.Enabled() {
-		return
-	}
-	_ = s.cache.Delete(ctx, fmt.Sprintf("audit-log:%d", id))
-}
-
-func (s *auditLogService) cacheGetAuditLogList(ctx context.Context, query *model.ListAuditLogsQuery) ([]*model.AuditLog, int64, bool) {
-	if s.cache == nil || !s.cache.Enabled() {
-		return nil, 0, false
-	}
-
-	key := s.auditLogListCacheKey(query)
-	var payload struct {
-		Entries []*model.AuditLog `json:"entries"`
-		Total   int64             `json:"total"`
-	}
-
-	found, err := s.cache.GetJSON(ctx, key, &payload)
-	if err != nil || !found {
-		return nil, 0, false
-	}
-	return payload.Entries, payload.Total, true
-}
-
-func (s *auditLogService) cacheSetAuditLogList(ctx context.Context, query *model.ListAuditLogsQuery, entries []*model.AuditLog, total int64) {
-	if s.cache == nil || !s.cache.Enabled() {
-		return
-	}
-
-	key := s.auditLogListCacheKey(query)
-	payload := struct {
-		Entries []*model.AuditLog `json:"entries"`
-		Total   int64             `json:"total"`
-	}{
-		Entries: entries,
-		Total:   total,
-	}
-
-	_ = s.cache.SetJSON(ctx, key, payload, 60*time.Second)
+func auditLogCacheKey(id uint) string {
+	return fmt.Sprintf("audit-log:%d", id)
 }
 
 func (s *auditLogService) auditLogListCacheKey(query *model.ListAuditLogsQuery) string {
@@ -230,6 +194,11 @@ func (s *auditLogService) auditLogListCacheKey(query *model.ListAuditLogsQuery)
 		actor = "any"
 	}
 
+	actedBy := strings.TrimSpace(query.ActedBy)
+	if actedBy == "" {
+		actedBy = "any"
+	}
+
 	action := strings.TrimSpace(query.Action)
 	if action == "" {
 		action = "any"
@@ -251,10 +220,11 @@ func (s *auditLogService) auditLogListCacheKey(query *model.ListAuditLogsQuery)
 	}
 
 	return fmt.Sprintf(
-		"audit-logs:list:p%d:ps%d:actor:%s:action:%s:rtype:%s:rid:%s:status:%s:search:%s",
+		"audit-logs:list:p%d:ps%d:actor:%s:acted_by:%s:action:%s:rtype:%s:rid:%s:status:%s:search:%s",
 		query.Page,
 		query.PageSize,
 		actor,
+		actedBy,
 		action,
 		resourceType,
 		resourceID,
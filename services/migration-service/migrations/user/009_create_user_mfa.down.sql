@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS user_mfa;
+
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS amr;
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS authorization_codes;
+
+ALTER TABLE oauth_clients DROP COLUMN IF EXISTS public;
+ALTER TABLE oauth_clients DROP COLUMN IF EXISTS redirect_uris;
+
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified;
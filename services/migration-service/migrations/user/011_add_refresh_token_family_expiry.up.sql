@@ -0,0 +1,6 @@
+ALTER TABLE refresh_tokens ADD COLUMN IF NOT EXISTS family_expires_at TIMESTAMPTZ;
+
+-- Existing families end when their current token does.
+UPDATE refresh_tokens SET family_expires_at = expires_at WHERE family_expires_at IS NULL;
+
+ALTER TABLE refresh_tokens ALTER COLUMN family_expires_at SET NOT NULL;
@@ -12,17 +12,21 @@ import (
 	"fmt"
 	"github.com/RashadTanjim/enterprise-microservice-system/common/audit"
 	"github.com/RashadTanjim/enterprise-microservice-system/common/auth"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/bulkhead"
 	"github.com/RashadTanjim/enterprise-microservice-system/common/cache"
 	"github.com/RashadTanjim/enterprise-microservice-system/common/circuitbreaker"
 	"github.com/RashadTanjim/enterprise-microservice-system/common/logger"
 	"github.com/RashadTanjim/enterprise-microservice-system/common/metrics"
 	"github.com/RashadTanjim/enterprise-microservice-system/common/middleware"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/retry"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/sony/gobreaker"
 	"go.uber.org/zap"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -55,17 +59,36 @@ func main() {
 		log.Fatal("Failed to connect to database", zap.Error(err))
 	}
 
+	// Initialize metrics
+	metricsCollector := metrics.NewMetrics("order_service")
+
 	// Initialize circuit breaker for user service
+	tripStrategies, err := buildTripStrategies(cfg.CircuitBreaker)
+	if err != nil {
+		log.Fatal("Invalid circuit breaker configuration", zap.Error(err))
+	}
 	cbConfig := circuitbreaker.Config{
 		MaxRequests: cfg.CircuitBreaker.MaxRequests,
 		Interval:    cfg.CircuitBreaker.Interval,
 		Timeout:     cfg.CircuitBreaker.Timeout,
+		Strategies:  tripStrategies,
+		OnStateChange: func(name string, from gobreaker.State, to gobreaker.State) {
+			log.Warn("Circuit breaker state changed",
+				zap.String("service", name),
+				zap.String("from", from.String()),
+				zap.String("to", to.String()),
+			)
+			metricsCollector.ObserveCircuitTransition(name, from.String(), to.String())
+			metricsCollector.SetCircuitState(name, circuitbreaker.StateValue(to))
+		},
 	}
 	userServiceCB := circuitbreaker.New("user-service", cbConfig)
+	metricsCollector.SetCircuitState("user-service", userServiceCB.StateAsFloat())
 	log.Info("Circuit breaker initialized",
 		zap.Uint32("max_requests", cbConfig.MaxRequests),
 		zap.Duration("interval", cbConfig.Interval),
 		zap.Duration("timeout", cbConfig.Timeout),
+		zap.Strings("strategies", cfg.CircuitBreaker.Strategies),
 	)
 
 	authConfig := auth.Config{
@@ -74,52 +97,163 @@ func main() {
 		Audience: cfg.Auth.Audience,
 		TokenTTL: cfg.Auth.TokenTTL,
 	}
-
-	tokenProvider := func() (string, error) {
-		return auth.GenerateToken(authConfig, cfg.Auth.ServiceSubject, cfg.Auth.ServiceRoles)
+	if cfg.Auth.JWKSURL != "" {
+		authConfig.KeySet = auth.NewRemoteKeySet(cfg.Auth.JWKSURL, cfg.Auth.JWKSRefresh)
+		log.Info("JWKS token verification enabled", zap.String("jwks_url", cfg.Auth.JWKSURL))
 	}
 
-	if _, err := tokenProvider(); err != nil {
-		log.Fatal("Failed to initialize service token provider", zap.Error(err))
+	if authConfig.KeySet == nil && authConfig.Secret == "" {
+		log.Fatal("Token verification is not configured; set AUTH_JWKS_URL or AUTH_JWT_SECRET")
 	}
 
-	// Initialize user service client
-	userClient := client.NewUserClient(cfg.UserService.URL, userServiceCB, tokenProvider)
+	// Only user-service signs tokens: order-service obtains its own and
+	// delegated tokens from the token endpoint as an OAuth client.
+	if cfg.Auth.ServiceClientSecret == "" {
+		log.Fatal("AUTH_SERVICE_CLIENT_SECRET is required to obtain tokens from user-service")
+	}
+	tokenSource := auth.NewTokenSource(cfg.Auth.TokenURL, cfg.Auth.ServiceSubject, cfg.Auth.ServiceClientSecret,
+		strings.Join(cfg.Auth.ServiceRoles, " "))
+	tokenProvider := func() (string, error) {
+		return tokenSource.Token(context.Background())
+	}
 
+	// Initialize user service client; calls made for a request carry the caller as sub.
+	userServiceRetrier := retry.New(retry.Config{
+		MaxAttempts:   cfg.Retry.MaxAttempts,
+		BaseDelay:     cfg.Retry.BaseDelay,
+		MaxDelay:      cfg.Retry.MaxDelay,
+		MaxRetryAfter: cfg.Retry.MaxRetryAfter,
+		BudgetRatio:   cfg.Retry.BudgetRatio,
+		BudgetBurst:   cfg.Retry.BudgetBurst,
+	})
+	userServiceBulkhead := bulkhead.New("user-service", bulkhead.Config{
+		MaxConcurrent: cfg.Bulkhead.MaxConcurrent,
+		MaxQueue:      cfg.Bulkhead.MaxQueue,
+		QueueTimeout:  cfg.Bulkhead.QueueTimeout,
+		Observer:      metricsCollector,
+	})
 	// Initialize dependencies
 	orderRepo := repository.NewOrderRepository(db)
-	orderCache, err := cache.NewRedisCache(cache.Config{
-		Enabled:    cfg.Redis.Enabled,
-		Host:       cfg.Redis.Host,
-		Port:       cfg.Redis.Port,
-		Password:   cfg.Redis.Password,
-		DB:         cfg.Redis.DB,
-		DefaultTTL: cfg.Redis.DefaultTTL,
-	}, "order-service")
+	cacheConfig := cache.Config{
+		Enabled:          cfg.Redis.Enabled,
+		Backend:          cfg.Redis.Backend,
+		Host:             cfg.Redis.Host,
+		Port:             cfg.Redis.Port,
+		Addrs:            cfg.Redis.Addrs,
+		MasterName:       cfg.Redis.MasterName,
+		Cluster:          cfg.Redis.Cluster,
+		Username:         cfg.Redis.Username,
+		Password:         cfg.Redis.Password,
+		SentinelPassword: cfg.Redis.SentinelPassword,
+		DB:               cfg.Redis.DB,
+		TLS: cache.TLSConfig{
+			Enabled:            cfg.Redis.TLSEnabled,
+			CAFile:             cfg.Redis.TLSCAFile,
+			CertFile:           cfg.Redis.TLSCertFile,
+			KeyFile:            cfg.Redis.TLSKeyFile,
+			ServerName:         cfg.Redis.TLSServerName,
+			InsecureSkipVerify: cfg.Redis.TLSInsecureSkipVerify,
+		},
+		DefaultTTL:           cfg.Redis.DefaultTTL,
+		Format:               cfg.Redis.Format,
+		Compression:          cfg.Redis.Compression,
+		CompressionThreshold: cfg.Redis.CompressionThreshold,
+		MaxEntries:           cfg.Redis.MaxEntries,
+	}
+
+	// The service cache may keep hot entries in process; the shared auth
+	// cache always reads Redis so revocations apply immediately.
+	serviceCacheConfig := cacheConfig
+	serviceCacheConfig.LocalSize = cfg.Redis.LocalSize
+	serviceCacheConfig.LocalTTL = cfg.Redis.LocalTTL
+	serviceCacheConfig.Observer = metricsCollector.ObserveCacheLookup
+	orderCache, err := cache.New(serviceCacheConfig, "order-service")
 	if err != nil {
-		log.Warn("Redis cache disabled", zap.Error(err))
+		log.Warn("Redis unavailable, caching paused until it reconnects", zap.Error(err))
 	}
 
+	// Last known good users, served as stale while user-service is down
+	snapshotCacheConfig := cacheConfig
+	if cfg.UserService.SnapshotBackend != "" {
+		snapshotCacheConfig.Backend = cfg.UserService.SnapshotBackend
+	}
+	snapshotCacheConfig.Enabled = cfg.UserService.SnapshotsEnabled &&
+		(cfg.Redis.Enabled || snapshotCacheConfig.Backend == cache.BackendMemory)
+	snapshotCacheConfig.DefaultTTL = cfg.UserService.SnapshotMaxAge
+	snapshotCache, err := cache.New(snapshotCacheConfig, "order-service:user-snapshots")
+	if err != nil {
+		log.Warn("User snapshot store unavailable, stale user fallback paused", zap.Error(err))
+	}
+	userSnapshots := client.NewUserSnapshotStore(snapshotCache, cfg.UserService.SnapshotMaxAge)
+	userClient := client.NewUserClient(cfg.UserService.URL, userServiceCB, userServiceBulkhead, userServiceRetrier, userSnapshots,
+		client.NewExchangingTokenProvider(tokenSource))
+
+	degradedPolicy, err := service.ParseDegradedPolicy(cfg.UserService.DegradedOrderPolicy)
+	if err != nil {
+		log.Fatal("Invalid degraded order policy", zap.Error(err))
+	}
+
+	auditRetry := retry.DefaultConfig()
+	auditRetry.MaxAttempts = cfg.AuditLog.RetryMaxAttempts
 	auditClient := audit.NewClient(audit.Config{
 		Enabled: cfg.AuditLog.Enabled,
 		BaseURL: cfg.AuditLog.URL,
 		Timeout: cfg.AuditLog.Timeout,
+		// API key requests have no bearer token to forward, so use a service token.
+		TokenProvider: tokenProvider,
+		Retry:         auditRetry,
+		Bulkhead: bulkhead.Config{
+			MaxConcurrent: cfg.AuditLog.MaxConcurrent,
+			MaxQueue:      cfg.AuditLog.MaxQueue,
+			QueueTimeout:  cfg.AuditLog.QueueTimeout,
+			Observer:      metricsCollector,
+		},
 	}, log)
 
-	orderService := service.NewOrderService(orderRepo, userClient, orderCache)
+	orderService := service.NewOrderService(orderRepo, userClient, orderCache, degradedPolicy)
 	orderHandler := handler.NewOrderHandler(orderService, auditClient, log)
 
-	// Initialize metrics
-	metricsCollector := metrics.NewMetrics("order_service")
+	// Forced breaker states are shared with the other replicas through Redis
+	overrideCache, err := cache.New(cacheConfig, "circuit-breakers")
+	if err != nil {
+		log.Warn("Redis unavailable, circuit breaker overrides apply to this instance until it reconnects", zap.Error(err))
+	}
+	breakerOverrides := circuitbreaker.NewOverrideStore(overrideCache, cfg.CircuitBreaker.OverrideSyncInterval)
+	circuitBreakerHandler := handler.NewCircuitBreakerHandler(breakerOverrides, auditClient, log)
+
+	// Shared revocation list written by user-service
+	revocationCache, err := cache.New(cacheConfig, "auth")
+	if err != nil {
+		log.Warn("Redis unavailable, token revocations kept in memory until it reconnects", zap.Error(err))
+	}
+	authConfig.Revocations = auth.NewRevocationList(revocationCache, cfg.Auth.TokenTTL, metricsCollector.ObserveRevocationCheck)
+
+	permissions, err := auth.LoadRolePermissions(cfg.Auth.PermissionsFile)
+	if err != nil {
+		log.Fatal("Failed to load role permissions", zap.Error(err))
+	}
+	authConfig.Permissions = permissions
+	if cfg.Auth.PermissionsFile != "" {
+		log.Info("Role permissions loaded",
+			zap.String("file", cfg.Auth.PermissionsFile),
+			zap.Strings("roles", permissions.Roles()),
+		)
+	}
+	authConfig.MFARequiredRoles = cfg.Auth.MFARequiredRoles
+	if len(cfg.Auth.MFARequiredRoles) > 0 {
+		log.Info("MFA required for roles", zap.Strings("roles", cfg.Auth.MFARequiredRoles))
+	}
+
+	if cfg.Auth.APIKeyVerifyURL != "" {
+		authConfig.APIKeys = auth.NewRemoteAPIKeyVerifier(cfg.Auth.APIKeyVerifyURL, cfg.Auth.APIKeyCacheTTL)
+		log.Info("API key authentication enabled", zap.String("verify_url", cfg.Auth.APIKeyVerifyURL))
+	}
 
 	// Initialize rate limiter
 	rateLimiter := middleware.NewRateLimiter(cfg.Server.RateLimit, cfg.Server.RateLimit*2)
 
-	// Start background worker to update circuit breaker metrics
-	go updateCircuitBreakerMetrics(userClient, metricsCollector, log)
-
 	// Setup router
-	routerSetup := api.NewRouter(orderHandler, log, metricsCollector, rateLimiter, authConfig)
+	routerSetup := api.NewRouter(orderHandler, circuitBreakerHandler, log, metricsCollector, rateLimiter, authConfig)
 	router := routerSetup.Setup()
 
 	// Create HTTP server
@@ -160,6 +294,17 @@ func main() {
 		sqlDB.Close()
 	}
 
+	if err := orderCache.Close(); err != nil {
+		log.Warn("Failed to close Redis cache", zap.Error(err))
+	}
+	if err := snapshotCache.Close(); err != nil {
+		log.Warn("Failed to close user snapshot store", zap.Error(err))
+	}
+	breakerOverrides.Close()
+	if err := overrideCache.Close(); err != nil {
+		log.Warn("Failed to close circuit breaker override store", zap.Error(err))
+	}
+
 	log.Info("Server exited")
 }
 
@@ -212,13 +357,20 @@ func connectDatabase(cfg *config.Config, log *logger.Logger) (*gorm.DB, *sql.DB,
 	return db, sqlDB, nil
 }
 
-// updateCircuitBreakerMetrics updates circuit breaker state metrics
-func updateCircuitBreakerMetrics(userClient client.UserServiceClient, metrics *metrics.Metrics, log *logger.Logger) {
-	ticker := time.NewTicker(10 * time.Second)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		state := userClient.GetCircuitBreakerState()
-		metrics.SetCircuitState("user-service", state)
+// buildTripStrategies creates the configured circuit breaker trip strategies.
+func buildTripStrategies(cfg config.CircuitBreakerConfig) ([]circuitbreaker.TripStrategy, error) {
+	strategies := make([]circuitbreaker.TripStrategy, 0, len(cfg.Strategies))
+	for _, name := range cfg.Strategies {
+		switch name {
+		case "consecutive_failures":
+			strategies = append(strategies, circuitbreaker.ConsecutiveFailures(cfg.ConsecutiveFailures))
+		case "failure_rate":
+			strategies = append(strategies, circuitbreaker.FailureRate(cfg.WindowSize, cfg.MinCalls, cfg.FailureRate))
+		case "slow_call_rate":
+			strategies = append(strategies, circuitbreaker.SlowCallRate(cfg.WindowSize, cfg.MinCalls, cfg.SlowCall, cfg.SlowCallRate))
+		default:
+			return nil, fmt.Errorf("unknown circuit breaker strategy %q", name)
+		}
 	}
+	return strategies, nil
 }
@@ -17,27 +17,30 @@ import (
 
 // Router sets up all routes for the order service
 type Router struct {
-	handler     *handler.OrderHandler
-	logger      *logger.Logger
-	metrics     *metrics.Metrics
-	rateLimiter *middleware.RateLimiter
-	authConfig  auth.Config
+	handler         *handler.OrderHandler
+	circuitBreakers *handler.CircuitBreakerHandler
+	logger          *logger.Logger
+	metrics         *metrics.Metrics
+	rateLimiter     *middleware.RateLimiter
+	authConfig      auth.Config
 }
 
 // NewRouter creates a new router
 func NewRouter(
 	handler *handler.OrderHandler,
+	circuitBreakers *handler.CircuitBreakerHandler,
 	logger *logger.Logger,
 	metrics *metrics.Metrics,
 	rateLimiter *middleware.RateLimiter,
 	authConfig auth.Config,
 ) *Router {
 	return &Router{
-		handler:     handler,
-		logger:      logger,
-		metrics:     metrics,
-		rateLimiter: rateLimiter,
-		authConfig:  authConfig,
+		handler:         handler,
+		circuitBreakers: circuitBreakers,
+		logger:          logger,
+		metrics:         metrics,
+		rateLimiter:     rateLimiter,
+		authConfig:      authConfig,
 	}
 }
 
@@ -73,11 +76,17 @@ func (r *Router) Setup() *gin.Engine {
 
 	orders := protected.Group("/orders")
 	{
-		orders.POST("", middleware.RequireRoles("admin", "user"), r.handler.CreateOrder)
-		orders.GET("", middleware.RequireRoles("admin", "user"), r.handler.ListOrders)
-		orders.GET("/:id", middleware.RequireRoles("admin", "user"), r.handler.GetOrder)
-		orders.PUT("/:id", middleware.RequireRoles("admin"), r.handler.UpdateOrder)
-		orders.DELETE("/:id", middleware.RequireRoles("admin"), r.handler.DeleteOrder)
+		orders.POST("", middleware.RequirePermission(auth.PermOrdersCreate), r.handler.CreateOrder)
+		orders.GET("", middleware.RequirePermission(auth.PermOrdersRead), r.handler.ListOrders)
+		orders.GET("/:id", middleware.RequirePermission(auth.PermOrdersRead), r.handler.GetOrder)
+		orders.PUT("/:id", middleware.RequirePermission(auth.PermOrdersUpdate), r.handler.UpdateOrder)
+		orders.DELETE("/:id", middleware.RequirePermission(auth.PermOrdersDelete), r.handler.DeleteOrder)
+	}
+
+	admin := protected.Group("/admin")
+	{
+		admin.GET("/circuit-breakers", middleware.RequirePermission(auth.PermBreakersRead), r.circuitBreakers.ListCircuitBreakers)
+		admin.PUT("/circuit-breakers/:name", middleware.RequirePermission(auth.PermBreakersWrite), r.circuitBreakers.ForceCircuitBreaker)
 	}
 
 	return router
@@ -3,8 +3,12 @@ package client
 import (
 	"context"
 	"encoding/json"
+	stderrors "errors"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/auth"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/bulkhead"
 	"github.com/RashadTanjim/enterprise-microservice-system/common/circuitbreaker"
 	"github.com/RashadTanjim/enterprise-microservice-system/common/errors"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/retry"
 	"enterprise-microservice-system/services/order-service/internal/model"
 	"fmt"
 	"net/http"
@@ -22,17 +26,42 @@ type UserClient struct {
 	baseURL        string
 	client         *http.Client
 	circuitBreaker *circuitbreaker.CircuitBreaker
-	tokenProvider  func() (string, error)
+	bulkhead       *bulkhead.Bulkhead
+	retrier        *retry.Retrier
+	snapshots      *UserSnapshotStore
+	tokenProvider  TokenProvider
 }
 
-// NewUserClient creates a new user service client
-func NewUserClient(baseURL string, cb *circuitbreaker.CircuitBreaker, tokenProvider func() (string, error)) *UserClient {
+// TokenProvider returns the bearer token for a call made while serving ctx.
+type TokenProvider func(ctx context.Context) (string, error)
+
+// NewExchangingTokenProvider obtains tokens from user-service through source
+// rather than signing them locally. While serving a request authenticated with
+// a bearer token, that token is exchanged so user-service sees the caller as
+// sub and order-service as act; otherwise, for example for API key requests,
+// order-service calls as itself.
+func NewExchangingTokenProvider(source *auth.TokenSource) TokenProvider {
+	return func(ctx context.Context) (string, error) {
+		if token, ok := auth.TokenFromContext(ctx); ok {
+			return source.Exchange(ctx, token)
+		}
+		return source.Token(ctx)
+	}
+}
+
+// NewUserClient creates a new user service client. A nil bulkhead does not
+// limit concurrent calls, a nil retrier makes a single attempt per call and a
+// nil snapshot store disables the last known good fallback.
+func NewUserClient(baseURL string, cb *circuitbreaker.CircuitBreaker, bh *bulkhead.Bulkhead, retrier *retry.Retrier, snapshots *UserSnapshotStore, tokenProvider TokenProvider) *UserClient {
 	return &UserClient{
 		baseURL: baseURL,
 		client: &http.Client{
 			Timeout: 10 * time.Second,
 		},
 		circuitBreaker: cb,
+		bulkhead:       bh,
+		retrier:        retrier,
+		snapshots:      snapshots,
 		tokenProvider:  tokenProvider,
 	}
 }
@@ -50,25 +79,64 @@ type ErrorInfo struct {
 	Message string `json:"message"`
 }
 
-// GetUser retrieves a user by ID from user service
+// GetUser retrieves a user by ID from user service. When user-service cannot
+// be reached, its circuit is open or it fails, the last known good copy of the
+// user is returned instead with Stale set; without one the error is returned.
+// The bearer token is obtained once per call, before any attempt, so a failing
+// token endpoint neither counts against the circuit nor is retried here.
 func (c *UserClient) GetUser(ctx context.Context, userID uint) (*model.User, error) {
 	url := fmt.Sprintf("%s/api/v1/users/%d", c.baseURL, userID)
 
-	// Execute request with circuit breaker protection
-	result, err := c.circuitBreaker.ExecuteWithContext(ctx, func() (interface{}, error) {
-		return c.doGetRequest(ctx, url)
+	var token string
+	if c.tokenProvider != nil {
+		var err error
+		token, err = c.tokenProvider(ctx)
+		if err != nil {
+			if stale, ok := c.snapshots.Load(ctx, userID); ok {
+				return stale, nil
+			}
+			return nil, errors.NewInternal("failed to obtain service token", err)
+		}
+	}
+
+	// Each attempt holds a bulkhead slot only while it runs and goes through
+	// the circuit breaker, so an open circuit stops the retries. Calls
+	// rejected by the bulkhead fail fast and do not count against the circuit.
+	var user *model.User
+	err := c.retrier.Do(ctx, http.MethodGet, func(ctx context.Context) error {
+		result, err := c.bulkhead.Execute(ctx, func() (interface{}, error) {
+			return c.circuitBreaker.ExecuteWithContext(ctx, func() (interface{}, error) {
+				return c.doGetRequest(ctx, url, token)
+			})
+		})
+		if err != nil {
+			return err
+		}
+		user = result.(*model.User)
+		return nil
 	})
 
 	if err != nil {
+		var appErr *errors.AppError
+		if stderrors.As(err, &appErr) && appErr.Code == errors.ErrCodeNotFound {
+			c.snapshots.Delete(ctx, userID)
+			return nil, err
+		}
+		if circuitbreaker.IsFailure(err) {
+			if stale, ok := c.snapshots.Load(ctx, userID); ok {
+				return stale, nil
+			}
+		}
 		return nil, err
 	}
 
-	user := result.(*model.User)
+	c.snapshots.Save(ctx, user)
 	return user, nil
 }
 
-// doGetRequest performs the actual HTTP GET request
-func (c *UserClient) doGetRequest(ctx context.Context, url string) (*model.User, error) {
+// doGetRequest performs the actual HTTP GET request; an empty token sends no
+// Authorization header.
+func (c *UserClient) doGetRequest(ctx context.Context, url, token string) (*model.User, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, errors.NewInternal("failed to create request", err)
@@ -76,11 +144,7 @@ func (c *UserClient) doGetRequest(ctx context.Context, url string) (*model.User,
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
-	if c.tokenProvider != nil {
-		token, err := c.tokenProvider()
-		if err != nil {
-			return nil, errors.NewInternal("failed to generate service token", err)
-		}
+	if token != "" {
 		req.Header.Set("Authorization", "Bearer "+token)
 	}
 
@@ -98,7 +162,7 @@ func (c *UserClient) doGetRequest(ctx context.Context, url string) (*model.User,
 		return nil, errors.New(
 			errors.ErrCodeServiceUnavail,
 			fmt.Sprintf("user service returned status %d", resp.StatusCode),
-			nil,
+			retry.NewStatusError(resp),
 		)
 	}
 
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/RashadTanjim/enterprise-microservice-system/common/cache"
+	"enterprise-microservice-system/services/order-service/internal/model"
+)
+
+// userSnapshot is the stored form of a user fetched from user-service.
+type userSnapshot struct {
+	User      model.User `json:"user"`
+	FetchedAt time.Time  `json:"fetched_at"`
+}
+
+// UserSnapshotStore keeps the last user record fetched from user-service so
+// lookups can fall back to it while user-service is unavailable. Snapshots
+// expire after maxAge, which bounds how stale a fallback can be. A nil store
+// keeps nothing.
+type UserSnapshotStore struct {
+	cache  cache.Cache
+	maxAge time.Duration
+}
+
+// NewUserSnapshotStore creates a snapshot store on top of c, which may be a
+// Redis or in-process cache.
+func NewUserSnapshotStore(c cache.Cache, maxAge time.Duration) *UserSnapshotStore {
+	if c == nil {
+		c = cache.NewNoopCache()
+	}
+	return &UserSnapshotStore{cache: c, maxAge: maxAge}
+}
+
+// Save records user as the last known good copy. Cache errors are ignored.
+func (s *UserSnapshotStore) Save(ctx context.Context, user *model.User) {
+	if s == nil || user == nil {
+		return
+	}
+	_ = s.cache.Set(ctx, userSnapshotKey(user.ID), userSnapshot{User: *user, FetchedAt: time.Now().UTC()}, s.maxAge)
+}
+
+// Load returns the last known good copy of a user, marked as stale, or false
+// when there is none.
+func (s *UserSnapshotStore) Load(ctx context.Context, userID uint) (*model.User, bool) {
+	if s == nil {
+		return nil, false
+	}
+	var snapshot userSnapshot
+	found, err := s.cache.Get(ctx, userSnapshotKey(userID), &snapshot)
+	if err != nil || !found {
+		return nil, false
+	}
+	if s.maxAge > 0 && time.Since(snapshot.FetchedAt) > s.maxAge {
+		return nil, false
+	}
+
+	user := snapshot.User
+	user.Stale = true
+	user.FetchedAt = &snapshot.FetchedAt
+	return &user, true
+}
+
+// Delete forgets the snapshot of a user, e.g. once user-service reports it gone.
+func (s *UserSnapshotStore) Delete(ctx context.Context, userID uint) {
+	if s == nil {
+		return
+	}
+	_ = s.cache.Delete(ctx, userSnapshotKey(userID))
+}
+
+func userSnapshotKey(userID uint) string {
+	return fmt.Sprintf("user:%d", userID)
+}
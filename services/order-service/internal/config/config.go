@@ -17,6 +17,8 @@ type Config struct {
 	Log            LogConfig
 	UserService    UserServiceConfig
 	CircuitBreaker CircuitBreakerConfig
+	Retry          RetryConfig
+	Bulkhead       BulkheadConfig
 	Auth           AuthConfig
 	Redis          RedisConfig
 	AuditLog       AuditLogConfig
@@ -45,6 +47,14 @@ type LogConfig struct {
 // UserServiceConfig holds user service configuration
 type UserServiceConfig struct {
 	URL string
+	// SnapshotsEnabled keeps the last known good copy of every user fetched,
+	// served as stale for up to SnapshotMaxAge while user-service is down.
+	// SnapshotBackend is "redis" or "memory"; empty uses CACHE_BACKEND.
+	SnapshotsEnabled bool
+	SnapshotBackend  string
+	SnapshotMaxAge   time.Duration
+	// DegradedOrderPolicy is "reject", "accept_unvalidated" or "accept_stale".
+	DegradedOrderPolicy string
 }
 
 // CircuitBreakerConfig holds circuit breaker configuration
@@ -52,33 +62,101 @@ type CircuitBreakerConfig struct {
 	MaxRequests uint32
 	Interval    time.Duration
 	Timeout     time.Duration
+	// Strategies lists the trip strategies to combine; empty keeps the
+	// default of a 50% failure ratio over at least 3 requests per Interval.
+	Strategies          []string
+	ConsecutiveFailures uint32
+	WindowSize          int
+	MinCalls            int
+	FailureRate         float64
+	SlowCall            time.Duration
+	SlowCallRate        float64
+	// OverrideSyncInterval is how often forced states made on other
+	// replicas are picked up.
+	OverrideSyncInterval time.Duration
+}
+
+// RetryConfig holds retry configuration for calls to user-service
+type RetryConfig struct {
+	MaxAttempts   int
+	BaseDelay     time.Duration
+	MaxDelay      time.Duration
+	MaxRetryAfter time.Duration
+	BudgetRatio   float64
+	BudgetBurst   float64
+}
+
+// BulkheadConfig holds the concurrency limit for calls to user-service
+type BulkheadConfig struct {
+	MaxConcurrent int
+	MaxQueue      int
+	QueueTimeout  time.Duration
 }
 
 // AuthConfig holds authentication configuration
 type AuthConfig struct {
-	Secret         string
-	Issuer         string
-	Audience       string
-	TokenTTL       time.Duration
-	ServiceSubject string
-	ServiceRoles   []string
+	// Secret verifies HS256 tokens; it may be empty once JWKSURL is set.
+	Secret   string
+	Issuer   string
+	Audience string
+	TokenTTL time.Duration
+	// ServiceSubject and ServiceClientSecret are the OAuth client order-service
+	// authenticates as at TokenURL to obtain its own and delegated tokens, with
+	// ServiceRoles requested as scope.
+	ServiceSubject      string
+	ServiceClientSecret string
+	ServiceRoles        []string
+	TokenURL            string
+	JWKSURL             string
+	JWKSRefresh         time.Duration
+	// PermissionsFile is a JSON role to permission mapping; empty uses the built-in roles.
+	PermissionsFile string
+	// MFARequiredRoles only grant their permissions to tokens issued after MFA.
+	MFARequiredRoles []string
+	// APIKeyVerifyURL is the user-service endpoint that resolves X-API-Key headers; empty disables API keys.
+	APIKeyVerifyURL string
+	APIKeyCacheTTL  time.Duration
 }
 
 // RedisConfig holds Redis cache configuration
 type RedisConfig struct {
-	Enabled    bool
-	Host       string
-	Port       string
-	Password   string
-	DB         int
-	DefaultTTL time.Duration
+	Enabled bool
+	Backend string
+	Host    string
+	Port    string
+	// Addrs and MasterName select Sentinel or Cluster instead of Host/Port
+	Addrs                 []string
+	MasterName            string
+	Cluster               bool
+	Username              string
+	Password              string
+	SentinelPassword      string
+	DB                    int
+	TLSEnabled            bool
+	TLSCAFile             string
+	TLSCertFile           string
+	TLSKeyFile            string
+	TLSServerName         string
+	TLSInsecureSkipVerify bool
+	DefaultTTL            time.Duration
+	Format                string
+	Compression           string
+	CompressionThreshold  int
+	LocalSize             int
+	LocalTTL              time.Duration
+	MaxEntries            int
 }
 
 // AuditLogConfig holds audit log service configuration.
 type AuditLogConfig struct {
-	Enabled bool
-	URL     string
-	Timeout time.Duration
+	Enabled          bool
+	URL              string
+	Timeout          time.Duration
+	RetryMaxAttempts int
+	// MaxConcurrent caps in-flight posts; MaxQueue more may wait up to QueueTimeout
+	MaxConcurrent int
+	MaxQueue      int
+	QueueTimeout  time.Duration
 }
 
 // Load loads configuration from environment variables
@@ -106,6 +184,86 @@ func Load() (*Config, error) {
 		timeout = 30
 	}
 
+	consecutiveFailures, err := strconv.ParseUint(getEnv("CIRCUIT_BREAKER_CONSECUTIVE_FAILURES", "5"), 10, 32)
+	if err != nil {
+		consecutiveFailures = 5
+	}
+
+	windowSize, err := strconv.Atoi(getEnv("CIRCUIT_BREAKER_WINDOW_SIZE", "20"))
+	if err != nil {
+		windowSize = 20
+	}
+
+	minCalls, err := strconv.Atoi(getEnv("CIRCUIT_BREAKER_MIN_CALLS", "10"))
+	if err != nil {
+		minCalls = 10
+	}
+
+	failureRatePercent, err := strconv.Atoi(getEnv("CIRCUIT_BREAKER_FAILURE_RATE_PERCENT", "50"))
+	if err != nil {
+		failureRatePercent = 50
+	}
+
+	slowCallMillis, err := strconv.Atoi(getEnv("CIRCUIT_BREAKER_SLOW_CALL_MS", "2000"))
+	if err != nil {
+		slowCallMillis = 2000
+	}
+
+	slowCallRatePercent, err := strconv.Atoi(getEnv("CIRCUIT_BREAKER_SLOW_CALL_RATE_PERCENT", "50"))
+	if err != nil {
+		slowCallRatePercent = 50
+	}
+
+	overrideSyncSeconds, err := strconv.Atoi(getEnv("CIRCUIT_BREAKER_OVERRIDE_SYNC_SECONDS", "5"))
+	if err != nil {
+		overrideSyncSeconds = 5
+	}
+
+	retryAttempts, err := strconv.Atoi(getEnv("RETRY_MAX_ATTEMPTS", "3"))
+	if err != nil {
+		retryAttempts = 3
+	}
+
+	retryBaseDelayMillis, err := strconv.Atoi(getEnv("RETRY_BASE_DELAY_MS", "100"))
+	if err != nil {
+		retryBaseDelayMillis = 100
+	}
+
+	retryMaxDelayMillis, err := strconv.Atoi(getEnv("RETRY_MAX_DELAY_MS", "2000"))
+	if err != nil {
+		retryMaxDelayMillis = 2000
+	}
+
+	retryMaxRetryAfterSeconds, err := strconv.Atoi(getEnv("RETRY_MAX_RETRY_AFTER_SECONDS", "10"))
+	if err != nil {
+		retryMaxRetryAfterSeconds = 10
+	}
+
+	retryBudgetPercent, err := strconv.Atoi(getEnv("RETRY_BUDGET_PERCENT", "10"))
+	if err != nil {
+		retryBudgetPercent = 10
+	}
+
+	retryBudgetBurst, err := strconv.Atoi(getEnv("RETRY_BUDGET_BURST", "10"))
+	if err != nil {
+		retryBudgetBurst = 10
+	}
+
+	bulkheadMaxConcurrent, err := strconv.Atoi(getEnv("BULKHEAD_MAX_CONCURRENT", "20"))
+	if err != nil {
+		bulkheadMaxConcurrent = 20
+	}
+
+	bulkheadMaxQueue, err := strconv.Atoi(getEnv("BULKHEAD_MAX_QUEUE", "20"))
+	if err != nil {
+		bulkheadMaxQueue = 20
+	}
+
+	bulkheadQueueTimeoutMillis, err := strconv.Atoi(getEnv("BULKHEAD_QUEUE_TIMEOUT_MS", "500"))
+	if err != nil {
+		bulkheadQueueTimeoutMillis = 500
+	}
+
 	tokenTTLMinutes, err := strconv.Atoi(getEnv("AUTH_TOKEN_TTL_MINUTES", "60"))
 	if err != nil {
 		tokenTTLMinutes = 60
@@ -121,11 +279,68 @@ func Load() (*Config, error) {
 		cacheDB = 0
 	}
 
+	localCacheSize, err := strconv.Atoi(getEnv("REDIS_LOCAL_CACHE_SIZE", "0"))
+	if err != nil {
+		localCacheSize = 0
+	}
+
+	localCacheTTLSeconds, err := strconv.Atoi(getEnv("REDIS_LOCAL_CACHE_TTL_SECONDS", "30"))
+	if err != nil {
+		localCacheTTLSeconds = 30
+	}
+
+	memoryCacheEntries, err := strconv.Atoi(getEnv("CACHE_MEMORY_MAX_ENTRIES", "10000"))
+	if err != nil {
+		memoryCacheEntries = 10000
+	}
+
+	compressionThreshold, err := strconv.Atoi(getEnv("CACHE_COMPRESSION_THRESHOLD_BYTES", "1024"))
+	if err != nil {
+		compressionThreshold = 1024
+	}
+
 	auditTimeoutSeconds, err := strconv.Atoi(getEnv("AUDIT_LOG_SERVICE_TIMEOUT_SECONDS", "3"))
 	if err != nil {
 		auditTimeoutSeconds = 3
 	}
 
+	auditRetryAttempts, err := strconv.Atoi(getEnv("AUDIT_LOG_SERVICE_RETRY_MAX_ATTEMPTS", "2"))
+	if err != nil {
+		auditRetryAttempts = 2
+	}
+
+	auditMaxConcurrent, err := strconv.Atoi(getEnv("AUDIT_LOG_SERVICE_MAX_CONCURRENT", "10"))
+	if err != nil {
+		auditMaxConcurrent = 10
+	}
+
+	auditMaxQueue, err := strconv.Atoi(getEnv("AUDIT_LOG_SERVICE_MAX_QUEUE", "10"))
+	if err != nil {
+		auditMaxQueue = 10
+	}
+
+	auditQueueTimeoutMillis, err := strconv.Atoi(getEnv("AUDIT_LOG_SERVICE_QUEUE_TIMEOUT_MS", "200"))
+	if err != nil {
+		auditQueueTimeoutMillis = 200
+	}
+
+	jwksRefreshSeconds, err := strconv.Atoi(getEnv("AUTH_JWKS_REFRESH_SECONDS", "300"))
+	if err != nil {
+		jwksRefreshSeconds = 300
+	}
+
+	snapshotMaxAgeHours, err := strconv.Atoi(getEnv("USER_SNAPSHOT_MAX_AGE_HOURS", "24"))
+	if err != nil {
+		snapshotMaxAgeHours = 24
+	}
+
+	userServiceURL := strings.TrimRight(getEnv("ORDER_SERVICE_USER_SERVICE_URL", "http://localhost:8081"), "/")
+
+	apiKeyCacheSeconds, err := strconv.Atoi(getEnv("AUTH_API_KEY_CACHE_SECONDS", "30"))
+	if err != nil {
+		apiKeyCacheSeconds = 30
+	}
+
 	config := &Config{
 		Server: ServerConfig{
 			Port:      getEnv("ORDER_SERVICE_PORT", "8082"),
@@ -142,33 +357,88 @@ func Load() (*Config, error) {
 			Level: getEnv("ORDER_SERVICE_LOG_LEVEL", "info"),
 		},
 		UserService: UserServiceConfig{
-			URL: getEnv("ORDER_SERVICE_USER_SERVICE_URL", "http://localhost:8081"),
+			URL:                 userServiceURL,
+			SnapshotsEnabled:    getEnvBool("USER_SNAPSHOT_ENABLED", true),
+			SnapshotBackend:     getEnv("USER_SNAPSHOT_BACKEND", ""),
+			SnapshotMaxAge:      time.Duration(snapshotMaxAgeHours) * time.Hour,
+			DegradedOrderPolicy: getEnv("ORDER_DEGRADED_POLICY", "accept_unvalidated"),
 		},
 		CircuitBreaker: CircuitBreakerConfig{
-			MaxRequests: uint32(maxRequests),
-			Interval:    time.Duration(interval) * time.Second,
-			Timeout:     time.Duration(timeout) * time.Second,
+			MaxRequests:          uint32(maxRequests),
+			Interval:             time.Duration(interval) * time.Second,
+			Timeout:              time.Duration(timeout) * time.Second,
+			Strategies:           getEnvList("CIRCUIT_BREAKER_STRATEGIES", nil),
+			ConsecutiveFailures:  uint32(consecutiveFailures),
+			WindowSize:           windowSize,
+			MinCalls:             minCalls,
+			FailureRate:          float64(failureRatePercent) / 100,
+			SlowCall:             time.Duration(slowCallMillis) * time.Millisecond,
+			SlowCallRate:         float64(slowCallRatePercent) / 100,
+			OverrideSyncInterval: time.Duration(overrideSyncSeconds) * time.Second,
+		},
+		Retry: RetryConfig{
+			MaxAttempts:   retryAttempts,
+			BaseDelay:     time.Duration(retryBaseDelayMillis) * time.Millisecond,
+			MaxDelay:      time.Duration(retryMaxDelayMillis) * time.Millisecond,
+			MaxRetryAfter: time.Duration(retryMaxRetryAfterSeconds) * time.Second,
+			BudgetRatio:   float64(retryBudgetPercent) / 100,
+			BudgetBurst:   float64(retryBudgetBurst),
+		},
+		Bulkhead: BulkheadConfig{
+			MaxConcurrent: bulkheadMaxConcurrent,
+			MaxQueue:      bulkheadMaxQueue,
+			QueueTimeout:  time.Duration(bulkheadQueueTimeoutMillis) * time.Millisecond,
 		},
 		Auth: AuthConfig{
-			Secret:         getEnv("AUTH_JWT_SECRET", "change-me"),
-			Issuer:         getEnv("AUTH_JWT_ISSUER", "enterprise-microservice-system"),
-			Audience:       getEnv("AUTH_JWT_AUDIENCE", "enterprise-microservice-system"),
-			TokenTTL:       time.Duration(tokenTTLMinutes) * time.Minute,
-			ServiceSubject: getEnv("AUTH_SERVICE_SUBJECT", "order-service"),
-			ServiceRoles:   getEnvList("AUTH_SERVICE_ROLES", []string{"service"}),
+			Secret:              getEnvOrEmpty("AUTH_JWT_SECRET", "change-me"),
+			Issuer:              getEnv("AUTH_JWT_ISSUER", "enterprise-microservice-system"),
+			Audience:            getEnv("AUTH_JWT_AUDIENCE", "enterprise-microservice-system"),
+			TokenTTL:            time.Duration(tokenTTLMinutes) * time.Minute,
+			ServiceSubject:      getEnv("AUTH_SERVICE_SUBJECT", "order-service"),
+			ServiceClientSecret: getEnv("AUTH_SERVICE_CLIENT_SECRET", "order-service-secret"),
+			ServiceRoles:        getEnvList("AUTH_SERVICE_ROLES", []string{"service"}),
+			TokenURL:            getEnv("AUTH_TOKEN_URL", userServiceURL+"/oauth/token"),
+			JWKSURL:             getEnv("AUTH_JWKS_URL", ""),
+			JWKSRefresh:         time.Duration(jwksRefreshSeconds) * time.Second,
+			PermissionsFile:     getEnv("AUTH_PERMISSIONS_FILE", ""),
+			MFARequiredRoles:    getEnvList("AUTH_MFA_REQUIRED_ROLES", nil),
+			APIKeyVerifyURL:     getEnv("AUTH_API_KEY_VERIFY_URL", userServiceURL+"/api/v1/auth/api-keys/verify"),
+			APIKeyCacheTTL:      time.Duration(apiKeyCacheSeconds) * time.Second,
 		},
 		Redis: RedisConfig{
-			Enabled:    getEnvBool("REDIS_ENABLED", true),
-			Host:       getEnv("REDIS_HOST", "localhost"),
-			Port:       getEnv("REDIS_PORT", "6379"),
-			Password:   getEnv("REDIS_PASSWORD", ""),
-			DB:         cacheDB,
-			DefaultTTL: time.Duration(cacheTTLSeconds) * time.Second,
+			Enabled:               getEnvBool("REDIS_ENABLED", true),
+			Backend:               getEnv("CACHE_BACKEND", "redis"),
+			Host:                  getEnv("REDIS_HOST", "localhost"),
+			Port:                  getEnv("REDIS_PORT", "6379"),
+			Addrs:                 getEnvList("REDIS_ADDRS", nil),
+			MasterName:            getEnv("REDIS_MASTER_NAME", ""),
+			Cluster:               getEnvBool("REDIS_CLUSTER", false),
+			Username:              getEnv("REDIS_USERNAME", ""),
+			Password:              getEnv("REDIS_PASSWORD", ""),
+			SentinelPassword:      getEnv("REDIS_SENTINEL_PASSWORD", ""),
+			DB:                    cacheDB,
+			TLSEnabled:            getEnvBool("REDIS_TLS_ENABLED", false),
+			TLSCAFile:             getEnv("REDIS_TLS_CA_FILE", ""),
+			TLSCertFile:           getEnv("REDIS_TLS_CERT_FILE", ""),
+			TLSKeyFile:            getEnv("REDIS_TLS_KEY_FILE", ""),
+			TLSServerName:         getEnv("REDIS_TLS_SERVER_NAME", ""),
+			TLSInsecureSkipVerify: getEnvBool("REDIS_TLS_INSECURE_SKIP_VERIFY", false),
+			DefaultTTL:            time.Duration(cacheTTLSeconds) * time.Second,
+			Format:                getEnv("CACHE_FORMAT", "json"),
+			Compression:           getEnv("CACHE_COMPRESSION", "none"),
+			CompressionThreshold:  compressionThreshold,
+			LocalSize:             localCacheSize,
+			LocalTTL:              time.Duration(localCacheTTLSeconds) * time.Second,
+			MaxEntries:            memoryCacheEntries,
 		},
 		AuditLog: AuditLogConfig{
-			Enabled: getEnvBool("AUDIT_LOG_SERVICE_ENABLED", true),
-			URL:     getEnv("AUDIT_LOG_SERVICE_URL", "http://localhost:8083"),
-			Timeout: time.Duration(auditTimeoutSeconds) * time.Second,
+			Enabled:          getEnvBool("AUDIT_LOG_SERVICE_ENABLED", true),
+			URL:              getEnv("AUDIT_LOG_SERVICE_URL", "http://localhost:8083"),
+			Timeout:          time.Duration(auditTimeoutSeconds) * time.Second,
+			RetryMaxAttempts: auditRetryAttempts,
+			MaxConcurrent:    auditMaxConcurrent,
+			MaxQueue:         auditMaxQueue,
+			QueueTimeout:     time.Duration(auditQueueTimeoutMillis) * time.Millisecond,
 		},
 	}
 
@@ -190,6 +460,14 @@ func getEnv(key, defaultValue string) string {
 	return value
 }
 
+// getEnvOrEmpty is getEnv for values that may be deliberately set to empty.
+func getEnvOrEmpty(key, defaultValue string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return strings.TrimSpace(value)
+	}
+	return defaultValue
+}
+
 func getEnvList(key string, defaultValues []string) []string {
 	value := os.Getenv(key)
 	if value == "" {
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"github.com/RashadTanjim/enterprise-microservice-system/common/audit"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/circuitbreaker"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/errors"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/logger"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/response"
+	"enterprise-microservice-system/services/order-service/internal/model"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// CircuitBreakerHandler lists and forces the circuit breakers in the process
+type CircuitBreakerHandler struct {
+	overrides   *circuitbreaker.OverrideStore
+	auditClient *audit.Client
+	logger      *logger.Logger
+}
+
+// ForceCircuitBreakerResponse describes this replica's breaker after it was
+// forced. Shared is false when the override could not be stored for the other
+// replicas yet; it is stored once the shared cache recovers.
+type ForceCircuitBreakerResponse struct {
+	circuitbreaker.Snapshot
+	Shared bool `json:"shared"`
+}
+
+// NewCircuitBreakerHandler creates a new circuit breaker handler. Forced
+// states are shared with the other replicas through overrides.
+func NewCircuitBreakerHandler(overrides *circuitbreaker.OverrideStore, auditClient *audit.Client, logger *logger.Logger) *CircuitBreakerHandler {
+	return &CircuitBreakerHandler{
+		overrides:   overrides,
+		auditClient: auditClient,
+		logger:      logger,
+	}
+}
+
+// ListCircuitBreakers handles listing circuit breaker states and counts
+// @Summary List circuit breakers
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=[]circuitbreaker.Snapshot}
+// @Failure 403 {object} response.Response
+// @Router /admin/circuit-breakers [get]
+func (h *CircuitBreakerHandler) ListCircuitBreakers(c *gin.Context) {
+	breakers := circuitbreaker.List()
+	snapshots := make([]circuitbreaker.Snapshot, 0, len(breakers))
+	for _, cb := range breakers {
+		snapshots = append(snapshots, cb.Snapshot())
+	}
+	response.Success(c, snapshots)
+}
+
+// ForceCircuitBreaker handles forcing a circuit breaker open or closed. The
+// override applies here at once and on the other replicas within the sync
+// interval; the response describes this replica's breaker and whether the
+// override reached the other replicas.
+// @Summary Force a circuit breaker open or closed, or return it to automatic
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Breaker name"
+// @Param request body model.ForceCircuitBreakerRequest true "State"
+// @Success 200 {object} response.Response{data=ForceCircuitBreakerResponse}
+// @Failure 400 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /admin/circuit-breakers/{name} [put]
+func (h *CircuitBreakerHandler) ForceCircuitBreaker(c *gin.Context) {
+	var req model.ForceCircuitBreakerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Invalid request body", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+
+	name := c.Param("name")
+	cb, ok := circuitbreaker.Lookup(name)
+	if !ok {
+		response.Error(c, errors.NewNotFound("circuit breaker"))
+		return
+	}
+
+	override := circuitbreaker.OverrideNone
+	switch req.State {
+	case model.CircuitBreakerForceOpen:
+		override = circuitbreaker.OverrideOpen
+	case model.CircuitBreakerForceClosed:
+		override = circuitbreaker.OverrideClosed
+	}
+	shared := true
+	if err := h.overrides.Force(c.Request.Context(), cb, override); err != nil {
+		shared = false
+		h.logger.Warn("Circuit breaker override not shared with other instances",
+			zap.String("service", name),
+			zap.Error(err),
+		)
+	}
+
+	actor := resolveActor(c)
+	h.logger.Warn("Circuit breaker forced",
+		zap.String("service", name),
+		zap.String("state", req.State),
+		zap.String("actor", actor),
+	)
+	if h.auditClient != nil {
+		h.auditClient.Track(c.Request.Context(), audit.Event{
+			Actor:        actor,
+			Action:       "circuit_breaker.force",
+			ResourceType: "circuit_breaker",
+			ResourceID:   name,
+			Description:  "Circuit breaker forced " + req.State,
+			Metadata: encodeMetadata(map[string]interface{}{
+				"shared": shared,
+			}),
+		}, c.GetHeader("Authorization"))
+	}
+	response.Success(c, ForceCircuitBreakerResponse{Snapshot: cb.Snapshot(), Shared: shared})
+}
@@ -3,6 +3,8 @@ package handler
 import (
 	"encoding/json"
 	"github.com/RashadTanjim/enterprise-microservice-system/common/audit"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/auth"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/errors"
 	"github.com/RashadTanjim/enterprise-microservice-system/common/logger"
 	"github.com/RashadTanjim/enterprise-microservice-system/common/middleware"
 	"github.com/RashadTanjim/enterprise-microservice-system/common/response"
@@ -41,6 +43,7 @@ func NewOrderHandler(service service.OrderService, auditClient *audit.Client, lo
 // @Param order body model.CreateOrderRequest true "Order data"
 // @Success 201 {object} response.Response{data=model.Order}
 // @Failure 400 {object} response.Response
+// @Failure 403 {object} response.Response
 // @Failure 404 {object} response.Response
 // @Router /orders [post]
 func (h *OrderHandler) CreateOrder(c *gin.Context) {
@@ -51,6 +54,20 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 		return
 	}
 
+	ownerID, err := orderOwner(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	if ownerID != nil && req.UserID != *ownerID {
+		h.logger.Warn("Rejected order for another user",
+			zap.String("subject", resolveActor(c)),
+			zap.Uint("user_id", req.UserID),
+		)
+		response.Error(c, errors.New(errors.ErrCodeForbidden, "orders can only be created for your own user", nil))
+		return
+	}
+
 	actor := resolveActor(c)
 	order, err := h.service.CreateOrder(c.Request.Context(), &req, actor)
 	if err != nil {
@@ -92,7 +109,13 @@ func (h *OrderHandler) GetOrder(c *gin.Context) {
 		return
 	}
 
-	order, err := h.service.GetOrder(c.Request.Context(), uint(id))
+	ownerID, err := orderOwner(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	order, err := h.service.GetOrder(c.Request.Context(), uint(id), ownerID)
 	if err != nil {
 		h.logger.Error("Failed to get order", zap.Uint64("order_id", id), zap.Error(err))
 		response.Error(c, err)
@@ -200,7 +223,7 @@ func (h *OrderHandler) DeleteOrder(c *gin.Context) {
 // @Security BearerAuth
 // @Param page query int false "Page number" default(1)
 // @Param page_size query int false "Page size" default(10)
-// @Param user_id query int false "Filter by user ID"
+// @Param user_id query int false "Filter by user ID (ignored for non-admin callers)"
 // @Param order_status query string false "Filter by order status"
 // @Param status query string false "Filter by record status (active/deleted)"
 // @Param product_id query string false "Filter by product ID"
@@ -214,6 +237,15 @@ func (h *OrderHandler) ListOrders(c *gin.Context) {
 		return
 	}
 
+	ownerID, err := orderOwner(c)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	if ownerID != nil {
+		query.UserID = ownerID
+	}
+
 	orders, total, err := h.service.ListOrders(c.Request.Context(), &query)
 	if err != nil {
 		h.logger.Error("Failed to list orders", zap.Error(err))
@@ -247,9 +279,27 @@ func (h *OrderHandler) ListOrders(c *gin.Context) {
 	})
 }
 
+// orderOwner returns the user ID a caller's order access is restricted to, or
+// nil for callers granted orders:all. Other subjects must be a user ID.
+func orderOwner(c *gin.Context) (*uint, error) {
+	if middleware.HasPermission(c, auth.PermOrdersAll) {
+		return nil, nil
+	}
+
+	subject, _ := middleware.GetAuthSubject(c)
+	userID, err := strconv.ParseUint(subject, 10, 32)
+	if err != nil {
+		return nil, errors.New(errors.ErrCodeForbidden, "caller is not bound to a user", nil)
+	}
+	owner := uint(userID)
+	return &owner, nil
+}
+
+// resolveActor identifies the caller for created_by/updated_by and audit
+// events. Delegated and impersonation tokens record both identities, e.g. "42 via 1".
 func resolveActor(c *gin.Context) string {
-	if subject, ok := middleware.GetAuthSubject(c); ok && subject != "" {
-		return subject
+	if principal, ok := middleware.GetAuthPrincipal(c); ok && principal != "" {
+		return principal
 	}
 	return "system"
 }
@@ -0,0 +1,14 @@
+package model
+
+// Circuit breaker states accepted by ForceCircuitBreakerRequest. Auto
+// clears a forced state so the breaker follows call outcomes again.
+const (
+	CircuitBreakerForceOpen   = "open"
+	CircuitBreakerForceClosed = "closed"
+	CircuitBreakerForceAuto   = "auto"
+)
+
+// ForceCircuitBreakerRequest represents the request to force a circuit breaker state
+type ForceCircuitBreakerRequest struct {
+	State string `json:"state" binding:"required,oneof=open closed auto"`
+}
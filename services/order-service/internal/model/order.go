@@ -83,6 +83,10 @@ type User struct {
 	Name   string `json:"name"`
 	Age    int    `json:"age"`
 	Status string `json:"status"`
+	// Stale marks a last known good copy served while user-service was
+	// unavailable; FetchedAt is when that copy was fetched.
+	Stale     bool       `json:"stale,omitempty"`
+	FetchedAt *time.Time `json:"fetched_at,omitempty"`
 }
 
 // OrderWithUser combines order with user data
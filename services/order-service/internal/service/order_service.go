@@ -18,25 +18,78 @@ import (
 // OrderService defines the business logic interface for orders
 type OrderService interface {
 	CreateOrder(ctx context.Context, req *model.CreateOrderRequest, actor string) (*model.OrderWithUser, error)
-	GetOrder(ctx context.Context, id uint) (*model.OrderWithUser, error)
+	GetOrder(ctx context.Context, id uint, ownerID *uint) (*model.OrderWithUser, error)
 	UpdateOrder(ctx context.Context, id uint, req *model.UpdateOrderRequest, actor string) (*model.Order, error)
 	DeleteOrder(ctx context.Context, id uint, actor string) error
 	ListOrders(ctx context.Context, query *model.ListOrdersQuery) ([]*model.Order, int64, error)
 }
 
+var (
+	// Missing orders are cached briefly so lookups of unknown IDs do not all
+	// reach the database.
+	orderCacheOptions     = cache.LoadOptions{NegativeTTL: 30 * time.Second, EarlyRefresh: 1}
+	orderListCacheOptions = cache.LoadOptions{TTL: 60 * time.Second, EarlyRefresh: 1, Tags: []string{orderListCacheTag}}
+)
+
+// orderListCacheTag tags every cached ListOrders page.
+const orderListCacheTag = "orders:list"
+
+// orderPage is the cached form of a ListOrders result.
+type orderPage struct {
+	Orders []*model.Order `json:"orders"`
+	Total  int64          `json:"total"`
+}
+
+// DegradedPolicy decides how orders are created while user-service is
+// unavailable and users cannot be validated live.
+type DegradedPolicy string
+
+const (
+	// DegradedPolicyReject refuses orders that cannot be validated live.
+	DegradedPolicyReject DegradedPolicy = "reject"
+	// DegradedPolicyAcceptUnvalidated accepts every order; a stale copy of
+	// the user is returned when there is one but not enforced.
+	DegradedPolicyAcceptUnvalidated DegradedPolicy = "accept_unvalidated"
+	// DegradedPolicyAcceptStale validates orders against the last known good
+	// copy of the user and refuses them when there is none.
+	DegradedPolicyAcceptStale DegradedPolicy = "accept_stale"
+)
+
+// ParseDegradedPolicy validates a policy name; empty selects
+// DegradedPolicyAcceptUnvalidated.
+func ParseDegradedPolicy(name string) (DegradedPolicy, error) {
+	switch policy := DegradedPolicy(strings.ToLower(strings.TrimSpace(name))); policy {
+	case "":
+		return DegradedPolicyAcceptUnvalidated, nil
+	case DegradedPolicyReject, DegradedPolicyAcceptUnvalidated, DegradedPolicyAcceptStale:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown degraded order policy %q", name)
+	}
+}
+
 // orderService implements OrderService
 type orderService struct {
-	repo       repository.OrderRepository
-	userClient client.UserServiceClient
-	cache      *cache.Cache
+	repo           repository.OrderRepository
+	userClient     client.UserServiceClient
+	cache          cache.Cache
+	degradedPolicy DegradedPolicy
 }
 
-// NewOrderService creates a new order service
-func NewOrderService(repo repository.OrderRepository, userClient client.UserServiceClient, cacheClient *cache.Cache) OrderService {
+// NewOrderService creates a new order service. An empty degradedPolicy
+// selects DegradedPolicyAcceptUnvalidated.
+func NewOrderService(repo repository.OrderRepository, userClient client.UserServiceClient, cacheClient cache.Cache, degradedPolicy DegradedPolicy) OrderService {
+	if cacheClient == nil {
+		cacheClient = cache.NewNoopCache()
+	}
+	if degradedPolicy == "" {
+		degradedPolicy = DegradedPolicyAcceptUnvalidated
+	}
 	return &orderService{
-		repo:       repo,
-		userClient: userClient,
-		cache:      cacheClient,
+		repo:           repo,
+		userClient:     userClient,
+		cache:          cacheClient,
+		degradedPolicy: degradedPolicy,
 	}
 }
 
@@ -45,43 +98,22 @@ func (s *orderService) CreateOrder(ctx context.Context, req *model.CreateOrderRe
 	// Validate user exists via circuit breaker protected call
 	user, err := s.userClient.GetUser(ctx, req.UserID)
 	if err != nil {
-		// If circuit breaker is open or user service is down, still create order
-		// but return without user data (graceful degradation)
-		if appErr, ok := err.(*errors.AppError); ok {
-			if appErr.Code == errors.ErrCodeCircuitOpen || appErr.Code == errors.ErrCodeServiceUnavail {
-				// Create order without user validation
-				order := &model.Order{
-					UserID:      req.UserID,
-					ProductID:   req.ProductID,
-					Quantity:    req.Quantity,
-					TotalPrice:  req.TotalPrice,
-					OrderStatus: model.OrderStatusPending,
-					Status:      model.OrderRecordStatusActive,
-				}
-
-				if actor == "" {
-					actor = "system"
-				}
-				order.CreatedBy = actor
-				order.UpdatedBy = actor
-
-				if err := s.repo.Create(ctx, order); err != nil {
-					return nil, errors.NewInternal("failed to create order", err)
-				}
-
-				result := &model.OrderWithUser{
-					Order: *order,
-					User:  nil, // User data unavailable
-				}
-				s.cacheSetOrder(ctx, result)
-				return result, nil
+		if !isUserServiceUnavailable(err) || s.degradedPolicy != DegradedPolicyAcceptUnvalidated {
+			return nil, err
+		}
+		// User service is down and there is no stale copy: create the order
+		// without validation and without user data (graceful degradation)
+		user = nil
+	} else if user.Stale {
+		switch s.degradedPolicy {
+		case DegradedPolicyReject:
+			return nil, errors.New(errors.ErrCodeServiceUnavail, "user service unavailable, cannot validate user", nil)
+		case DegradedPolicyAcceptStale:
+			if user.Status != "active" {
+				return nil, errors.NewBadRequest("user is not active")
 			}
 		}
-		return nil, err
-	}
-
-	// Check if user is active
-	if user.Status != "active" {
+	} else if user.Status != "active" {
 		return nil, errors.NewBadRequest("user is not active")
 	}
 
@@ -105,7 +137,7 @@ func (s *orderService) CreateOrder(ctx context.Context, req *model.CreateOrderRe
 		return nil, errors.NewInternal("failed to create order", err)
 	}
 
-	s.cacheSetOrder(ctx, &model.OrderWithUser{Order: *order, User: user})
+	s.invalidateOrder(ctx, order.ID)
 
 	return &model.OrderWithUser{
 		Order: *order,
@@ -113,33 +145,46 @@ func (s *orderService) CreateOrder(ctx context.Context, req *model.CreateOrderRe
 	}, nil
 }
 
-// GetOrder retrieves an order by ID with user data
-func (s *orderService) GetOrder(ctx context.Context, id uint) (*model.OrderWithUser, error) {
-	if cached := s.cacheGetOrder(ctx, id); cached != nil {
-		return cached, nil
-	}
+// isUserServiceUnavailable reports whether err means user-service could not
+// answer, as opposed to answering that the user is invalid.
+func isUserServiceUnavailable(err error) bool {
+	appErr, ok := err.(*errors.AppError)
+	return ok && (appErr.Code == errors.ErrCodeCircuitOpen || appErr.Code == errors.ErrCodeServiceUnavail)
+}
 
-	order, err := s.repo.FindByID(ctx, id)
-	if err != nil {
+// GetOrder retrieves an order by ID with user data. When ownerID is set, orders
+// belonging to other users are reported as not found.
+func (s *orderService) GetOrder(ctx context.Context, id uint, ownerID *uint) (*model.OrderWithUser, error) {
+	order, err := cache.GetOrLoad(ctx, s.cache, orderCacheKey(id), orderCacheOptions, func(ctx context.Context) (*model.Order, error) {
+		order, err := s.repo.FindByID(ctx, id)
 		if err == gorm.ErrRecordNotFound {
+			return nil, cache.ErrNotFound
+		}
+		return order, err
+	})
+	if err != nil {
+		if err == cache.ErrNotFound {
 			return nil, errors.NewNotFound("order")
 		}
 		return nil, errors.NewInternal("failed to get order", err)
 	}
+	if !ownedBy(order, ownerID) {
+		return nil, errors.NewNotFound("order")
+	}
 
-	// Try to fetch user data (graceful degradation if user service is down)
+	// Try to fetch user data (graceful degradation if user service is down;
+	// the user may then be a stale copy). Users are cached by user-service, so
+	// only the order record is cached here.
 	user, err := s.userClient.GetUser(ctx, order.UserID)
 	if err != nil {
 		// Log error but continue without user data
 		user = nil
 	}
 
-	result := &model.OrderWithUser{
+	return &model.OrderWithUser{
 		Order: *order,
 		User:  user,
-	}
-	s.cacheSetOrder(ctx, result)
-	return result, nil
+	}, nil
 }
 
 // UpdateOrder updates an order
@@ -167,7 +212,7 @@ func (s *orderService) UpdateOrder(ctx context.Context, id uint, req *model.Upda
 		return nil, errors.NewInternal("failed to update order", err)
 	}
 
-	s.cacheDeleteOrder(ctx, id)
+	s.invalidateOrder(ctx, id)
 
 	return order, nil
 }
@@ -192,7 +237,7 @@ func (s *orderService) DeleteOrder(ctx context.Context, id uint, actor string) e
 		return errors.NewInternal("failed to delete order", err)
 	}
 
-	s.cacheDeleteOrder(ctx, id)
+	s.invalidateOrder(ctx, id)
 
 	return nil
 }
@@ -201,80 +246,29 @@ func (s *orderService) DeleteOrder(ctx context.Context, id uint, actor string) e
 func (s *orderService) ListOrders(ctx context.Context, query *model.ListOrdersQuery) ([]*model.Order, int64, error) {
 	query.ApplyDefaults()
 
-	if cachedOrders, cachedTotal, ok := s.cacheGetOrderList(ctx, query); ok {
-		return cachedOrders, cachedTotal, nil
-	}
-
-	orders, total, err := s.repo.List(ctx, query)
+	page, err := cache.GetOrLoad(ctx, s.cache, s.orderListCacheKey(query), orderListCacheOptions, func(ctx context.Context) (orderPage, error) {
+		orders, total, err := s.repo.List(ctx, query)
+		return orderPage{Orders: orders, Total: total}, err
+	})
 	if err != nil {
 		return nil, 0, errors.NewInternal("failed to list orders", err)
 	}
-
-	s.cacheSetOrderList(ctx, query, orders, total)
-	return orders, total, nil
-}
-
-func (s *orderService) cacheGetOrder(ctx context.Context, id uint) *model.OrderWithUser {
-	if s.cache == nil || !s.cache.Enabled() {
-		return nil
-	}
-
-	var payload model.OrderWithUser
-	found, err := s.cache.GetJSON(ctx, fmt.Sprintf("order:%d", id), &payload)
-	if err != nil || !found {
-		return nil
-	}
-	return &payload
-}
-
-func (s *orderService) cacheSetOrder(ctx context.Context, order *model.OrderWithUser) {
-	if s.cache == nil || !s.cache.Enabled() || order == nil {
-		return
-	}
-
-	_ = s.cache.SetJSON(ctx, fmt.Sprintf("order:%d", order.ID), order, 0)
+	return page.Orders, page.Total, nil
 }
 
-func (s *orderService) cacheDeleteOrder(ctx context.Context, id uint) {
-	if s.cache == nil || !s.cache.Enabled() {
-		return
-	}
-	_ = s.cache.Delete(ctx, fmt.Sprintf("order:%d", id))
+// ownedBy reports whether an order is visible to a caller restricted to ownerID
+func ownedBy(order *model.Order, ownerID *uint) bool {
+	return ownerID == nil || order.UserID == *ownerID
 }
 
-func (s *orderService) cacheGetOrderList(ctx context.Context, query *model.ListOrdersQuery) ([]*model.Order, int64, bool) {
-	if s.cache == nil || !s.cache.Enabled() {
-		return nil, 0, false
-	}
-
-	key := s.orderListCacheKey(query)
-	var payload struct {
-		Orders []*model.Order `json:"orders"`
-		Total  int64          `json:"total"`
-	}
-
-	found, err := s.cache.GetJSON(ctx, key, &payload)
-	if err != nil || !found {
-		return nil, 0, false
-	}
-	return payload.Orders, payload.Total, true
+// invalidateOrder drops the cached order and every cached list page.
+func (s *orderService) invalidateOrder(ctx context.Context, id uint) {
+	_ = s.cache.Delete(ctx, orderCacheKey(id))
+	_ = s.cache.InvalidateTags(ctx, orderListCacheTag)
 }
 
-func (s *orderService) cacheSetOrderList(ctx context.Context, query *model.ListOrdersQuery, orders []*model.Order, total int64) {
-	if s.cache == nil || !s.cache.Enabled() {
-		return
-	}
-
-	key := s.orderListCacheKey(query)
-	payload := struct {
-		Orders []*model.Order `json:"orders"`
-		Total  int64          `json:"total"`
-	}{
-		Orders: orders,
-		Total:  total,
-	}
-
-	_ = s.cache.SetJSON(ctx, key, payload, 60*time.Second)
+func orderCacheKey(id uint) string {
+	return fmt.Sprintf("order:%d", id)
 }
 
 func (s *orderService) orderListCacheKey(query *model.ListOrdersQuery) string {
@@ -3,10 +3,12 @@ package tests
 import (
 	"context"
 	"testing"
+	"time"
 
 	"enterprise-microservice-system/services/order-service/internal/client"
 	"enterprise-microservice-system/services/order-service/internal/model"
 	"enterprise-microservice-system/services/order-service/internal/service"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/errors"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -77,7 +79,7 @@ var _ client.UserServiceClient = (*MockUserClient)(nil)
 func TestCreateOrder_SetsAuditAndStatus(t *testing.T) {
 	repo := new(MockOrderRepository)
 	userClient := new(MockUserClient)
-	svc := service.NewOrderService(repo, userClient, nil)
+	svc := service.NewOrderService(repo, userClient, nil, "")
 
 	req := &model.CreateOrderRequest{
 		UserID:     1,
@@ -107,7 +109,7 @@ func TestCreateOrder_SetsAuditAndStatus(t *testing.T) {
 func TestUpdateOrder_UpdatesStatus(t *testing.T) {
 	repo := new(MockOrderRepository)
 	userClient := new(MockUserClient)
-	svc := service.NewOrderService(repo, userClient, nil)
+	svc := service.NewOrderService(repo, userClient, nil, "")
 
 	existing := &model.Order{ID: 10, OrderStatus: model.OrderStatusPending, Status: model.OrderRecordStatusActive}
 	newStatus := model.OrderStatusConfirmed
@@ -128,7 +130,7 @@ func TestUpdateOrder_UpdatesStatus(t *testing.T) {
 func TestDeleteOrder_UsesActor(t *testing.T) {
 	repo := new(MockOrderRepository)
 	userClient := new(MockUserClient)
-	svc := service.NewOrderService(repo, userClient, nil)
+	svc := service.NewOrderService(repo, userClient, nil, "")
 
 	repo.On("FindByID", mock.Anything, uint(7)).Return(&model.Order{ID: 7}, nil)
 	repo.On("Delete", mock.Anything, uint(7), "tester").Return(nil)
@@ -138,3 +140,83 @@ func TestDeleteOrder_UsesActor(t *testing.T) {
 	assert.NoError(t, err)
 	repo.AssertExpectations(t)
 }
+
+func TestGetOrder_HidesOtherUsersOrders(t *testing.T) {
+	repo := new(MockOrderRepository)
+	userClient := new(MockUserClient)
+	svc := service.NewOrderService(repo, userClient, nil, "")
+
+	repo.On("FindByID", mock.Anything, uint(5)).Return(&model.Order{ID: 5, UserID: 42}, nil)
+	userClient.On("GetUser", mock.Anything, uint(42)).Return(&model.User{ID: 42, Status: "active"}, nil)
+
+	owner := uint(42)
+	result, err := svc.GetOrder(context.Background(), 5, &owner)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(42), result.UserID)
+
+	other := uint(7)
+	result, err = svc.GetOrder(context.Background(), 5, &other)
+	assert.Nil(t, result)
+	appErr, ok := err.(*errors.AppError)
+	assert.True(t, ok)
+	assert.Equal(t, errors.ErrCodeNotFound, appErr.Code)
+	userClient.AssertNumberOfCalls(t, "GetUser", 1)
+}
+
+func TestCreateOrder_DegradedPolicies(t *testing.T) {
+	req := &model.CreateOrderRequest{UserID: 3, ProductID: "PROD-1", Quantity: 1, TotalPrice: 10}
+	fetchedAt := time.Now().Add(-time.Hour)
+	staleActive := &model.User{ID: 3, Status: "active", Stale: true, FetchedAt: &fetchedAt}
+	staleInactive := &model.User{ID: 3, Status: "inactive", Stale: true, FetchedAt: &fetchedAt}
+	unavailable := errors.NewCircuitOpen("user-service")
+
+	cases := []struct {
+		name    string
+		policy  service.DegradedPolicy
+		user    *model.User
+		err     error
+		created bool
+	}{
+		{"reject refuses stale users", service.DegradedPolicyReject, staleActive, nil, false},
+		{"reject refuses without user data", service.DegradedPolicyReject, nil, unavailable, false},
+		{"accept_stale validates stale users", service.DegradedPolicyAcceptStale, staleActive, nil, true},
+		{"accept_stale enforces stale status", service.DegradedPolicyAcceptStale, staleInactive, nil, false},
+		{"accept_stale refuses without user data", service.DegradedPolicyAcceptStale, nil, unavailable, false},
+		{"accept_unvalidated ignores stale status", service.DegradedPolicyAcceptUnvalidated, staleInactive, nil, true},
+		{"accept_unvalidated accepts without user data", service.DegradedPolicyAcceptUnvalidated, nil, unavailable, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := new(MockOrderRepository)
+			userClient := new(MockUserClient)
+			svc := service.NewOrderService(repo, userClient, nil, tc.policy)
+
+			userClient.On("GetUser", mock.Anything, uint(3)).Return(tc.user, tc.err)
+			repo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+			result, err := svc.CreateOrder(context.Background(), req, "tester")
+			if !tc.created {
+				assert.Error(t, err)
+				repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.user, result.User)
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestParseDegradedPolicy(t *testing.T) {
+	policy, err := service.ParseDegradedPolicy("")
+	assert.NoError(t, err)
+	assert.Equal(t, service.DegradedPolicyAcceptUnvalidated, policy)
+
+	policy, err = service.ParseDegradedPolicy(" Accept_Stale ")
+	assert.NoError(t, err)
+	assert.Equal(t, service.DegradedPolicyAcceptStale, policy)
+
+	_, err = service.ParseDegradedPolicy("sometimes")
+	assert.Error(t, err)
+}
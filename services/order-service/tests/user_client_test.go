@@ -0,0 +1,206 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"enterprise-microservice-system/services/order-service/internal/client"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/auth"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/cache"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/circuitbreaker"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/retry"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserClient_ExchangesCallerToken(t *testing.T) {
+	var tokenRequests []url.Values
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/oauth/token" {
+			clientID, secret, ok := r.BasicAuth()
+			require.True(t, ok)
+			assert.Equal(t, "order-service", clientID)
+			assert.Equal(t, "secret", secret)
+			require.NoError(t, r.ParseForm())
+			tokenRequests = append(tokenRequests, r.PostForm)
+			token := "service-token"
+			if r.PostForm.Get("subject_token") != "" {
+				token = "delegated-" + r.PostForm.Get("subject_token")
+			}
+			_, _ = w.Write([]byte(`{"access_token":"` + token + `","token_type":"Bearer","expires_in":3600}`))
+			return
+		}
+		received = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		_, _ = w.Write([]byte(`{"success":true,"data":{"id":7,"email":"user@example.com","name":"User"}}`))
+	}))
+	defer server.Close()
+
+	source := auth.NewTokenSource(server.URL+"/oauth/token", "order-service", "secret", "service")
+	cb := circuitbreaker.New("user-service", circuitbreaker.Config{MaxRequests: 1, Interval: time.Minute, Timeout: time.Minute})
+	userClient := client.NewUserClient(server.URL, cb, nil, nil, nil, client.NewExchangingTokenProvider(source))
+
+	// Without a caller the service calls as itself.
+	_, err := userClient.GetUser(context.Background(), 7)
+	require.NoError(t, err)
+	assert.Equal(t, "service-token", received)
+	assert.Equal(t, "client_credentials", tokenRequests[0].Get("grant_type"))
+	assert.Equal(t, "service", tokenRequests[0].Get("scope"))
+
+	// The caller's token is exchanged once and the result reused.
+	ctx := auth.ContextWithToken(context.Background(), "caller-token")
+	for i := 0; i < 2; i++ {
+		_, err = userClient.GetUser(ctx, 7)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, "delegated-caller-token", received)
+	require.Len(t, tokenRequests, 2)
+	assert.Equal(t, "urn:ietf:params:oauth:grant-type:token-exchange", tokenRequests[1].Get("grant_type"))
+	assert.Equal(t, "urn:ietf:params:oauth:token-type:access_token", tokenRequests[1].Get("subject_token_type"))
+}
+
+func TestUserClient_NotFoundDoesNotOpenCircuit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	cb := circuitbreaker.New("user-service", circuitbreaker.Config{MaxRequests: 1, Interval: time.Minute, Timeout: time.Minute})
+	userClient := client.NewUserClient(server.URL, cb, nil, nil, nil, nil)
+
+	for i := 0; i < 10; i++ {
+		_, err := userClient.GetUser(context.Background(), 7)
+		require.Error(t, err)
+	}
+	assert.Equal(t, float64(0), userClient.GetCircuitBreakerState())
+}
+
+func TestUserClient_RetriesUnavailableUserService(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"success":true,"data":{"id":7,"email":"user@example.com","name":"User"}}`))
+	}))
+	defer server.Close()
+
+	cb := circuitbreaker.New("user-service", circuitbreaker.Config{MaxRequests: 1, Interval: time.Minute, Timeout: time.Minute})
+	retrier := retry.New(retry.Config{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond, MaxRetryAfter: time.Second})
+	userClient := client.NewUserClient(server.URL, cb, nil, retrier, nil, nil)
+
+	user, err := userClient.GetUser(context.Background(), 7)
+	require.NoError(t, err)
+	assert.Equal(t, uint(7), user.ID)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestUserClient_ServesStaleSnapshotWhenUnavailable(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusOK)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if code := int(status.Load()); code != http.StatusOK {
+			w.WriteHeader(code)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"success":true,"data":{"id":7,"email":"user@example.com","name":"User","status":"active"}}`))
+	}))
+	defer server.Close()
+
+	memory, err := cache.NewMemoryCache(cache.Config{DefaultTTL: time.Minute})
+	require.NoError(t, err)
+	snapshots := client.NewUserSnapshotStore(memory, time.Hour)
+	cb := circuitbreaker.New("user-service", circuitbreaker.Config{
+		Timeout:    time.Minute,
+		Strategies: []circuitbreaker.TripStrategy{circuitbreaker.ConsecutiveFailures(10)},
+	})
+	userClient := client.NewUserClient(server.URL, cb, nil, nil, snapshots, nil)
+
+	user, err := userClient.GetUser(context.Background(), 7)
+	require.NoError(t, err)
+	assert.False(t, user.Stale)
+
+	// Unknown users have no snapshot to fall back to.
+	status.Store(http.StatusServiceUnavailable)
+	_, err = userClient.GetUser(context.Background(), 8)
+	require.Error(t, err)
+
+	user, err = userClient.GetUser(context.Background(), 7)
+	require.NoError(t, err)
+	assert.True(t, user.Stale)
+
+	// An open circuit serves the snapshot too.
+	cb.Force(circuitbreaker.OverrideOpen)
+	user, err = userClient.GetUser(context.Background(), 7)
+	require.NoError(t, err)
+	assert.True(t, user.Stale)
+	cb.Force(circuitbreaker.OverrideNone)
+	assert.NotNil(t, user.FetchedAt)
+	assert.Equal(t, "user@example.com", user.Email)
+
+	// A user reported gone is forgotten.
+	status.Store(http.StatusNotFound)
+	_, err = userClient.GetUser(context.Background(), 7)
+	require.Error(t, err)
+	status.Store(http.StatusServiceUnavailable)
+	_, err = userClient.GetUser(context.Background(), 7)
+	require.Error(t, err)
+}
+
+func TestUserClient_TokenFailureServesSnapshotWithoutOpeningCircuit(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"success":true,"data":{"id":7,"email":"user@example.com","name":"User","status":"active"}}`))
+	}))
+	defer server.Close()
+
+	var tokenFails atomic.Bool
+	var tokenCalls atomic.Int32
+	provider := func(ctx context.Context) (string, error) {
+		tokenCalls.Add(1)
+		if tokenFails.Load() {
+			return "", errors.New("token endpoint unavailable")
+		}
+		return "service-token", nil
+	}
+
+	memory, err := cache.NewMemoryCache(cache.Config{DefaultTTL: time.Minute})
+	require.NoError(t, err)
+	snapshots := client.NewUserSnapshotStore(memory, time.Hour)
+	cb := circuitbreaker.New("user-service", circuitbreaker.Config{
+		Timeout:    time.Minute,
+		Strategies: []circuitbreaker.TripStrategy{circuitbreaker.ConsecutiveFailures(1)},
+	})
+	retrier := retry.New(retry.Config{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+	userClient := client.NewUserClient(server.URL, cb, nil, retrier, snapshots, provider)
+
+	_, err = userClient.GetUser(context.Background(), 7)
+	require.NoError(t, err)
+
+	tokenFails.Store(true)
+	tokenCalls.Store(0)
+	user, err := userClient.GetUser(context.Background(), 7)
+	require.NoError(t, err)
+	assert.True(t, user.Stale)
+	assert.Equal(t, int32(1), tokenCalls.Load())
+	assert.Equal(t, int32(1), calls.Load())
+
+	_, err = userClient.GetUser(context.Background(), 8)
+	require.Error(t, err)
+	assert.Equal(t, float64(0), userClient.GetCircuitBreakerState())
+}
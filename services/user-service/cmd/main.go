@@ -11,10 +11,12 @@ import (
 	"fmt"
 	"github.com/RashadTanjim/enterprise-microservice-system/common/audit"
 	"github.com/RashadTanjim/enterprise-microservice-system/common/auth"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/bulkhead"
 	"github.com/RashadTanjim/enterprise-microservice-system/common/cache"
 	"github.com/RashadTanjim/enterprise-microservice-system/common/logger"
 	"github.com/RashadTanjim/enterprise-microservice-system/common/metrics"
 	"github.com/RashadTanjim/enterprise-microservice-system/common/middleware"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/retry"
 	"net/http"
 	"os"
 	"os/signal"
@@ -53,45 +55,192 @@ func main() {
 		log.Fatal("Failed to connect to database", zap.Error(err))
 	}
 
+	// Initialize metrics
+	metricsCollector := metrics.NewMetrics("user_service")
+
 	// Initialize dependencies
 	userRepo := repository.NewUserRepository(db)
-	userCache, err := cache.NewRedisCache(cache.Config{
-		Enabled:    cfg.Redis.Enabled,
-		Host:       cfg.Redis.Host,
-		Port:       cfg.Redis.Port,
-		Password:   cfg.Redis.Password,
-		DB:         cfg.Redis.DB,
-		DefaultTTL: cfg.Redis.DefaultTTL,
-	}, "user-service")
+	cacheConfig := cache.Config{
+		Enabled:          cfg.Redis.Enabled,
+		Backend:          cfg.Redis.Backend,
+		Host:             cfg.Redis.Host,
+		Port:             cfg.Redis.Port,
+		Addrs:            cfg.Redis.Addrs,
+		MasterName:       cfg.Redis.MasterName,
+		Cluster:          cfg.Redis.Cluster,
+		Username:         cfg.Redis.Username,
+		Password:         cfg.Redis.Password,
+		SentinelPassword: cfg.Redis.SentinelPassword,
+		DB:               cfg.Redis.DB,
+		TLS: cache.TLSConfig{
+			Enabled:            cfg.Redis.TLSEnabled,
+			CAFile:             cfg.Redis.TLSCAFile,
+			CertFile:           cfg.Redis.TLSCertFile,
+			KeyFile:            cfg.Redis.TLSKeyFile,
+			ServerName:         cfg.Redis.TLSServerName,
+			InsecureSkipVerify: cfg.Redis.TLSInsecureSkipVerify,
+		},
+		DefaultTTL:           cfg.Redis.DefaultTTL,
+		Format:               cfg.Redis.Format,
+		Compression:          cfg.Redis.Compression,
+		CompressionThreshold: cfg.Redis.CompressionThreshold,
+		MaxEntries:           cfg.Redis.MaxEntries,
+	}
+
+	// The service cache may keep hot entries in process; the shared auth
+	// cache always reads Redis so revocations apply immediately.
+	serviceCacheConfig := cacheConfig
+	serviceCacheConfig.LocalSize = cfg.Redis.LocalSize
+	serviceCacheConfig.LocalTTL = cfg.Redis.LocalTTL
+	serviceCacheConfig.Observer = metricsCollector.ObserveCacheLookup
+	userCache, err := cache.New(serviceCacheConfig, "user-service")
 	if err != nil {
-		log.Warn("Redis cache disabled", zap.Error(err))
+		log.Warn("Redis unavailable, caching paused until it reconnects", zap.Error(err))
 	}
 	userService := service.NewUserService(userRepo, userCache)
 
+	credentialService := service.NewCredentialService(userRepo, repository.NewPasswordResetTokenRepository(db), userCache, service.CredentialConfig{
+		Algorithm: cfg.Password.Algorithm,
+		Policy: service.PasswordPolicy{
+			MinLength:     cfg.Password.MinLength,
+			MaxLength:     cfg.Password.MaxLength,
+			RequireUpper:  cfg.Password.RequireUpper,
+			RequireLower:  cfg.Password.RequireLower,
+			RequireDigit:  cfg.Password.RequireDigit,
+			RequireSymbol: cfg.Password.RequireSymbol,
+		},
+		ResetTokenTTL: cfg.Password.ResetTokenTTL,
+	})
+
+	authConfig := auth.Config{
+		Secret:   cfg.Auth.Secret,
+		Issuer:   cfg.Auth.Issuer,
+		Audience: cfg.Auth.Audience,
+		TokenTTL: cfg.Auth.TokenTTL,
+	}
+	if len(cfg.Auth.SigningKeys) > 0 {
+		keyring, err := auth.LoadKeyring(cfg.Auth.ActiveKeyID, cfg.Auth.SigningKeys)
+		if err != nil {
+			log.Fatal("Failed to load JWT signing keys", zap.Error(err))
+		}
+		authConfig.Keyring = keyring
+		log.Info("Asymmetric JWT signing enabled",
+			zap.String("active_key_id", keyring.Active().ID),
+			zap.Int("keys", len(cfg.Auth.SigningKeys)),
+		)
+	} else if authConfig.Secret == "" {
+		log.Fatal("Token signing is not configured; set AUTH_JWT_SIGNING_KEYS or AUTH_JWT_SECRET")
+	}
+
+	// Requests authenticated with an API key carry no bearer token, so audit
+	// events fall back to a user-service token.
+	auditRetry := retry.DefaultConfig()
+	auditRetry.MaxAttempts = cfg.AuditLog.RetryMaxAttempts
 	auditClient := audit.NewClient(audit.Config{
 		Enabled: cfg.AuditLog.Enabled,
 		BaseURL: cfg.AuditLog.URL,
 		Timeout: cfg.AuditLog.Timeout,
+		TokenProvider: func() (string, error) {
+			return auth.GenerateToken(authConfig, "user-service", []string{"service"})
+		},
+		Retry: auditRetry,
+		Bulkhead: bulkhead.Config{
+			MaxConcurrent: cfg.AuditLog.MaxConcurrent,
+			MaxQueue:      cfg.AuditLog.MaxQueue,
+			QueueTimeout:  cfg.AuditLog.QueueTimeout,
+			Observer:      metricsCollector,
+		},
 	}, log)
 
-	userHandler := handler.NewUserHandler(userService, auditClient, log)
+	// Revocations are stored under a shared prefix so every service sees them
+	revocationCache, err := cache.New(cacheConfig, "auth")
+	if err != nil {
+		log.Warn("Redis unavailable, token revocations kept in memory until it reconnects", zap.Error(err))
+	}
+	revocationList := auth.NewRevocationList(revocationCache, cfg.Auth.TokenTTL, metricsCollector.ObserveRevocationCheck)
+	authConfig.Revocations = revocationList
 
-	authConfig := auth.Config{
-		Secret:   cfg.Auth.Secret,
-		Issuer:   cfg.Auth.Issuer,
-		Audience: cfg.Auth.Audience,
-		TokenTTL: cfg.Auth.TokenTTL,
+	permissions, err := auth.LoadRolePermissions(cfg.Auth.PermissionsFile)
+	if err != nil {
+		log.Fatal("Failed to load role permissions", zap.Error(err))
+	}
+	authConfig.Permissions = permissions
+	if cfg.Auth.PermissionsFile != "" {
+		log.Info("Role permissions loaded",
+			zap.String("file", cfg.Auth.PermissionsFile),
+			zap.Strings("roles", permissions.Roles()),
+		)
+	}
+	authConfig.MFARequiredRoles = cfg.Auth.MFARequiredRoles
+	if len(cfg.Auth.MFARequiredRoles) > 0 {
+		log.Info("MFA required for roles", zap.Strings("roles", cfg.Auth.MFARequiredRoles))
 	}
-	authHandler := handler.NewAuthHandler(log, auditClient, authConfig, cfg.Auth.ClientID, cfg.Auth.ClientSecret, cfg.Auth.ClientRoles)
 
-	// Initialize metrics
-	metricsCollector := metrics.NewMetrics("user_service")
+	clientRepo := repository.NewOAuthClientRepository(db)
+
+	var refreshTokenService service.RefreshTokenService
+	if cfg.Auth.RefreshTokenTTL > 0 {
+		refreshTokenService = service.NewRefreshTokenService(repository.NewRefreshTokenRepository(db), userRepo, clientRepo, cfg.Auth.RefreshTokenTTL)
+	}
+
+	clientService := service.NewOAuthClientService(clientRepo, cfg.Auth.ClientSecretTTL)
+	if err := clientService.EnsureClient(context.Background(), cfg.Auth.ClientID, cfg.Auth.ClientSecret, cfg.Auth.ClientRoles); err != nil {
+		log.Fatal("Failed to register bootstrap OAuth client", zap.Error(err))
+	}
+	if err := clientService.EnsureClient(context.Background(), cfg.Auth.ServiceClientID, cfg.Auth.ServiceClientSecret, cfg.Auth.ServiceClientRoles); err != nil {
+		log.Fatal("Failed to register order-service OAuth client", zap.Error(err))
+	}
+
+	apiKeyService := service.NewAPIKeyService(repository.NewAPIKeyRepository(db), userRepo, clientRepo)
+	authConfig.APIKeys = apiKeyService
+
+	// Failure counters are read-modify-write, so they bypass the in-process tier.
+	lockoutCache, err := cache.New(cacheConfig, "user-service")
+	if err != nil {
+		log.Warn("Redis unavailable, lockout counters kept in memory until it reconnects", zap.Error(err))
+	}
+	loginThrottle := service.NewLoginThrottle(lockoutCache, service.LockoutPolicy{
+		MaxClientFailures: cfg.Auth.LockoutClientFailures,
+		MaxIPFailures:     cfg.Auth.LockoutIPFailures,
+		Window:            cfg.Auth.LockoutWindow,
+		BaseLockout:       cfg.Auth.LockoutBase,
+		MaxLockout:        cfg.Auth.LockoutMax,
+	})
+
+	// TOTP secrets get their own key so a leaked signing secret does not expose them.
+	if cfg.MFA.EncryptionKey == "" {
+		log.Fatal("MFA_ENCRYPTION_KEY must be set")
+	}
+	if cfg.MFA.EncryptionKey == cfg.Auth.Secret {
+		log.Fatal("MFA_ENCRYPTION_KEY must differ from AUTH_JWT_SECRET")
+	}
+	mfaService, err := service.NewMFAService(repository.NewMFARepository(db), userRepo, service.MFAConfig{
+		Issuer:        cfg.MFA.Issuer,
+		EncryptionKey: cfg.MFA.EncryptionKey,
+		RecoveryCodes: cfg.MFA.RecoveryCodes,
+	})
+	if err != nil {
+		log.Fatal("Failed to initialize MFA; set MFA_ENCRYPTION_KEY", zap.Error(err))
+	}
+
+	oidcService := service.NewOIDCService(repository.NewAuthorizationCodeRepository(db), userRepo, service.OIDCConfig{
+		Issuer:  cfg.OIDC.IssuerURL,
+		CodeTTL: cfg.OIDC.CodeTTL,
+	})
+	log.Info("OpenID Connect provider enabled", zap.String("issuer", oidcService.Issuer()))
+
+	userHandler := handler.NewUserHandler(userService, credentialService, refreshTokenService, revocationList, auditClient, log)
+	authHandler := handler.NewAuthHandler(log, auditClient, authConfig, clientService, credentialService, mfaService, oidcService, refreshTokenService, revocationList, loginThrottle, cfg.Auth.Scopes)
+	clientHandler := handler.NewOAuthClientHandler(clientService, refreshTokenService, revocationList, auditClient, log)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService, revocationList, loginThrottle, auditClient, log)
+	mfaHandler := handler.NewMFAHandler(mfaService, loginThrottle, refreshTokenService, revocationList, auditClient, log)
+	impersonationHandler := handler.NewImpersonationHandler(userService, authConfig, cfg.Auth.ImpersonationTTL, revocationList, auditClient, log)
 
 	// Initialize rate limiter
 	rateLimiter := middleware.NewRateLimiter(cfg.Server.RateLimit, cfg.Server.RateLimit*2)
 
 	// Setup router
-	routerSetup := api.NewRouter(userHandler, authHandler, log, metricsCollector, rateLimiter, authConfig)
+	routerSetup := api.NewRouter(userHandler, authHandler, clientHandler, apiKeyHandler, mfaHandler, impersonationHandler, log, metricsCollector, rateLimiter, authConfig)
 	router := routerSetup.Setup()
 
 	// Create HTTP server
@@ -132,6 +281,10 @@ func main() {
 		sqlDB.Close()
 	}
 
+	if err := userCache.Close(); err != nil {
+		log.Warn("Failed to close Redis cache", zap.Error(err))
+	}
+
 	log.Info("Server exited")
 }
 
@@ -17,30 +17,42 @@ import (
 
 // Router sets up all routes for the user service
 type Router struct {
-	handler     *handler.UserHandler
-	authHandler *handler.AuthHandler
-	logger      *logger.Logger
-	metrics     *metrics.Metrics
-	rateLimiter *middleware.RateLimiter
-	authConfig  auth.Config
+	handler       *handler.UserHandler
+	authHandler   *handler.AuthHandler
+	clientHandler *handler.OAuthClientHandler
+	apiKeyHandler *handler.APIKeyHandler
+	mfaHandler    *handler.MFAHandler
+	impersonation *handler.ImpersonationHandler
+	logger        *logger.Logger
+	metrics       *metrics.Metrics
+	rateLimiter   *middleware.RateLimiter
+	authConfig    auth.Config
 }
 
 // NewRouter creates a new router
 func NewRouter(
 	handler *handler.UserHandler,
 	authHandler *handler.AuthHandler,
+	clientHandler *handler.OAuthClientHandler,
+	apiKeyHandler *handler.APIKeyHandler,
+	mfaHandler *handler.MFAHandler,
+	impersonation *handler.ImpersonationHandler,
 	logger *logger.Logger,
 	metrics *metrics.Metrics,
 	rateLimiter *middleware.RateLimiter,
 	authConfig auth.Config,
 ) *Router {
 	return &Router{
-		handler:     handler,
-		authHandler: authHandler,
-		logger:      logger,
-		metrics:     metrics,
-		rateLimiter: rateLimiter,
-		authConfig:  authConfig,
+		handler:       handler,
+		authHandler:   authHandler,
+		clientHandler: clientHandler,
+		apiKeyHandler: apiKeyHandler,
+		mfaHandler:    mfaHandler,
+		impersonation: impersonation,
+		logger:        logger,
+		metrics:       metrics,
+		rateLimiter:   rateLimiter,
+		authConfig:    authConfig,
 	}
 }
 
@@ -68,21 +80,81 @@ func (r *Router) Setup() *gin.Engine {
 	userdocs.SwaggerInfo.BasePath = "/api/v1"
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// Public signing keys for token verification by other services
+	router.GET("/.well-known/jwks.json", r.authHandler.JWKS)
+
+	// OpenID Connect discovery and userinfo
+	router.GET("/.well-known/openid-configuration", r.authHandler.OpenIDConfiguration)
+	userInfo := router.Group("/userinfo")
+	userInfo.Use(middleware.AuthMiddleware(r.authConfig))
+	{
+		userInfo.GET("", r.authHandler.UserInfo)
+		userInfo.POST("", r.authHandler.UserInfo)
+	}
+
+	// OAuth2 endpoints (RFC 6749, RFC 7662, RFC 7009) with client authentication
+	oauth := router.Group("/oauth")
+	{
+		oauth.GET("/authorize", r.authHandler.Authorize)
+		oauth.POST("/authorize", r.authHandler.AuthorizeLogin)
+		oauth.POST("/token", r.authHandler.OAuthToken)
+		oauth.POST("/introspect", r.authHandler.Introspect)
+		oauth.POST("/revoke", r.authHandler.OAuthRevoke)
+	}
+
 	// API v1 routes
 	v1 := router.Group("/api/v1")
 
 	v1.POST("/auth/token", r.authHandler.IssueToken)
+	v1.POST("/auth/logout", r.authHandler.Logout)
+	v1.POST("/auth/login", r.authHandler.Login)
+	v1.POST("/auth/password/reset", r.authHandler.ResetPassword)
+	v1.POST("/auth/api-keys/verify", r.apiKeyHandler.VerifyKey)
 
 	protected := v1.Group("/")
 	protected.Use(middleware.AuthMiddleware(r.authConfig))
 
+	protected.POST("/auth/revocations", middleware.RequirePermission(auth.PermAuthRevoke), r.authHandler.RevokeToken)
+	protected.POST("/auth/password", r.authHandler.ChangePassword)
+	protected.POST("/auth/impersonation/end", r.impersonation.End)
+
+	mfa := protected.Group("/auth/mfa")
+	{
+		mfa.GET("", r.mfaHandler.Status)
+		mfa.POST("/totp", r.mfaHandler.EnrollTOTP)
+		mfa.POST("/totp/confirm", r.mfaHandler.ConfirmTOTP)
+		mfa.POST("/totp/disable", r.mfaHandler.DisableTOTP)
+		mfa.POST("/recovery-codes", r.mfaHandler.RegenerateRecoveryCodes)
+	}
+
+	clients := protected.Group("/auth/clients")
+	clients.Use(middleware.RequirePermission(auth.PermAuthClients))
+	{
+		clients.POST("", r.clientHandler.CreateClient)
+		clients.GET("", r.clientHandler.ListClients)
+		clients.GET("/:client_id", r.clientHandler.GetClient)
+		clients.PUT("/:client_id", r.clientHandler.UpdateClient)
+		clients.DELETE("/:client_id", r.clientHandler.DeleteClient)
+		clients.POST("/:client_id/secret", r.clientHandler.RotateSecret)
+	}
+
+	apiKeys := protected.Group("/auth/api-keys")
+	apiKeys.Use(middleware.RequirePermission(auth.PermAuthAPIKeys))
+	{
+		apiKeys.POST("", r.apiKeyHandler.CreateKey)
+		apiKeys.GET("", r.apiKeyHandler.ListKeys)
+		apiKeys.DELETE("/:key_id", r.apiKeyHandler.RevokeKey)
+	}
+
 	users := protected.Group("/users")
 	{
-		users.POST("", middleware.RequireRoles("admin"), r.handler.CreateUser)
-		users.GET("", middleware.RequireRoles("admin"), r.handler.ListUsers)
-		users.GET("/:id", middleware.RequireRoles("admin", "service"), r.handler.GetUser)
-		users.PUT("/:id", middleware.RequireRoles("admin"), r.handler.UpdateUser)
-		users.DELETE("/:id", middleware.RequireRoles("admin"), r.handler.DeleteUser)
+		users.POST("", middleware.RequirePermission(auth.PermUsersWrite), r.handler.CreateUser)
+		users.GET("", middleware.RequirePermission(auth.PermUsersList), r.handler.ListUsers)
+		users.GET("/:id", middleware.RequirePermission(auth.PermUsersRead), r.handler.GetUser)
+		users.PUT("/:id", middleware.RequirePermission(auth.PermUsersWrite), r.handler.UpdateUser)
+		users.DELETE("/:id", middleware.RequirePermission(auth.PermUsersDelete), r.handler.DeleteUser)
+		users.POST("/:id/password-reset-token", middleware.RequirePermission(auth.PermUsersWrite), r.authHandler.IssuePasswordReset)
+		users.POST("/:id/impersonate", middleware.RequirePermission(auth.PermAuthImpersonate), r.impersonation.Start)
 	}
 
 	return router
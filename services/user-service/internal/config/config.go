@@ -18,6 +18,9 @@ type Config struct {
 	Auth     AuthConfig
 	Redis    RedisConfig
 	AuditLog AuditLogConfig
+	Password PasswordConfig
+	MFA      MFAConfig
+	OIDC     OIDCConfig
 }
 
 // ServerConfig holds server configuration
@@ -42,30 +45,117 @@ type LogConfig struct {
 
 // AuthConfig holds authentication configuration
 type AuthConfig struct {
-	Secret       string
-	Issuer       string
-	Audience     string
-	TokenTTL     time.Duration
+	// Secret signs and verifies HS256 tokens; it is ignored once SigningKeys
+	// are set and may then be empty.
+	Secret   string
+	Issuer   string
+	Audience string
+	TokenTTL time.Duration
+	// ClientID, ClientSecret and ClientRoles register a bootstrap OAuth client on
+	// first start; afterwards clients are managed through the admin API.
 	ClientID     string
 	ClientSecret string
 	ClientRoles  []string
+	// ServiceClientID, ServiceClientSecret and ServiceClientRoles register the
+	// OAuth client order-service obtains its tokens with; an empty secret skips it.
+	ServiceClientID     string
+	ServiceClientSecret string
+	ServiceClientRoles  []string
+	// ClientSecretTTL is the default lifetime of generated client secrets; zero means no expiry.
+	ClientSecretTTL time.Duration
+	// RefreshTokenTTL is the absolute lifetime of a refresh token family;
+	// rotation does not extend it. Zero disables refresh tokens.
+	RefreshTokenTTL time.Duration
+	// SigningKeys maps key IDs to PEM private key files used for RS256/ES256 signing.
+	SigningKeys map[string]string
+	// ActiveKeyID selects the signing key for new tokens; other keys remain valid for verification.
+	ActiveKeyID string
+	// Scopes maps OAuth2 scope values to roles; unmapped scopes are treated as role names.
+	Scopes map[string]string
+	// PermissionsFile is a JSON role to permission mapping; empty uses the built-in roles.
+	PermissionsFile string
+	// MFARequiredRoles only grant their permissions to tokens issued after MFA.
+	MFARequiredRoles []string
+	// LockoutClientFailures and LockoutIPFailures are the failed token requests
+	// allowed per client_id and per IP within LockoutWindow; zero disables that check.
+	LockoutClientFailures int
+	LockoutIPFailures     int
+	LockoutWindow         time.Duration
+	// LockoutBase is the first lockout duration; it doubles on each repeat up to LockoutMax.
+	LockoutBase time.Duration
+	LockoutMax  time.Duration
+	// ImpersonationTTL is the lifetime of admin impersonation tokens; zero disables impersonation.
+	ImpersonationTTL time.Duration
+}
+
+// PasswordConfig holds end-user password hashing and policy configuration
+type PasswordConfig struct {
+	// Algorithm is argon2id or bcrypt; existing hashes of either kind keep verifying.
+	Algorithm     string
+	MinLength     int
+	MaxLength     int
+	RequireUpper  bool
+	RequireLower  bool
+	RequireDigit  bool
+	RequireSymbol bool
+	ResetTokenTTL time.Duration
+}
+
+// MFAConfig holds TOTP multi-factor authentication configuration
+type MFAConfig struct {
+	Issuer string
+	// EncryptionKey encrypts TOTP secrets at rest; empty falls back to the JWT secret.
+	EncryptionKey string
+	RecoveryCodes int
+}
+
+// OIDCConfig holds OpenID Connect provider configuration
+type OIDCConfig struct {
+	// IssuerURL is the public base URL clients reach user-service on; it is the
+	// iss of ID tokens and the base of the discovery document endpoints.
+	IssuerURL string
+	CodeTTL   time.Duration
 }
 
 // RedisConfig holds Redis cache configuration
 type RedisConfig struct {
-	Enabled    bool
-	Host       string
-	Port       string
-	Password   string
-	DB         int
-	DefaultTTL time.Duration
+	Enabled bool
+	Backend string
+	Host    string
+	Port    string
+	// Addrs and MasterName select Sentinel or Cluster instead of Host/Port
+	Addrs                 []string
+	MasterName            string
+	Cluster               bool
+	Username              string
+	Password              string
+	SentinelPassword      string
+	DB                    int
+	TLSEnabled            bool
+	TLSCAFile             string
+	TLSCertFile           string
+	TLSKeyFile            string
+	TLSServerName         string
+	TLSInsecureSkipVerify bool
+	DefaultTTL            time.Duration
+	Format                string
+	Compression           string
+	CompressionThreshold  int
+	LocalSize             int
+	LocalTTL              time.Duration
+	MaxEntries            int
 }
 
 // AuditLogConfig holds audit log service configuration.
 type AuditLogConfig struct {
-	Enabled bool
-	URL     string
-	Timeout time.Duration
+	Enabled          bool
+	URL              string
+	Timeout          time.Duration
+	RetryMaxAttempts int
+	// MaxConcurrent caps in-flight posts; MaxQueue more may wait up to QueueTimeout
+	MaxConcurrent int
+	MaxQueue      int
+	QueueTimeout  time.Duration
 }
 
 // Load loads configuration from environment variables
@@ -83,6 +173,71 @@ func Load() (*Config, error) {
 		tokenTTLMinutes = 60
 	}
 
+	refreshTTLHours, err := strconv.Atoi(getEnv("AUTH_REFRESH_TOKEN_TTL_HOURS", "720"))
+	if err != nil {
+		refreshTTLHours = 720
+	}
+
+	clientSecretTTLDays, err := strconv.Atoi(getEnv("AUTH_CLIENT_SECRET_TTL_DAYS", "0"))
+	if err != nil {
+		clientSecretTTLDays = 0
+	}
+
+	lockoutClientFailures, err := strconv.Atoi(getEnv("AUTH_LOCKOUT_CLIENT_FAILURES", "5"))
+	if err != nil {
+		lockoutClientFailures = 5
+	}
+
+	lockoutIPFailures, err := strconv.Atoi(getEnv("AUTH_LOCKOUT_IP_FAILURES", "20"))
+	if err != nil {
+		lockoutIPFailures = 20
+	}
+
+	lockoutWindowMinutes, err := strconv.Atoi(getEnv("AUTH_LOCKOUT_WINDOW_MINUTES", "15"))
+	if err != nil {
+		lockoutWindowMinutes = 15
+	}
+
+	lockoutBaseSeconds, err := strconv.Atoi(getEnv("AUTH_LOCKOUT_BASE_SECONDS", "60"))
+	if err != nil {
+		lockoutBaseSeconds = 60
+	}
+
+	lockoutMaxMinutes, err := strconv.Atoi(getEnv("AUTH_LOCKOUT_MAX_MINUTES", "60"))
+	if err != nil {
+		lockoutMaxMinutes = 60
+	}
+
+	impersonationTTLMinutes, err := strconv.Atoi(getEnv("AUTH_IMPERSONATION_TTL_MINUTES", "15"))
+	if err != nil {
+		impersonationTTLMinutes = 15
+	}
+
+	passwordMinLength, err := strconv.Atoi(getEnv("PASSWORD_MIN_LENGTH", "8"))
+	if err != nil {
+		passwordMinLength = 8
+	}
+
+	passwordMaxLength, err := strconv.Atoi(getEnv("PASSWORD_MAX_LENGTH", "128"))
+	if err != nil {
+		passwordMaxLength = 128
+	}
+
+	resetTokenTTLMinutes, err := strconv.Atoi(getEnv("PASSWORD_RESET_TOKEN_TTL_MINUTES", "30"))
+	if err != nil {
+		resetTokenTTLMinutes = 30
+	}
+
+	mfaRecoveryCodes, err := strconv.Atoi(getEnv("MFA_RECOVERY_CODES", "10"))
+	if err != nil {
+		mfaRecoveryCodes = 10
+	}
+
+	oidcCodeTTLSeconds, err := strconv.Atoi(getEnv("OIDC_CODE_TTL_SECONDS", "60"))
+	if err != nil {
+		oidcCodeTTLSeconds = 60
+	}
+
 	cacheTTLSeconds, err := strconv.Atoi(getEnv("REDIS_TTL_SECONDS", "300"))
 	if err != nil {
 		cacheTTLSeconds = 300
@@ -93,11 +248,51 @@ func Load() (*Config, error) {
 		cacheDB = 0
 	}
 
+	localCacheSize, err := strconv.Atoi(getEnv("REDIS_LOCAL_CACHE_SIZE", "0"))
+	if err != nil {
+		localCacheSize = 0
+	}
+
+	localCacheTTLSeconds, err := strconv.Atoi(getEnv("REDIS_LOCAL_CACHE_TTL_SECONDS", "30"))
+	if err != nil {
+		localCacheTTLSeconds = 30
+	}
+
+	memoryCacheEntries, err := strconv.Atoi(getEnv("CACHE_MEMORY_MAX_ENTRIES", "10000"))
+	if err != nil {
+		memoryCacheEntries = 10000
+	}
+
+	compressionThreshold, err := strconv.Atoi(getEnv("CACHE_COMPRESSION_THRESHOLD_BYTES", "1024"))
+	if err != nil {
+		compressionThreshold = 1024
+	}
+
 	auditTimeoutSeconds, err := strconv.Atoi(getEnv("AUDIT_LOG_SERVICE_TIMEOUT_SECONDS", "3"))
 	if err != nil {
 		auditTimeoutSeconds = 3
 	}
 
+	auditRetryAttempts, err := strconv.Atoi(getEnv("AUDIT_LOG_SERVICE_RETRY_MAX_ATTEMPTS", "2"))
+	if err != nil {
+		auditRetryAttempts = 2
+	}
+
+	auditMaxConcurrent, err := strconv.Atoi(getEnv("AUDIT_LOG_SERVICE_MAX_CONCURRENT", "10"))
+	if err != nil {
+		auditMaxConcurrent = 10
+	}
+
+	auditMaxQueue, err := strconv.Atoi(getEnv("AUDIT_LOG_SERVICE_MAX_QUEUE", "10"))
+	if err != nil {
+		auditMaxQueue = 10
+	}
+
+	auditQueueTimeoutMillis, err := strconv.Atoi(getEnv("AUDIT_LOG_SERVICE_QUEUE_TIMEOUT_MS", "200"))
+	if err != nil {
+		auditQueueTimeoutMillis = 200
+	}
+
 	config := &Config{
 		Server: ServerConfig{
 			Port:      getEnv("USER_SERVICE_PORT", "8081"),
@@ -114,26 +309,83 @@ func Load() (*Config, error) {
 			Level: getEnv("USER_SERVICE_LOG_LEVEL", "info"),
 		},
 		Auth: AuthConfig{
-			Secret:       getEnv("AUTH_JWT_SECRET", "change-me"),
-			Issuer:       getEnv("AUTH_JWT_ISSUER", "enterprise-microservice-system"),
-			Audience:     getEnv("AUTH_JWT_AUDIENCE", "enterprise-microservice-system"),
-			TokenTTL:     time.Duration(tokenTTLMinutes) * time.Minute,
-			ClientID:     getEnv("AUTH_CLIENT_ID", "admin"),
-			ClientSecret: getEnv("AUTH_CLIENT_SECRET", "admin123"),
-			ClientRoles:  getEnvList("AUTH_CLIENT_ROLES", []string{"admin"}),
+			Secret:                getEnvOrEmpty("AUTH_JWT_SECRET", "change-me"),
+			Issuer:                getEnv("AUTH_JWT_ISSUER", "enterprise-microservice-system"),
+			Audience:              getEnv("AUTH_JWT_AUDIENCE", "enterprise-microservice-system"),
+			TokenTTL:              time.Duration(tokenTTLMinutes) * time.Minute,
+			RefreshTokenTTL:       time.Duration(refreshTTLHours) * time.Hour,
+			ClientID:              getEnv("AUTH_CLIENT_ID", "admin"),
+			ClientSecret:          getEnv("AUTH_CLIENT_SECRET", "admin123"),
+			ClientRoles:           getEnvList("AUTH_CLIENT_ROLES", []string{"admin"}),
+			ServiceClientID:       getEnv("AUTH_SERVICE_SUBJECT", "order-service"),
+			ServiceClientSecret:   getEnv("AUTH_SERVICE_CLIENT_SECRET", "order-service-secret"),
+			ServiceClientRoles:    getEnvList("AUTH_SERVICE_ROLES", []string{"service"}),
+			ClientSecretTTL:       time.Duration(clientSecretTTLDays) * 24 * time.Hour,
+			SigningKeys:           getEnvMap("AUTH_JWT_SIGNING_KEYS"),
+			ActiveKeyID:           getEnv("AUTH_JWT_ACTIVE_KEY_ID", ""),
+			Scopes:                getEnvMap("AUTH_OAUTH_SCOPES"),
+			PermissionsFile:       getEnv("AUTH_PERMISSIONS_FILE", ""),
+			MFARequiredRoles:      getEnvList("AUTH_MFA_REQUIRED_ROLES", nil),
+			LockoutClientFailures: lockoutClientFailures,
+			LockoutIPFailures:     lockoutIPFailures,
+			LockoutWindow:         time.Duration(lockoutWindowMinutes) * time.Minute,
+			LockoutBase:           time.Duration(lockoutBaseSeconds) * time.Second,
+			LockoutMax:            time.Duration(lockoutMaxMinutes) * time.Minute,
+			ImpersonationTTL:      time.Duration(impersonationTTLMinutes) * time.Minute,
 		},
 		Redis: RedisConfig{
-			Enabled:    getEnvBool("REDIS_ENABLED", true),
-			Host:       getEnv("REDIS_HOST", "localhost"),
-			Port:       getEnv("REDIS_PORT", "6379"),
-			Password:   getEnv("REDIS_PASSWORD", ""),
-			DB:         cacheDB,
-			DefaultTTL: time.Duration(cacheTTLSeconds) * time.Second,
+			Enabled:               getEnvBool("REDIS_ENABLED", true),
+			Backend:               getEnv("CACHE_BACKEND", "redis"),
+			Host:                  getEnv("REDIS_HOST", "localhost"),
+			Port:                  getEnv("REDIS_PORT", "6379"),
+			Addrs:                 getEnvList("REDIS_ADDRS", nil),
+			MasterName:            getEnv("REDIS_MASTER_NAME", ""),
+			Cluster:               getEnvBool("REDIS_CLUSTER", false),
+			Username:              getEnv("REDIS_USERNAME", ""),
+			Password:              getEnv("REDIS_PASSWORD", ""),
+			SentinelPassword:      getEnv("REDIS_SENTINEL_PASSWORD", ""),
+			DB:                    cacheDB,
+			TLSEnabled:            getEnvBool("REDIS_TLS_ENABLED", false),
+			TLSCAFile:             getEnv("REDIS_TLS_CA_FILE", ""),
+			TLSCertFile:           getEnv("REDIS_TLS_CERT_FILE", ""),
+			TLSKeyFile:            getEnv("REDIS_TLS_KEY_FILE", ""),
+			TLSServerName:         getEnv("REDIS_TLS_SERVER_NAME", ""),
+			TLSInsecureSkipVerify: getEnvBool("REDIS_TLS_INSECURE_SKIP_VERIFY", false),
+			DefaultTTL:            time.Duration(cacheTTLSeconds) * time.Second,
+			Format:                getEnv("CACHE_FORMAT", "json"),
+			Compression:           getEnv("CACHE_COMPRESSION", "none"),
+			CompressionThreshold:  compressionThreshold,
+			LocalSize:             localCacheSize,
+			LocalTTL:              time.Duration(localCacheTTLSeconds) * time.Second,
+			MaxEntries:            memoryCacheEntries,
 		},
 		AuditLog: AuditLogConfig{
-			Enabled: getEnvBool("AUDIT_LOG_SERVICE_ENABLED", true),
-			URL:     getEnv("AUDIT_LOG_SERVICE_URL", "http://localhost:8083"),
-			Timeout: time.Duration(auditTimeoutSeconds) * time.Second,
+			Enabled:          getEnvBool("AUDIT_LOG_SERVICE_ENABLED", true),
+			URL:              getEnv("AUDIT_LOG_SERVICE_URL", "http://localhost:8083"),
+			Timeout:          time.Duration(auditTimeoutSeconds) * time.Second,
+			RetryMaxAttempts: auditRetryAttempts,
+			MaxConcurrent:    auditMaxConcurrent,
+			MaxQueue:         auditMaxQueue,
+			QueueTimeout:     time.Duration(auditQueueTimeoutMillis) * time.Millisecond,
+		},
+		Password: PasswordConfig{
+			Algorithm:     strings.ToLower(getEnv("PASSWORD_HASH_ALGORITHM", "argon2id")),
+			MinLength:     passwordMinLength,
+			MaxLength:     passwordMaxLength,
+			RequireUpper:  getEnvBool("PASSWORD_REQUIRE_UPPER", false),
+			RequireLower:  getEnvBool("PASSWORD_REQUIRE_LOWER", false),
+			RequireDigit:  getEnvBool("PASSWORD_REQUIRE_DIGIT", false),
+			RequireSymbol: getEnvBool("PASSWORD_REQUIRE_SYMBOL", false),
+			ResetTokenTTL: time.Duration(resetTokenTTLMinutes) * time.Minute,
+		},
+		MFA: MFAConfig{
+			Issuer:        getEnv("MFA_TOTP_ISSUER", "Enterprise Microservice System"),
+			EncryptionKey: getEnv("MFA_ENCRYPTION_KEY", ""),
+			RecoveryCodes: mfaRecoveryCodes,
+		},
+		OIDC: OIDCConfig{
+			IssuerURL: getEnv("OIDC_ISSUER_URL", "http://localhost:8081"),
+			CodeTTL:   time.Duration(oidcCodeTTLSeconds) * time.Second,
 		},
 	}
 
@@ -155,6 +407,14 @@ func getEnv(key, defaultValue string) string {
 	return value
 }
 
+// getEnvOrEmpty is getEnv for values that may be deliberately set to empty.
+func getEnvOrEmpty(key, defaultValue string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return strings.TrimSpace(value)
+	}
+	return defaultValue
+}
+
 func getEnvList(key string, defaultValues []string) []string {
 	value := os.Getenv(key)
 	if value == "" {
@@ -177,6 +437,23 @@ func getEnvList(key string, defaultValues []string) []string {
 	return result
 }
 
+// getEnvMap parses a CSV of key=value pairs, e.g. "k1=/keys/a.pem,k2=/keys/b.pem".
+func getEnvMap(key string) map[string]string {
+	result := map[string]string{}
+	for _, entry := range getEnvList(key, nil) {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		name := strings.TrimSpace(parts[0])
+		value := strings.TrimSpace(parts[1])
+		if name != "" && value != "" {
+			result[name] = value
+		}
+	}
+	return result
+}
+
 func getEnvBool(key string, defaultValue bool) bool {
 	value := strings.ToLower(strings.TrimSpace(os.Getenv(key)))
 	if value == "" {
@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"github.com/RashadTanjim/enterprise-microservice-system/common/audit"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/auth"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/errors"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/logger"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/response"
+	"enterprise-microservice-system/services/user-service/internal/model"
+	"enterprise-microservice-system/services/user-service/internal/service"
+	"math"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// APIKeyHandler handles API key management and verification
+type APIKeyHandler struct {
+	service     service.APIKeyService
+	revocations *auth.RevocationList
+	lockout     service.LoginThrottle
+	auditClient *audit.Client
+	logger      *logger.Logger
+}
+
+// NewAPIKeyHandler creates a new API key handler. revocations may be nil; when
+// set, revoked keys are rejected by every service immediately instead of after
+// their verification cache expires. lockout, when set, throttles failed
+// verifications per key ID and caller IP.
+func NewAPIKeyHandler(service service.APIKeyService, revocations *auth.RevocationList, lockout service.LoginThrottle, auditClient *audit.Client, logger *logger.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		service:     service,
+		revocations: revocations,
+		lockout:     lockout,
+		auditClient: auditClient,
+		logger:      logger,
+	}
+}
+
+// CreateKey handles API key creation
+// @Summary Create an API key
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param key body model.CreateAPIKeyRequest true "API key data"
+// @Success 201 {object} response.Response{data=model.CreatedAPIKeyResponse}
+// @Failure 400 {object} response.Response
+// @Router /auth/api-keys [post]
+func (h *APIKeyHandler) CreateKey(c *gin.Context) {
+	var req model.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Invalid request body", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+
+	actor := resolveActor(c)
+	key, value, err := h.service.CreateKey(c.Request.Context(), &req, actor)
+	if err != nil {
+		h.logger.Error("Failed to create api key", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+
+	h.logger.Info("API key created", zap.String("key_id", key.KeyID), zap.String("owner", key.Owner))
+	h.trackAudit(c, audit.Event{
+		Actor:        actor,
+		Action:       "api_key.create",
+		ResourceType: "api_key",
+		ResourceID:   key.KeyID,
+		Description:  "API key created",
+		Metadata: encodeMetadata(map[string]interface{}{
+			"owner":      key.Owner,
+			"roles":      key.Roles,
+			"expires_at": key.ExpiresAt,
+		}),
+	})
+	response.Created(c, model.CreatedAPIKeyResponse{APIKey: *key, Key: value})
+}
+
+// ListKeys handles listing API keys with pagination
+// @Summary List API keys
+// @Tags api-keys
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(10)
+// @Param owner query string false "Filter by owner"
+// @Param status query string false "Filter by status (active/revoked)"
+// @Success 200 {object} response.Response{data=[]model.APIKey}
+// @Router /auth/api-keys [get]
+func (h *APIKeyHandler) ListKeys(c *gin.Context) {
+	var query model.ListAPIKeysQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		h.logger.Warn("Invalid query parameters", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+
+	keys, total, err := h.service.ListKeys(c.Request.Context(), &query)
+	if err != nil {
+		h.logger.Error("Failed to list api keys", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(query.PageSize)))
+	response.SuccessWithMeta(c, keys, &response.Meta{
+		Page:       query.Page,
+		PageSize:   query.PageSize,
+		TotalPages: totalPages,
+		TotalCount: total,
+	})
+}
+
+// RevokeKey handles API key revocation
+// @Summary Revoke an API key
+// @Tags api-keys
+// @Produce json
+// @Security BearerAuth
+// @Param key_id path string true "Key ID"
+// @Success 200 {object} response.Response{data=model.APIKey}
+// @Failure 404 {object} response.Response
+// @Router /auth/api-keys/{key_id} [delete]
+func (h *APIKeyHandler) RevokeKey(c *gin.Context) {
+	keyID := c.Param("key_id")
+
+	actor := resolveActor(c)
+	key, err := h.service.RevokeKey(c.Request.Context(), keyID, actor)
+	if err != nil {
+		h.logger.Error("Failed to revoke api key", zap.String("key_id", keyID), zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+
+	if h.revocations != nil {
+		if err := h.revocations.RevokeToken(c.Request.Context(), key.KeyID); err != nil {
+			h.logger.Error("Failed to publish api key revocation", zap.String("key_id", keyID), zap.Error(err))
+		}
+	}
+
+	h.logger.Info("API key revoked", zap.String("key_id", keyID))
+	h.trackAudit(c, audit.Event{
+		Actor:        actor,
+		Action:       "api_key.revoke",
+		ResourceType: "api_key",
+		ResourceID:   keyID,
+		Description:  "API key revoked",
+		Metadata: encodeMetadata(map[string]interface{}{
+			"owner": key.Owner,
+		}),
+	})
+	response.Success(c, key)
+}
+
+// VerifyKey resolves an API key to its owner and roles for other services.
+// Presenting the full key is the credential, so the endpoint is unauthenticated
+// and failed attempts count towards the login lockout.
+// @Summary Verify an API key
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param key body model.VerifyAPIKeyRequest true "API key"
+// @Success 200 {object} response.Response{data=auth.APIKeyPrincipal}
+// @Failure 401 {object} response.Response
+// @Failure 429 {object} response.Response
+// @Router /auth/api-keys/verify [post]
+func (h *APIKeyHandler) VerifyKey(c *gin.Context) {
+	var req model.VerifyAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	keyID, _, _ := auth.ParseAPIKey(req.APIKey)
+	lockoutPrincipal := apiKeyPrincipal(keyID)
+	if checkLockout(c, h.lockout, h.logger, lockoutPrincipal) {
+		response.Error(c, errors.New(errors.ErrCodeRateLimit, lockoutMessage, nil))
+		return
+	}
+
+	principal, err := h.service.VerifyAPIKey(c.Request.Context(), req.APIKey)
+	if err != nil {
+		if err == auth.ErrInvalidAPIKey {
+			err = errors.New(errors.ErrCodeUnauthorized, "invalid or expired api key", nil)
+			recordLockoutFailure(c, h.lockout, h.logger, func(event audit.Event) {
+				h.trackAudit(c, event)
+			}, lockoutPrincipal, err)
+			response.Error(c, err)
+			return
+		}
+		h.logger.Error("Failed to verify api key", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	recordLockoutSuccess(c, h.lockout, h.logger, lockoutPrincipal)
+
+	response.Success(c, principal)
+}
+
+// apiKeyPrincipal is the lockout key for an API key; malformed keys have no
+// key ID and are only throttled by IP.
+func apiKeyPrincipal(keyID string) string {
+	if keyID == "" {
+		return ""
+	}
+	return "apikey:" + keyID
+}
+
+func (h *APIKeyHandler) trackAudit(c *gin.Context, event audit.Event) {
+	if h.auditClient == nil {
+		return
+	}
+	h.auditClient.Track(c.Request.Context(), event, c.GetHeader("Authorization"))
+}
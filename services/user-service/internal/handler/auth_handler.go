@@ -6,6 +6,9 @@ import (
 	"github.com/RashadTanjim/enterprise-microservice-system/common/errors"
 	"github.com/RashadTanjim/enterprise-microservice-system/common/logger"
 	"github.com/RashadTanjim/enterprise-microservice-system/common/response"
+	"enterprise-microservice-system/services/user-service/internal/service"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -14,42 +17,83 @@ import (
 
 // AuthHandler handles authentication token issuance.
 type AuthHandler struct {
-	logger       *logger.Logger
-	auditClient  *audit.Client
-	authConfig   auth.Config
-	clientID     string
-	clientSecret string
-	clientRoles  []string
+	logger        *logger.Logger
+	auditClient   *audit.Client
+	authConfig    auth.Config
+	refreshTokens service.RefreshTokenService
+	revocations   *auth.RevocationList
+	clients       service.OAuthClientService
+	credentials   service.CredentialService
+	mfa           service.MFAService
+	oidc          service.OIDCService
+	lockout       service.LoginThrottle
+	scopes        map[string]string
 }
 
+// clientAMR is recorded for clients, which authenticate with a shared secret.
+var clientAMR = []string{auth.AMRPassword}
+
+// Supported token grant types.
+const (
+	GrantTypeClientCredentials = "client_credentials"
+	GrantTypeRefreshToken      = "refresh_token"
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeTokenExchange     = "urn:ietf:params:oauth:grant-type:token-exchange"
+)
+
 // TokenRequest represents the token request payload.
+// grant_type defaults to client_credentials; refresh_token grants only need refresh_token.
 type TokenRequest struct {
-	ClientID     string   `json:"client_id" binding:"required"`
-	ClientSecret string   `json:"client_secret" binding:"required"`
+	GrantType    string   `json:"grant_type" binding:"omitempty,oneof=client_credentials refresh_token"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"`
+	RefreshToken string   `json:"refresh_token"`
 	Roles        []string `json:"roles"`
 }
 
 // TokenResponse represents the token response payload.
 type TokenResponse struct {
-	AccessToken string    `json:"access_token"`
-	TokenType   string    `json:"token_type"`
-	ExpiresAt   time.Time `json:"expires_at"`
-	Roles       []string  `json:"roles"`
+	AccessToken      string     `json:"access_token"`
+	TokenType        string     `json:"token_type"`
+	ExpiresAt        time.Time  `json:"expires_at"`
+	Roles            []string   `json:"roles"`
+	RefreshToken     string     `json:"refresh_token,omitempty"`
+	RefreshExpiresAt *time.Time `json:"refresh_expires_at,omitempty"`
+	AMR              []string   `json:"amr,omitempty"`
+	IDToken          string     `json:"id_token,omitempty"`
+}
+
+// LogoutRequest represents the logout payload.
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// RevokeTokenRequest represents an admin token revocation. Exactly one of
+// jti (a single access token) or subject (every token for that subject) is required.
+type RevokeTokenRequest struct {
+	JTI     string `json:"jti" binding:"required_without=Subject,excluded_with=Subject"`
+	Subject string `json:"subject" binding:"required_without=JTI"`
 }
 
-// NewAuthHandler creates a new auth handler.
-func NewAuthHandler(log *logger.Logger, auditClient *audit.Client, authConfig auth.Config, clientID, clientSecret string, clientRoles []string) *AuthHandler {
+// NewAuthHandler creates a new auth handler. credentials, mfa, oidc, refreshTokens, revocations and lockout may be nil to disable those features.
+// scopes maps OAuth2 scope values to roles; scopes without a mapping are treated as role names.
+func NewAuthHandler(log *logger.Logger, auditClient *audit.Client, authConfig auth.Config, clients service.OAuthClientService, credentials service.CredentialService, mfa service.MFAService, oidc service.OIDCService, refreshTokens service.RefreshTokenService, revocations *auth.RevocationList, lockout service.LoginThrottle, scopes map[string]string) *AuthHandler {
 	return &AuthHandler{
-		logger:       log,
-		auditClient:  auditClient,
-		authConfig:   authConfig,
-		clientID:     clientID,
-		clientSecret: clientSecret,
-		clientRoles:  clientRoles,
+		logger:        log,
+		auditClient:   auditClient,
+		authConfig:    authConfig,
+		refreshTokens: refreshTokens,
+		revocations:   revocations,
+		clients:       clients,
+		credentials:   credentials,
+		mfa:           mfa,
+		oidc:          oidc,
+		lockout:       lockout,
+		scopes:        scopes,
 	}
 }
 
-// IssueToken validates client credentials and issues a JWT.
+// IssueToken validates client credentials or a refresh token and issues a JWT.
 // @Summary Issue a JWT token
 // @Tags auth
 // @Accept json
@@ -59,6 +103,7 @@ func NewAuthHandler(log *logger.Logger, auditClient *audit.Client, authConfig au
 // @Failure 400 {object} response.Response
 // @Failure 401 {object} response.Response
 // @Failure 403 {object} response.Response
+// @Failure 429 {object} response.Response
 // @Router /auth/token [post]
 func (h *AuthHandler) IssueToken(c *gin.Context) {
 	var req TokenRequest
@@ -68,45 +113,262 @@ func (h *AuthHandler) IssueToken(c *gin.Context) {
 		return
 	}
 
-	if req.ClientID != h.clientID || req.ClientSecret != h.clientSecret {
-		response.Error(c, errors.New(errors.ErrCodeUnauthorized, "invalid client credentials", nil))
+	if req.GrantType == GrantTypeRefreshToken {
+		h.refresh(c, &req)
+		return
+	}
+
+	if req.ClientID == "" || req.ClientSecret == "" {
+		response.Error(c, errors.NewValidation("client_id and client_secret are required"))
+		return
+	}
+
+	if h.lockedOut(c, req.ClientID) {
+		response.Error(c, errors.New(errors.ErrCodeRateLimit, lockoutMessage, nil))
+		return
+	}
+
+	client, err := h.clients.Authenticate(c.Request.Context(), req.ClientID, req.ClientSecret)
+	if err != nil {
+		h.logger.Warn("Client authentication failed", zap.String("client_id", req.ClientID), zap.Error(err))
+		h.recordAuthFailure(c, req.ClientID, err)
+		response.Error(c, err)
 		return
 	}
+	h.recordAuthSuccess(c, req.ClientID)
 
-	roles := h.clientRoles
+	roles := client.RoleList()
 	if len(req.Roles) > 0 {
-		if !rolesAllowed(req.Roles, h.clientRoles) {
+		if !rolesAllowed(req.Roles, roles) {
 			response.Error(c, errors.New(errors.ErrCodeForbidden, "requested roles are not allowed", nil))
 			return
 		}
 		roles = req.Roles
 	}
 
-	token, err := auth.GenerateToken(h.authConfig, req.ClientID, roles)
+	var refreshToken *service.IssuedRefreshToken
+	if h.refreshTokens != nil {
+		issued, err := h.refreshTokens.Issue(c.Request.Context(), req.ClientID, roles, clientAMR)
+		if err != nil {
+			h.logger.Error("Failed to issue refresh token", zap.Error(err))
+			response.Error(c, err)
+			return
+		}
+		refreshToken = issued
+	}
+
+	h.respondWithToken(c, req.ClientID, roles, clientAMR, refreshToken, "auth.token.issued", "JWT token issued")
+}
+
+// refresh rotates a refresh token and issues a new access token for its family.
+func (h *AuthHandler) refresh(c *gin.Context, req *TokenRequest) {
+	if h.refreshTokens == nil {
+		response.Error(c, errors.NewBadRequest("refresh_token grant is not enabled"))
+		return
+	}
+	if req.RefreshToken == "" {
+		response.Error(c, errors.NewValidation("refresh_token is required"))
+		return
+	}
+
+	issued, err := h.refreshTokens.Rotate(c.Request.Context(), req.RefreshToken)
 	if err != nil {
-		h.logger.Error("Failed to generate token", zap.Error(err))
-		response.Error(c, errors.New(errors.ErrCodeInternal, "failed to generate token", err))
+		if err == service.ErrRefreshTokenReuse {
+			h.logger.Warn("Refresh token reuse detected, token family revoked")
+			h.trackAudit(c, audit.Event{
+				Actor:        "system",
+				Action:       "auth.refresh_token.reuse_detected",
+				ResourceType: "auth",
+				ResourceID:   "refresh_token",
+				Description:  "Rotated refresh token presented again; token family revoked",
+			}, c.GetHeader("Authorization"))
+		}
+		response.Error(c, err)
 		return
 	}
 
-	expiresAt := time.Now().UTC().Add(h.authConfig.TokenTTL)
-	response.Success(c, TokenResponse{
+	h.respondWithToken(c, issued.Subject, issued.Roles, issued.AMR, issued, "auth.token.refreshed", "JWT token refreshed")
+}
+
+// Logout revokes the refresh token family of the presented token.
+// @Summary Revoke a refresh token family
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param logout body LogoutRequest true "Logout request"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Router /auth/logout [post]
+func (h *AuthHandler) Logout(c *gin.Context) {
+	if h.refreshTokens == nil {
+		response.Error(c, errors.NewBadRequest("refresh tokens are not enabled"))
+		return
+	}
+
+	var req LogoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Invalid logout request", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+
+	revoked, err := h.refreshTokens.Revoke(c.Request.Context(), req.RefreshToken, resolveActor(c))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, gin.H{"message": "logged out successfully"})
+	h.trackAudit(c, audit.Event{
+		Actor:        revoked.Subject,
+		Action:       "auth.logout",
+		ResourceType: "auth",
+		ResourceID:   revoked.Subject,
+		Description:  "Refresh token family revoked",
+		Metadata: encodeMetadata(map[string]interface{}{
+			"family_id": revoked.FamilyID,
+		}),
+	}, c.GetHeader("Authorization"))
+}
+
+// RevokeToken revokes an access token by jti or every token issued to a subject.
+// @Summary Revoke access tokens
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param revocation body RevokeTokenRequest true "Revocation request"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Router /auth/revocations [post]
+func (h *AuthHandler) RevokeToken(c *gin.Context) {
+	if h.revocations == nil {
+		response.Error(c, errors.NewBadRequest("token revocation is not enabled"))
+		return
+	}
+
+	var req RevokeTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Invalid revocation request", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+
+	ctx := c.Request.Context()
+	actor := resolveActor(c)
+	event := audit.Event{
+		Actor:        actor,
+		ResourceType: "auth",
+	}
+
+	if req.JTI != "" {
+		if err := h.revocations.RevokeToken(ctx, req.JTI); err != nil {
+			h.logger.Error("Failed to revoke token", zap.String("jti", req.JTI), zap.Error(err))
+			response.Error(c, errors.NewInternal("failed to revoke token", err))
+			return
+		}
+		event.Action = "auth.token.revoked"
+		event.ResourceID = req.JTI
+		event.Description = "Access token revoked"
+	} else {
+		if err := h.revocations.RevokeSubject(ctx, req.Subject); err != nil {
+			h.logger.Error("Failed to revoke subject tokens", zap.String("subject", req.Subject), zap.Error(err))
+			response.Error(c, errors.NewInternal("failed to revoke tokens", err))
+			return
+		}
+		if h.refreshTokens != nil {
+			if err := h.refreshTokens.RevokeSubject(ctx, req.Subject, actor); err != nil {
+				h.logger.Error("Failed to revoke subject refresh tokens", zap.String("subject", req.Subject), zap.Error(err))
+				response.Error(c, err)
+				return
+			}
+		}
+		event.Action = "auth.subject.revoked"
+		event.ResourceID = req.Subject
+		event.Description = "All tokens for subject revoked"
+	}
+
+	h.logger.Info("Tokens revoked", zap.String("jti", req.JTI), zap.String("subject", req.Subject))
+	response.Success(c, gin.H{"message": "tokens revoked successfully"})
+	h.trackAudit(c, event, c.GetHeader("Authorization"))
+}
+
+func (h *AuthHandler) respondWithToken(c *gin.Context, subject string, roles []string, amr []string, refreshToken *service.IssuedRefreshToken, action, description string) {
+	payload, err := h.issueAccessToken(subject, roles, amr, refreshToken)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, payload)
+
+	h.trackTokenIssued(c, subject, payload, refreshToken, action, description)
+}
+
+// issueAccessToken signs a new access token and assembles the token payload.
+// amr records how the subject authenticated (RFC 8176).
+func (h *AuthHandler) issueAccessToken(subject string, roles []string, amr []string, refreshToken *service.IssuedRefreshToken) (*TokenResponse, error) {
+	token, err := auth.GenerateTokenWithAMR(h.authConfig, subject, roles, amr)
+	if err != nil {
+		h.logger.Error("Failed to generate token", zap.Error(err))
+		return nil, errors.New(errors.ErrCodeInternal, "failed to generate token", err)
+	}
+
+	payload := &TokenResponse{
 		AccessToken: token,
 		TokenType:   "Bearer",
-		ExpiresAt:   expiresAt,
+		ExpiresAt:   time.Now().UTC().Add(h.authConfig.TokenTTL),
 		Roles:       roles,
-	})
+		AMR:         amr,
+	}
+	if refreshToken != nil {
+		payload.RefreshToken = refreshToken.Value
+		payload.RefreshExpiresAt = &refreshToken.ExpiresAt
+	}
+	return payload, nil
+}
+
+func (h *AuthHandler) trackTokenIssued(c *gin.Context, subject string, payload *TokenResponse, refreshToken *service.IssuedRefreshToken, action, description string) {
+	metadata := map[string]interface{}{
+		"roles": payload.Roles,
+		"amr":   payload.AMR,
+	}
+	if refreshToken != nil {
+		metadata["refresh_family_id"] = refreshToken.FamilyID
+	}
 
 	h.trackAudit(c, audit.Event{
-		Actor:        req.ClientID,
-		Action:       "auth.token.issued",
+		Actor:        subject,
+		Action:       action,
 		ResourceType: "auth",
-		ResourceID:   req.ClientID,
-		Description:  "JWT token issued",
-		Metadata: encodeMetadata(map[string]interface{}{
-			"roles": roles,
-		}),
-	}, token)
+		ResourceID:   subject,
+		Description:  description,
+		Metadata:     encodeMetadata(metadata),
+	}, payload.AccessToken)
+}
+
+// JWKS publishes the public signing keys so other services can verify tokens.
+// @Summary JSON Web Key Set
+// @Tags auth
+// @Produce json
+// @Success 200 {object} auth.JWKS
+// @Router /.well-known/jwks.json [get]
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	if h.authConfig.Keyring == nil {
+		c.JSON(http.StatusOK, auth.JWKS{Keys: []auth.JWK{}})
+		return
+	}
+
+	set, err := h.authConfig.Keyring.JWKS()
+	if err != nil {
+		h.logger.Error("Failed to build JWKS", zap.Error(err))
+		response.Error(c, errors.NewInternal("failed to build key set", err))
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, set)
 }
 
 func rolesAllowed(requested []string, allowed []string) bool {
@@ -124,10 +386,24 @@ func rolesAllowed(requested []string, allowed []string) bool {
 	return true
 }
 
+// trackAudit publishes an audit event. token is the caller's bearer token; when
+// the request carried none (e.g. HTTP Basic client auth) a short-lived service
+// token is minted so the event is still accepted by the audit log service.
 func (h *AuthHandler) trackAudit(c *gin.Context, event audit.Event, token string) {
 	if h.auditClient == nil {
 		return
 	}
+	if scheme, _, found := strings.Cut(token, " "); found && !strings.EqualFold(scheme, "Bearer") {
+		token = ""
+	}
+	if token == "" {
+		serviceToken, err := auth.GenerateToken(h.authConfig, "user-service", []string{"service"})
+		if err != nil {
+			h.logger.Warn("Failed to mint audit token", zap.Error(err))
+			return
+		}
+		token = serviceToken
+	}
 	h.auditClient.Track(c.Request.Context(), event, token)
 }
 
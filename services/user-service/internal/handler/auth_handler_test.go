@@ -2,30 +2,69 @@ package handler
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/audit"
 	"github.com/RashadTanjim/enterprise-microservice-system/common/auth"
 	"github.com/RashadTanjim/enterprise-microservice-system/common/logger"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/middleware"
+	"enterprise-microservice-system/services/user-service/internal/model"
+	"enterprise-microservice-system/services/user-service/internal/repository"
+	"enterprise-microservice-system/services/user-service/internal/service"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
 )
 
 type tokenResponse struct {
 	Success bool `json:"success"`
 	Data    struct {
-		AccessToken string    `json:"access_token"`
-		TokenType   string    `json:"token_type"`
-		ExpiresAt   time.Time `json:"expires_at"`
-		Roles       []string  `json:"roles"`
+		AccessToken  string    `json:"access_token"`
+		TokenType    string    `json:"token_type"`
+		ExpiresAt    time.Time `json:"expires_at"`
+		Roles        []string  `json:"roles"`
+		RefreshToken string    `json:"refresh_token"`
 	} `json:"data"`
 	Error *struct {
 		Code string `json:"code"`
 	} `json:"error"`
 }
 
+// newTestClients returns a client registry backed by an in-memory database with one registered client.
+func newTestClients(t *testing.T, db *gorm.DB, clientID, secret string, roles []string) service.OAuthClientService {
+	t.Helper()
+
+	if err := db.AutoMigrate(&model.OAuthClient{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+
+	clients := service.NewOAuthClientService(repository.NewOAuthClientRepository(db), 0)
+	if err := clients.EnsureClient(context.Background(), clientID, secret, roles); err != nil {
+		t.Fatalf("failed to register client: %v", err)
+	}
+	return clients
+}
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	return db
+}
+
 func TestIssueTokenSuccess(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -42,7 +81,8 @@ func TestIssueTokenSuccess(t *testing.T) {
 		TokenTTL: time.Minute,
 	}
 
-	h := NewAuthHandler(log, nil, cfg, "admin", "secret", []string{"admin", "user"})
+	clients := newTestClients(t, openTestDB(t), "admin", "secret", []string{"admin", "user"})
+	h := NewAuthHandler(log, nil, cfg, clients, nil, nil, nil, nil, nil, nil, nil)
 
 	router := gin.New()
 	router.POST("/token", h.IssueToken)
@@ -89,7 +129,8 @@ func TestIssueTokenInvalidCredentials(t *testing.T) {
 		TokenTTL: time.Minute,
 	}
 
-	h := NewAuthHandler(log, nil, cfg, "admin", "secret", []string{"admin"})
+	clients := newTestClients(t, openTestDB(t), "admin", "secret", []string{"admin"})
+	h := NewAuthHandler(log, nil, cfg, clients, nil, nil, nil, nil, nil, nil, nil)
 
 	router := gin.New()
 	router.POST("/token", h.IssueToken)
@@ -110,6 +151,106 @@ func TestIssueTokenInvalidCredentials(t *testing.T) {
 	}
 }
 
+func TestIssueTokenLocksOutAfterRepeatedFailures(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	log, err := logger.New("info")
+	if err != nil {
+		t.Fatalf("failed to init logger: %v", err)
+	}
+	defer log.Sync()
+
+	cfg := auth.Config{
+		Secret:   "test-secret",
+		Issuer:   "test-issuer",
+		Audience: "test-audience",
+		TokenTTL: time.Minute,
+	}
+
+	clients := newTestClients(t, openTestDB(t), "admin", "secret", []string{"admin"})
+	lockout := service.NewLoginThrottle(nil, service.LockoutPolicy{
+		MaxClientFailures: 2,
+		Window:            time.Minute,
+		BaseLockout:       time.Minute,
+		MaxLockout:        time.Hour,
+	})
+	h := NewAuthHandler(log, nil, cfg, clients, nil, nil, nil, nil, nil, lockout, nil)
+
+	router := gin.New()
+	router.POST("/token", h.IssueToken)
+
+	request := func(secret string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"client_id": "admin", "client_secret": secret})
+		req := httptest.NewRequest(http.MethodPost, "/token", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	for i := 0; i < 2; i++ {
+		if recorder := request("wrong"); recorder.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: expected status 401, got %d", i+1, recorder.Code)
+		}
+	}
+
+	recorder := request("secret")
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected locked out client to get 429, got %d", recorder.Code)
+	}
+	if recorder.Header().Get("Retry-After") != "60" {
+		t.Fatalf("expected Retry-After 60, got %q", recorder.Header().Get("Retry-After"))
+	}
+}
+
+func TestVerifyAPIKeyLockout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	log, err := logger.New("info")
+	if err != nil {
+		t.Fatalf("failed to init logger: %v", err)
+	}
+	defer log.Sync()
+
+	db := openTestDB(t)
+	if err := db.AutoMigrate(&model.APIKey{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	keys := service.NewAPIKeyService(repository.NewAPIKeyRepository(db), nil, nil)
+	created, value, err := keys.CreateKey(context.Background(), &model.CreateAPIKeyRequest{Name: "partner", Owner: "partner-acme", Roles: []string{"user"}}, "admin")
+	if err != nil {
+		t.Fatalf("failed to create api key: %v", err)
+	}
+	lockout := service.NewLoginThrottle(nil, service.LockoutPolicy{
+		MaxClientFailures: 2,
+		Window:            time.Minute,
+		BaseLockout:       time.Minute,
+		MaxLockout:        time.Hour,
+	})
+	h := NewAPIKeyHandler(keys, nil, lockout, nil, log)
+
+	router := gin.New()
+	router.POST("/verify", h.VerifyKey)
+
+	verify := func(key string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"api_key": key})
+		req := httptest.NewRequest(http.MethodPost, "/verify", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	for i := 0; i < 2; i++ {
+		if recorder := verify(created.KeyID + "_wrong-secret"); recorder.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: expected status 401, got %d", i+1, recorder.Code)
+		}
+	}
+	if recorder := verify(value); recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected locked out key to get 429, got %d", recorder.Code)
+	}
+}
+
 func TestIssueTokenRolesNotAllowed(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -126,7 +267,8 @@ func TestIssueTokenRolesNotAllowed(t *testing.T) {
 		TokenTTL: time.Minute,
 	}
 
-	h := NewAuthHandler(log, nil, cfg, "admin", "secret", []string{"admin"})
+	clients := newTestClients(t, openTestDB(t), "admin", "secret", []string{"admin"})
+	h := NewAuthHandler(log, nil, cfg, clients, nil, nil, nil, nil, nil, nil, nil)
 
 	router := gin.New()
 	router.POST("/token", h.IssueToken)
@@ -147,3 +289,984 @@ func TestIssueTokenRolesNotAllowed(t *testing.T) {
 		t.Fatalf("expected status 403, got %d", recorder.Code)
 	}
 }
+
+func TestRefreshTokenRotationAndReuse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	log, err := logger.New("info")
+	if err != nil {
+		t.Fatalf("failed to init logger: %v", err)
+	}
+	defer log.Sync()
+
+	db := openTestDB(t)
+	if err := db.AutoMigrate(&model.RefreshToken{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+
+	cfg := auth.Config{
+		Secret:   "test-secret",
+		Issuer:   "test-issuer",
+		Audience: "test-audience",
+		TokenTTL: time.Minute,
+	}
+	refreshTokens := service.NewRefreshTokenService(repository.NewRefreshTokenRepository(db), repository.NewUserRepository(db), repository.NewOAuthClientRepository(db), time.Hour)
+	clients := newTestClients(t, db, "admin", "secret", []string{"admin"})
+	h := NewAuthHandler(log, nil, cfg, clients, nil, nil, nil, refreshTokens, nil, nil, nil)
+
+	router := gin.New()
+	router.POST("/token", h.IssueToken)
+	router.POST("/logout", h.Logout)
+
+	post := func(path string, payload interface{}) (*httptest.ResponseRecorder, tokenResponse) {
+		body, _ := json.Marshal(payload)
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+
+		var resp tokenResponse
+		_ = json.Unmarshal(recorder.Body.Bytes(), &resp)
+		return recorder, resp
+	}
+
+	recorder, issued := post("/token", map[string]string{"client_id": "admin", "client_secret": "secret"})
+	if recorder.Code != http.StatusOK || issued.Data.RefreshToken == "" {
+		t.Fatalf("expected refresh token to be issued, got %d %s", recorder.Code, recorder.Body.String())
+	}
+
+	refreshRequest := func(token string) map[string]string {
+		return map[string]string{"grant_type": "refresh_token", "refresh_token": token}
+	}
+
+	recorder, rotated := post("/token", refreshRequest(issued.Data.RefreshToken))
+	if recorder.Code != http.StatusOK || rotated.Data.AccessToken == "" {
+		t.Fatalf("expected refresh to succeed, got %d %s", recorder.Code, recorder.Body.String())
+	}
+	if rotated.Data.RefreshToken == issued.Data.RefreshToken {
+		t.Fatal("expected refresh token to be rotated")
+	}
+
+	// Replaying the original token revokes the whole family.
+	if recorder, _ := post("/token", refreshRequest(issued.Data.RefreshToken)); recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected reuse to be rejected, got %d", recorder.Code)
+	}
+	if recorder, _ := post("/token", refreshRequest(rotated.Data.RefreshToken)); recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected rotated token to be revoked after reuse, got %d", recorder.Code)
+	}
+
+	// Logout revokes a fresh family.
+	_, second := post("/token", map[string]string{"client_id": "admin", "client_secret": "secret"})
+	if recorder, _ := post("/logout", map[string]string{"refresh_token": second.Data.RefreshToken}); recorder.Code != http.StatusOK {
+		t.Fatalf("expected logout to succeed, got %d", recorder.Code)
+	}
+	if recorder, _ := post("/token", refreshRequest(second.Data.RefreshToken)); recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected logged out token to be rejected, got %d", recorder.Code)
+	}
+}
+
+func TestOAuthClientCredentialsIntrospectAndRevoke(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	log, err := logger.New("info")
+	if err != nil {
+		t.Fatalf("failed to init logger: %v", err)
+	}
+	defer log.Sync()
+
+	cfg := auth.Config{
+		Secret:   "test-secret",
+		Issuer:   "test-issuer",
+		Audience: "test-audience",
+		TokenTTL: time.Minute,
+	}
+	clients := newTestClients(t, openTestDB(t), "reporting", "s3cret", []string{"admin", "user"})
+	revocations := auth.NewRevocationList(nil, time.Minute, nil)
+	h := NewAuthHandler(log, nil, cfg, clients, nil, nil, nil, nil, revocations, nil, map[string]string{"orders:read": "user"})
+
+	router := gin.New()
+	router.POST("/oauth/token", h.OAuthToken)
+	router.POST("/oauth/introspect", h.Introspect)
+	router.POST("/oauth/revoke", h.OAuthRevoke)
+
+	postForm := func(path string, form url.Values, basic bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if basic {
+			req.SetBasicAuth("reporting", "s3cret")
+		}
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	recorder := postForm("/oauth/token", url.Values{"grant_type": {"client_credentials"}, "scope": {"orders:read"}}, true)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d %s", recorder.Code, recorder.Body.String())
+	}
+	var token OAuthTokenResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &token); err != nil {
+		t.Fatalf("failed to decode token response: %v", err)
+	}
+	if token.AccessToken == "" || token.TokenType != "Bearer" || token.Scope != "orders:read" || token.ExpiresIn != 60 {
+		t.Fatalf("unexpected token response %+v", token)
+	}
+
+	claims, err := auth.ParseToken(cfg, token.AccessToken)
+	if err != nil || len(claims.Roles) != 1 || claims.Roles[0] != "user" {
+		t.Fatalf("expected scope to map onto the user role, got %+v (%v)", claims, err)
+	}
+
+	recorder = postForm("/oauth/token", url.Values{"grant_type": {"client_credentials"}, "client_id": {"reporting"}, "client_secret": {"wrong"}}, false)
+	if recorder.Code != http.StatusUnauthorized || recorder.Header().Get("WWW-Authenticate") == "" {
+		t.Fatalf("expected invalid_client with 401, got %d", recorder.Code)
+	}
+
+	recorder = postForm("/oauth/token", url.Values{"grant_type": {"client_credentials"}, "scope": {"service"}}, true)
+	if recorder.Code != http.StatusBadRequest || !strings.Contains(recorder.Body.String(), "invalid_scope") {
+		t.Fatalf("expected invalid_scope, got %d %s", recorder.Code, recorder.Body.String())
+	}
+
+	introspect := func() TokenIntrospectionResponse {
+		recorder := postForm("/oauth/introspect", url.Values{"token": {token.AccessToken}}, true)
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("expected introspection status 200, got %d", recorder.Code)
+		}
+		var result TokenIntrospectionResponse
+		_ = json.Unmarshal(recorder.Body.Bytes(), &result)
+		return result
+	}
+
+	if result := introspect(); !result.Active || result.Subject != "reporting" || result.Scope != "orders:read" {
+		t.Fatalf("expected active token, got %+v", result)
+	}
+
+	if recorder := postForm("/oauth/revoke", url.Values{"token": {token.AccessToken}, "token_type_hint": {"access_token"}}, true); recorder.Code != http.StatusOK {
+		t.Fatalf("expected revocation status 200, got %d", recorder.Code)
+	}
+
+	if result := introspect(); result.Active {
+		t.Fatalf("expected revoked token to be inactive, got %+v", result)
+	}
+
+	if recorder := postForm("/oauth/revoke", url.Values{"token": {"not-a-token"}}, true); recorder.Code != http.StatusOK {
+		t.Fatalf("expected unknown token revocation to return 200, got %d", recorder.Code)
+	}
+}
+
+func TestOAuthTokenExchange(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	log, err := logger.New("info")
+	if err != nil {
+		t.Fatalf("failed to init logger: %v", err)
+	}
+	defer log.Sync()
+
+	cfg := auth.Config{
+		Secret:   "test-secret",
+		Issuer:   "test-issuer",
+		Audience: "test-audience",
+		TokenTTL: time.Minute,
+	}
+	clients := newTestClients(t, openTestDB(t), "order-service", "s3cret", []string{"service"})
+	revocations := auth.NewRevocationList(nil, time.Minute, nil)
+	h := NewAuthHandler(log, nil, cfg, clients, nil, nil, nil, nil, revocations, nil, nil)
+
+	router := gin.New()
+	router.POST("/oauth/token", h.OAuthToken)
+	router.POST("/oauth/introspect", h.Introspect)
+
+	exchange := func(subjectToken, scope string) *httptest.ResponseRecorder {
+		form := url.Values{
+			"grant_type":         {GrantTypeTokenExchange},
+			"subject_token":      {subjectToken},
+			"subject_token_type": {TokenTypeAccessToken},
+		}
+		if scope != "" {
+			form.Set("scope", scope)
+		}
+		req := httptest.NewRequest(http.MethodPost, "/oauth/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth("order-service", "s3cret")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	userToken, _ := auth.GenerateTokenWithAMR(cfg, "42", []string{"user"}, []string{auth.AMRPassword})
+	recorder := exchange(userToken, "")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d %s", recorder.Code, recorder.Body.String())
+	}
+	var token OAuthTokenResponse
+	if err := json.Unmarshal(recorder.Body.Bytes(), &token); err != nil {
+		t.Fatalf("failed to decode token response: %v", err)
+	}
+	if token.IssuedTokenType != TokenTypeAccessToken || token.RefreshToken != "" {
+		t.Fatalf("unexpected token response %+v", token)
+	}
+
+	claims, err := auth.ParseToken(cfg, token.AccessToken)
+	if err != nil {
+		t.Fatalf("failed to parse exchanged token: %v", err)
+	}
+	if claims.Subject != "42" || claims.ActorSubject() != "order-service" {
+		t.Fatalf("expected user 42 acted on by order-service, got %q and %q", claims.Subject, claims.ActorSubject())
+	}
+	if len(claims.Roles) != 1 || claims.Roles[0] != "service" {
+		t.Fatalf("expected the client's roles, got %v", claims.Roles)
+	}
+	if len(claims.AMR) != 0 || claims.MFAVerified() {
+		t.Fatalf("expected the subject's amr not to carry over to the client's roles, got %v", claims.AMR)
+	}
+	subjectClaims, _ := auth.ParseToken(cfg, userToken)
+	// NumericDate decodes through a float, so allow a millisecond of drift.
+	if claims.AuthTime == nil || subjectClaims.IssuedAt.Sub(claims.AuthTime.Time) > time.Millisecond || claims.IssuedAt.After(subjectClaims.IssuedAt.Time) {
+		t.Fatalf("expected iat and auth_time of the subject token, got iat=%v auth_time=%v", claims.IssuedAt, claims.AuthTime)
+	}
+
+	if recorder := exchange(token.AccessToken, ""); recorder.Code != http.StatusBadRequest || !strings.Contains(recorder.Body.String(), "invalid_grant") {
+		t.Fatalf("expected exchanged tokens not to be exchanged again, got %d %s", recorder.Code, recorder.Body.String())
+	}
+
+	shortLived := cfg
+	shortLived.TokenTTL = 10 * time.Second
+	shortToken, _ := auth.GenerateToken(shortLived, "42", []string{"user"})
+	recorder = exchange(shortToken, "")
+	var capped OAuthTokenResponse
+	_ = json.Unmarshal(recorder.Body.Bytes(), &capped)
+	cappedClaims, err := auth.ParseToken(cfg, capped.AccessToken)
+	if err != nil || capped.ExpiresIn > 10 || time.Until(cappedClaims.ExpiresAt.Time) > 10*time.Second {
+		t.Fatalf("expected the exchanged token to expire with the subject token, got expires_in=%d (%v)", capped.ExpiresIn, err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/oauth/introspect", strings.NewReader(url.Values{"token": {token.AccessToken}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth("order-service", "s3cret")
+	recorder = httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	var introspection TokenIntrospectionResponse
+	_ = json.Unmarshal(recorder.Body.Bytes(), &introspection)
+	if !introspection.Active || introspection.Subject != "42" || introspection.ClientID != "order-service" || introspection.Act == nil {
+		t.Fatalf("expected introspection to report the delegation, got %+v", introspection)
+	}
+
+	if recorder := exchange(userToken, "admin"); recorder.Code != http.StatusBadRequest || !strings.Contains(recorder.Body.String(), "invalid_scope") {
+		t.Fatalf("expected invalid_scope for roles beyond the client's, got %d %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder := exchange("not-a-token", ""); recorder.Code != http.StatusBadRequest || !strings.Contains(recorder.Body.String(), "invalid_grant") {
+		t.Fatalf("expected invalid_grant, got %d %s", recorder.Code, recorder.Body.String())
+	}
+
+	if err := revocations.RevokeSubject(context.Background(), "42"); err != nil {
+		t.Fatalf("failed to revoke subject: %v", err)
+	}
+	if recorder := exchange(userToken, ""); recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected revoked subject token to be rejected, got %d", recorder.Code)
+	}
+}
+
+func TestLoginIssuesUserToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	log, err := logger.New("info")
+	if err != nil {
+		t.Fatalf("failed to init logger: %v", err)
+	}
+	defer log.Sync()
+
+	cfg := auth.Config{
+		Secret:   "test-secret",
+		Issuer:   "test-issuer",
+		Audience: "test-audience",
+		TokenTTL: time.Minute,
+	}
+
+	db := openTestDB(t)
+	if err := db.AutoMigrate(&model.User{}, &model.PasswordResetToken{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	users := repository.NewUserRepository(db)
+	credentials := service.NewCredentialService(users, repository.NewPasswordResetTokenRepository(db), nil, service.CredentialConfig{
+		Algorithm: service.PasswordHashBcrypt,
+	})
+
+	user, err := service.NewUserService(users, nil).CreateUser(context.Background(), &model.CreateUserRequest{
+		Email: "jane@example.com",
+		Name:  "Jane",
+		Age:   30,
+		Roles: []string{"user", "auditor"},
+	}, "admin")
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	if err := credentials.SetPassword(context.Background(), user.ID, "correct-horse", "admin"); err != nil {
+		t.Fatalf("failed to set password: %v", err)
+	}
+
+	h := NewAuthHandler(log, nil, cfg, newTestClients(t, db, "admin", "secret", []string{"admin"}), credentials, nil, nil, nil, nil, nil, nil)
+
+	router := gin.New()
+	router.POST("/login", h.Login)
+
+	login := func(password string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"email": "jane@example.com", "password": password})
+		req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	if recorder := login("wrong-password"); recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status 401 for wrong password, got %d", recorder.Code)
+	}
+
+	recorder := login("correct-horse")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", recorder.Code)
+	}
+
+	var resp tokenResponse
+	if err := json.NewDecoder(recorder.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	claims, err := auth.ParseToken(cfg, resp.Data.AccessToken)
+	if err != nil {
+		t.Fatalf("failed to parse access token: %v", err)
+	}
+	if claims.Subject != userSubject(user.ID) {
+		t.Fatalf("expected subject %d, got %q", user.ID, claims.Subject)
+	}
+	if len(claims.Roles) != 2 || claims.Roles[1] != "auditor" {
+		t.Fatalf("expected user roles in token, got %v", claims.Roles)
+	}
+	if claims.ACR != auth.ACRSingleFactor || claims.MFAVerified() {
+		t.Fatalf("expected a single-factor token, got acr=%q amr=%v", claims.ACR, claims.AMR)
+	}
+}
+
+func TestLoginLocksOutAfterRepeatedBadPasswords(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	log, err := logger.New("info")
+	if err != nil {
+		t.Fatalf("failed to init logger: %v", err)
+	}
+	defer log.Sync()
+
+	cfg := auth.Config{
+		Secret:   "test-secret",
+		Issuer:   "test-issuer",
+		Audience: "test-audience",
+		TokenTTL: time.Minute,
+	}
+
+	var (
+		mu     sync.Mutex
+		events []audit.Event
+	)
+	auditServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event audit.Event
+		if err := json.NewDecoder(r.Body).Decode(&event); err == nil {
+			mu.Lock()
+			events = append(events, event)
+			mu.Unlock()
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer auditServer.Close()
+	auditClient := audit.NewClient(audit.Config{Enabled: true, BaseURL: auditServer.URL}, log)
+
+	db := openTestDB(t)
+	if err := db.AutoMigrate(&model.User{}, &model.PasswordResetToken{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	users := repository.NewUserRepository(db)
+	credentials := service.NewCredentialService(users, repository.NewPasswordResetTokenRepository(db), nil, service.CredentialConfig{
+		Algorithm: service.PasswordHashBcrypt,
+	})
+
+	user, err := service.NewUserService(users, nil).CreateUser(context.Background(), &model.CreateUserRequest{
+		Email: "jane@example.com",
+		Name:  "Jane",
+		Age:   30,
+	}, "admin")
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	if err := credentials.SetPassword(context.Background(), user.ID, "correct-horse", "admin"); err != nil {
+		t.Fatalf("failed to set password: %v", err)
+	}
+
+	lockout := service.NewLoginThrottle(nil, service.LockoutPolicy{
+		MaxClientFailures: 3,
+		Window:            time.Minute,
+		BaseLockout:       time.Minute,
+		MaxLockout:        time.Hour,
+	})
+	h := NewAuthHandler(log, auditClient, cfg, newTestClients(t, db, "admin", "secret", []string{"admin"}), credentials, nil, nil, nil, nil, lockout, nil)
+
+	router := gin.New()
+	router.POST("/login", h.Login)
+
+	login := func(email, password string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"email": email, "password": password})
+		req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	for i := 0; i < 2; i++ {
+		if recorder := login("jane@example.com", "wrong-password"); recorder.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: expected status 401, got %d", i+1, recorder.Code)
+		}
+	}
+
+	// The third failure uses a different spelling of the same address and
+	// triggers the lockout; the correct password is refused afterwards.
+	if recorder := login("Jane@Example.com", "wrong-password"); recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("attempt 3: expected status 401, got %d", recorder.Code)
+	}
+	recorder := login("jane@example.com", "correct-horse")
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected locked out login to get 429, got %d", recorder.Code)
+	}
+	if recorder.Header().Get("Retry-After") != "60" {
+		t.Fatalf("expected Retry-After 60, got %q", recorder.Header().Get("Retry-After"))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	var lockedOut bool
+	for _, event := range events {
+		if event.Action == "auth.lockout" && strings.Contains(event.Metadata, `"principal":"email:jane@example.com"`) {
+			lockedOut = true
+		}
+	}
+	if !lockedOut {
+		t.Fatalf("expected an auth.lockout audit event for the email, got %+v", events)
+	}
+}
+
+func TestLoginRequiresMFACode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	log, err := logger.New("info")
+	if err != nil {
+		t.Fatalf("failed to init logger: %v", err)
+	}
+	defer log.Sync()
+
+	cfg := auth.Config{
+		Secret:   "test-secret",
+		Issuer:   "test-issuer",
+		Audience: "test-audience",
+		TokenTTL: time.Minute,
+	}
+
+	db := openTestDB(t)
+	if err := db.AutoMigrate(&model.User{}, &model.PasswordResetToken{}, &model.UserMFA{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	users := repository.NewUserRepository(db)
+	credentials := service.NewCredentialService(users, repository.NewPasswordResetTokenRepository(db), nil, service.CredentialConfig{
+		Algorithm: service.PasswordHashBcrypt,
+	})
+	mfa, err := service.NewMFAService(repository.NewMFARepository(db), users, service.MFAConfig{EncryptionKey: "test-key"})
+	if err != nil {
+		t.Fatalf("failed to create mfa service: %v", err)
+	}
+
+	ctx := context.Background()
+	user, err := service.NewUserService(users, nil).CreateUser(ctx, &model.CreateUserRequest{
+		Email: "root@example.com",
+		Name:  "Root",
+		Age:   30,
+		Roles: []string{"admin"},
+	}, "admin")
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	if err := credentials.SetPassword(ctx, user.ID, "correct-horse", "admin"); err != nil {
+		t.Fatalf("failed to set password: %v", err)
+	}
+	enrollment, err := mfa.EnrollTOTP(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("failed to enroll: %v", err)
+	}
+	code, _ := service.TOTPCode(enrollment.Secret, time.Now().Add(-service.TOTPPeriod))
+	if _, err := mfa.ConfirmTOTP(ctx, user.ID, code); err != nil {
+		t.Fatalf("failed to confirm enrollment: %v", err)
+	}
+
+	h := NewAuthHandler(log, nil, cfg, newTestClients(t, db, "admin", "secret", []string{"admin"}), credentials, mfa, nil, nil, nil, nil, nil)
+
+	router := gin.New()
+	router.POST("/login", h.Login)
+
+	login := func(mfaCode string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"email": "root@example.com", "password": "correct-horse", "mfa_code": mfaCode})
+		req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	if recorder := login(""); recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status 401 without an mfa code, got %d", recorder.Code)
+	}
+
+	code, _ = service.TOTPCode(enrollment.Secret, time.Now())
+	recorder := login(code)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", recorder.Code)
+	}
+
+	var resp tokenResponse
+	if err := json.NewDecoder(recorder.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	claims, err := auth.ParseToken(cfg, resp.Data.AccessToken)
+	if err != nil {
+		t.Fatalf("failed to parse access token: %v", err)
+	}
+	if claims.ACR != auth.ACRMultiFactor || !claims.MFAVerified() {
+		t.Fatalf("expected an MFA-verified token, got acr=%q amr=%v", claims.ACR, claims.AMR)
+	}
+
+	if recorder := login(code); recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected a replayed code to be rejected, got %d", recorder.Code)
+	}
+}
+
+func TestMFACodeChecksShareLoginLockout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	log, err := logger.New("info")
+	if err != nil {
+		t.Fatalf("failed to init logger: %v", err)
+	}
+	defer log.Sync()
+
+	revocations := auth.NewRevocationList(nil, time.Minute, nil)
+	cfg := auth.Config{
+		Secret:      "test-secret",
+		Issuer:      "test-issuer",
+		Audience:    "test-audience",
+		TokenTTL:    time.Minute,
+		Revocations: revocations,
+	}
+
+	db := openTestDB(t)
+	if err := db.AutoMigrate(&model.User{}, &model.UserMFA{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	users := repository.NewUserRepository(db)
+	mfa, err := service.NewMFAService(repository.NewMFARepository(db), users, service.MFAConfig{EncryptionKey: "test-key"})
+	if err != nil {
+		t.Fatalf("failed to create mfa service: %v", err)
+	}
+
+	ctx := context.Background()
+	user, err := service.NewUserService(users, nil).CreateUser(ctx, &model.CreateUserRequest{Email: "mfa@example.com", Name: "MFA", Age: 30}, "admin")
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	enrollment, err := mfa.EnrollTOTP(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("failed to enroll: %v", err)
+	}
+	code, _ := service.TOTPCode(enrollment.Secret, time.Now().Add(-service.TOTPPeriod))
+	if _, err := mfa.ConfirmTOTP(ctx, user.ID, code); err != nil {
+		t.Fatalf("failed to confirm enrollment: %v", err)
+	}
+
+	lockout := service.NewLoginThrottle(nil, service.LockoutPolicy{
+		MaxClientFailures: 2,
+		Window:            time.Minute,
+		BaseLockout:       50 * time.Millisecond,
+		MaxLockout:        50 * time.Millisecond,
+	})
+	h := NewMFAHandler(mfa, lockout, nil, revocations, nil, log)
+
+	router := gin.New()
+	protected := router.Group("/", middleware.AuthMiddleware(cfg))
+	protected.POST("/mfa/disable", h.DisableTOTP)
+	protected.POST("/mfa/recovery-codes", h.RegenerateRecoveryCodes)
+
+	token, _ := auth.GenerateToken(cfg, userSubject(user.ID), []string{"user"})
+	send := func(path, mfaCode string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]string{"code": mfaCode})
+		req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	for i := 0; i < 2; i++ {
+		if recorder := send("/mfa/recovery-codes", "000000"); recorder.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: expected status 401, got %d", i+1, recorder.Code)
+		}
+	}
+	code, _ = service.TOTPCode(enrollment.Secret, time.Now())
+	if recorder := send("/mfa/disable", code); recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected locked out user to get 429, got %d", recorder.Code)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if recorder := send("/mfa/disable", code); recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder := send("/mfa/disable", code); recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected sessions to be revoked after disabling mfa, got %d", recorder.Code)
+	}
+}
+
+func TestAuthorizationCodeFlowWithPKCE(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	log, err := logger.New("info")
+	if err != nil {
+		t.Fatalf("failed to init logger: %v", err)
+	}
+	defer log.Sync()
+
+	cfg := auth.Config{
+		Secret:   "test-secret",
+		Issuer:   "test-issuer",
+		Audience: "test-audience",
+		TokenTTL: time.Minute,
+	}
+
+	db := openTestDB(t)
+	if err := db.AutoMigrate(&model.User{}, &model.PasswordResetToken{}, &model.AuthorizationCode{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	users := repository.NewUserRepository(db)
+	credentials := service.NewCredentialService(users, repository.NewPasswordResetTokenRepository(db), nil, service.CredentialConfig{
+		Algorithm: service.PasswordHashBcrypt,
+	})
+	oidc := service.NewOIDCService(repository.NewAuthorizationCodeRepository(db), users, service.OIDCConfig{Issuer: "https://id.example.com"})
+
+	ctx := context.Background()
+	user, err := service.NewUserService(users, nil).CreateUser(ctx, &model.CreateUserRequest{
+		Email: "jane@example.com",
+		Name:  "Jane",
+		Age:   30,
+		Roles: []string{"admin", "user"},
+	}, "admin")
+	if err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	if err := credentials.SetPassword(ctx, user.ID, "correct-horse", "admin"); err != nil {
+		t.Fatalf("failed to set password: %v", err)
+	}
+
+	clients := newTestClients(t, db, "admin", "secret", []string{"admin"})
+	redirectURI := "https://app.example.com/callback"
+	if _, _, err := clients.CreateClient(ctx, &model.CreateOAuthClientRequest{
+		ClientID:     "frontend",
+		Name:         "Frontend",
+		AllowedRoles: []string{"user"},
+		RedirectURIs: []string{redirectURI},
+		Public:       true,
+	}, "admin"); err != nil {
+		t.Fatalf("failed to register client: %v", err)
+	}
+
+	h := NewAuthHandler(log, nil, cfg, clients, credentials, nil, oidc, nil, nil, nil, nil)
+
+	router := gin.New()
+	router.GET("/.well-known/openid-configuration", h.OpenIDConfiguration)
+	router.GET("/oauth/authorize", h.Authorize)
+	router.POST("/oauth/authorize", h.AuthorizeLogin)
+	router.POST("/oauth/token", h.OAuthToken)
+	router.GET("/userinfo", middleware.AuthMiddleware(cfg), h.UserInfo)
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+	postForm := func(path string, form url.Values) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return serve(req)
+	}
+
+	recorder := serve(httptest.NewRequest(http.MethodGet, "/.well-known/openid-configuration", nil))
+	var discovery OpenIDConfiguration
+	if err := json.NewDecoder(recorder.Body).Decode(&discovery); err != nil {
+		t.Fatalf("failed to decode discovery document: %v", err)
+	}
+	if discovery.Issuer != "https://id.example.com" || discovery.AuthorizationEndpoint != "https://id.example.com/oauth/authorize" {
+		t.Fatalf("unexpected discovery document: %+v", discovery)
+	}
+
+	verifier := strings.Repeat("a", 43)
+	sum := sha256.Sum256([]byte(verifier))
+	authorize := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {"frontend"},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {"openid profile email"},
+		"state":                 {"xyz"},
+		"nonce":                 {"n-123"},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(sum[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	unregistered := url.Values{}
+	for key, values := range authorize {
+		unregistered[key] = values
+	}
+	unregistered.Set("redirect_uri", "https://evil.example.com/callback")
+	if recorder := serve(httptest.NewRequest(http.MethodGet, "/oauth/authorize?"+unregistered.Encode(), nil)); recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for an unregistered redirect_uri, got %d", recorder.Code)
+	}
+
+	recorder = serve(httptest.NewRequest(http.MethodGet, "/oauth/authorize?"+authorize.Encode(), nil))
+	if recorder.Code != http.StatusOK || !strings.Contains(recorder.Body.String(), `name="password"`) {
+		t.Fatalf("expected the sign-in form, got %d", recorder.Code)
+	}
+
+	login := url.Values{"email": {"jane@example.com"}, "password": {"wrong-password"}}
+	for key, values := range authorize {
+		login[key] = values
+	}
+	if recorder := postForm("/oauth/authorize", login); recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected status 401 for a wrong password, got %d", recorder.Code)
+	}
+
+	login.Set("password", "correct-horse")
+	recorder = postForm("/oauth/authorize", login)
+	if recorder.Code != http.StatusFound {
+		t.Fatalf("expected a redirect, got %d", recorder.Code)
+	}
+	location, err := url.Parse(recorder.Header().Get("Location"))
+	if err != nil || !strings.HasPrefix(location.String(), redirectURI) {
+		t.Fatalf("unexpected redirect %q", recorder.Header().Get("Location"))
+	}
+	code := location.Query().Get("code")
+	if code == "" || location.Query().Get("state") != "xyz" {
+		t.Fatalf("expected code and state in redirect, got %q", location.RawQuery)
+	}
+
+	exchange := url.Values{
+		"grant_type":    {GrantTypeAuthorizationCode},
+		"client_id":     {"frontend"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {strings.Repeat("b", 43)},
+	}
+	if recorder := postForm("/oauth/token", exchange); recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400 for a wrong code_verifier, got %d", recorder.Code)
+	}
+
+	exchange.Set("code_verifier", verifier)
+	recorder = postForm("/oauth/token", exchange)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	var tokens OAuthTokenResponse
+	if err := json.NewDecoder(recorder.Body).Decode(&tokens); err != nil {
+		t.Fatalf("failed to decode token response: %v", err)
+	}
+	if tokens.IDToken == "" || tokens.RefreshToken != "" {
+		t.Fatalf("expected an id token and no refresh token, got %+v", tokens)
+	}
+
+	access, err := auth.ParseToken(cfg, tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("failed to parse access token: %v", err)
+	}
+	if access.Subject != userSubject(user.ID) || len(access.Roles) != 1 || access.Roles[0] != "user" {
+		t.Fatalf("expected the user's roles limited to the client, got sub=%q roles=%v", access.Subject, access.Roles)
+	}
+
+	idConfig := cfg
+	idConfig.Issuer = "https://id.example.com"
+	idConfig.Audience = "frontend"
+	idClaims, err := auth.ParseToken(idConfig, tokens.IDToken)
+	if err != nil {
+		t.Fatalf("failed to parse id token: %v", err)
+	}
+	if idClaims.Nonce != "n-123" || idClaims.Email != "jane@example.com" || idClaims.Name != "Jane" || idClaims.AuthTime == nil {
+		t.Fatalf("unexpected id token claims: %+v", idClaims)
+	}
+
+	if recorder := postForm("/oauth/token", exchange); recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected a redeemed code to be rejected, got %d", recorder.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/userinfo", nil)
+	req.Header.Set("Authorization", "Bearer "+tokens.AccessToken)
+	recorder = serve(req)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200 from userinfo, got %d", recorder.Code)
+	}
+	var info model.UserInfoResponse
+	if err := json.NewDecoder(recorder.Body).Decode(&info); err != nil {
+		t.Fatalf("failed to decode userinfo: %v", err)
+	}
+	if info.Subject != userSubject(user.ID) || info.Email != "jane@example.com" {
+		t.Fatalf("unexpected userinfo: %+v", info)
+	}
+}
+
+func TestImpersonationStartAndEnd(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	log, err := logger.New("info")
+	if err != nil {
+		t.Fatalf("failed to init logger: %v", err)
+	}
+	defer log.Sync()
+
+	revocations := auth.NewRevocationList(nil, time.Minute, nil)
+	cfg := auth.Config{
+		Secret:      "test-secret",
+		Issuer:      "test-issuer",
+		Audience:    "test-audience",
+		TokenTTL:    time.Hour,
+		Revocations: revocations,
+		Permissions: auth.NewRolePermissions(map[string][]string{
+			"admin":   {"*"},
+			"user":    auth.DefaultRoleGrants()["user"],
+			"support": {auth.PermAuthImpersonate, auth.PermOrdersRead},
+		}),
+	}
+
+	db := openTestDB(t)
+	if err := db.AutoMigrate(&model.User{}, &model.PasswordResetToken{}); err != nil {
+		t.Fatalf("failed to migrate database: %v", err)
+	}
+	users := service.NewUserService(repository.NewUserRepository(db), nil)
+	ctx := context.Background()
+	createUser := func(email string, roles []string) string {
+		user, err := users.CreateUser(ctx, &model.CreateUserRequest{Email: email, Name: "Test", Age: 30, Roles: roles}, "admin")
+		if err != nil {
+			t.Fatalf("failed to create user: %v", err)
+		}
+		return userSubject(user.ID)
+	}
+	adminID := createUser("admin@example.com", []string{"admin"})
+	customerID := createUser("customer@example.com", nil)
+	otherAdminID := createUser("other@example.com", []string{"admin"})
+	supportID := createUser("support@example.com", []string{"support"})
+
+	h := NewImpersonationHandler(users, cfg, 5*time.Minute, revocations, nil, log)
+	credentials := service.NewCredentialService(repository.NewUserRepository(db), repository.NewPasswordResetTokenRepository(db), nil, service.CredentialConfig{
+		Algorithm: service.PasswordHashBcrypt,
+	})
+	authHandler := NewAuthHandler(log, nil, cfg, nil, credentials, nil, nil, nil, revocations, nil, nil)
+
+	var principal string
+	router := gin.New()
+	protected := router.Group("/", middleware.AuthMiddleware(cfg))
+	protected.POST("/users/:id/impersonate", middleware.RequirePermission(auth.PermAuthImpersonate), h.Start)
+	protected.POST("/impersonation/end", h.End)
+	protected.POST("/password", authHandler.ChangePassword)
+	protected.GET("/whoami", func(c *gin.Context) {
+		principal = resolveActor(c)
+		c.Status(http.StatusOK)
+	})
+
+	send := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	adminToken, _ := auth.GenerateToken(cfg, adminID, []string{"admin"})
+	customerToken, _ := auth.GenerateToken(cfg, customerID, []string{"user"})
+
+	if recorder := send(http.MethodPost, "/users/"+adminID+"/impersonate", customerToken); recorder.Code != http.StatusForbidden {
+		t.Fatalf("expected non-admins to be rejected, got %d", recorder.Code)
+	}
+	if recorder := send(http.MethodPost, "/users/"+otherAdminID+"/impersonate", adminToken); recorder.Code != http.StatusForbidden {
+		t.Fatalf("expected admins to be protected from impersonation, got %d", recorder.Code)
+	}
+	supportToken, _ := auth.GenerateToken(cfg, supportID, []string{"support"})
+	if recorder := send(http.MethodPost, "/users/"+customerID+"/impersonate", supportToken); recorder.Code != http.StatusForbidden {
+		t.Fatalf("expected impersonation beyond the caller's permissions to be rejected, got %d", recorder.Code)
+	}
+
+	clientToken, _ := auth.GenerateToken(cfg, "ops-client", []string{"admin"})
+	if recorder := send(http.MethodPost, "/users/"+customerID+"/impersonate", clientToken); recorder.Code != http.StatusForbidden {
+		t.Fatalf("expected clients to be refused, since End could not recognise their tokens, got %d", recorder.Code)
+	}
+
+	recorder := send(http.MethodPost, "/users/"+customerID+"/impersonate", adminToken)
+	if recorder.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d %s", recorder.Code, recorder.Body.String())
+	}
+	var resp struct {
+		Data ImpersonationResponse `json:"data"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Data.Subject != customerID || resp.Data.ActedBy != adminID || time.Until(resp.Data.ExpiresAt) > 5*time.Minute {
+		t.Fatalf("unexpected impersonation response %+v", resp.Data)
+	}
+
+	claims, err := auth.ParseToken(cfg, resp.Data.AccessToken)
+	if err != nil || claims.Subject != customerID || claims.ActorSubject() != adminID || len(claims.Roles) != 1 || claims.Roles[0] != "user" {
+		t.Fatalf("expected a user token acted on by the admin, got %+v (%v)", claims, err)
+	}
+
+	if recorder := send(http.MethodGet, "/whoami", resp.Data.AccessToken); recorder.Code != http.StatusOK || principal != customerID+" via "+adminID {
+		t.Fatalf("expected both identities to be recorded, got %q", principal)
+	}
+	if recorder := send(http.MethodPost, "/users/"+customerID+"/impersonate", resp.Data.AccessToken); recorder.Code != http.StatusForbidden {
+		t.Fatalf("expected impersonation tokens to lack admin permissions, got %d", recorder.Code)
+	}
+	if recorder := send(http.MethodPost, "/password", resp.Data.AccessToken); recorder.Code != http.StatusForbidden {
+		t.Fatalf("expected impersonation tokens to be refused by self-service endpoints, got %d", recorder.Code)
+	}
+
+	if recorder := send(http.MethodPost, "/impersonation/end", adminToken); recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected regular tokens to be rejected, got %d", recorder.Code)
+	}
+	if recorder := send(http.MethodPost, "/impersonation/end", resp.Data.AccessToken); recorder.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder := send(http.MethodGet, "/whoami", resp.Data.AccessToken); recorder.Code != http.StatusUnauthorized {
+		t.Fatalf("expected ended impersonation token to be revoked, got %d", recorder.Code)
+	}
+
+	// Callers holding a role that requires MFA must have passed it, even when
+	// their other roles grant the permissions needed.
+	mfaCfg := cfg
+	mfaCfg.MFARequiredRoles = []string{"admin"}
+	mfaHandler := NewImpersonationHandler(users, mfaCfg, 5*time.Minute, revocations, nil, log)
+	mfaRouter := gin.New()
+	mfaRouter.POST("/users/:id/impersonate", middleware.AuthMiddleware(mfaCfg), middleware.RequirePermission(auth.PermAuthImpersonate), mfaHandler.Start)
+	impersonate := func(token string) int {
+		req := httptest.NewRequest(http.MethodPost, "/users/"+customerID+"/impersonate", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		recorder := httptest.NewRecorder()
+		mfaRouter.ServeHTTP(recorder, req)
+		return recorder.Code
+	}
+	roles := []string{"admin", "support", "user"}
+	singleFactor, _ := auth.GenerateTokenWithAMR(cfg, adminID, roles, []string{auth.AMRPassword})
+	if code := impersonate(singleFactor); code != http.StatusForbidden {
+		t.Fatalf("expected impersonation without MFA to be refused, got %d", code)
+	}
+	multiFactor, _ := auth.GenerateTokenWithAMR(cfg, adminID, roles, []string{auth.AMRPassword, auth.AMROTP, auth.AMRMFA})
+	if code := impersonate(multiFactor); code != http.StatusCreated {
+		t.Fatalf("expected impersonation after MFA to succeed, got %d", code)
+	}
+}
@@ -0,0 +1,241 @@
+package handler
+
+import (
+	"github.com/RashadTanjim/enterprise-microservice-system/common/audit"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/auth"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/errors"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/logger"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/middleware"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/response"
+	"enterprise-microservice-system/services/user-service/internal/model"
+	"enterprise-microservice-system/services/user-service/internal/service"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// ImpersonationResponse is returned when an admin starts impersonating a user.
+type ImpersonationResponse struct {
+	AccessToken string    `json:"access_token"`
+	TokenType   string    `json:"token_type"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	Subject     string    `json:"subject"`
+	ActedBy     string    `json:"acted_by"`
+	Roles       []string  `json:"roles"`
+}
+
+// ImpersonationHandler lets admins act as a user to reproduce their issues
+type ImpersonationHandler struct {
+	users       service.UserService
+	authConfig  auth.Config
+	revocations *auth.RevocationList
+	auditClient *audit.Client
+	logger      *logger.Logger
+}
+
+// NewImpersonationHandler creates a new impersonation handler. Impersonation
+// tokens live for ttl, capped by the regular token lifetime; zero disables impersonation.
+func NewImpersonationHandler(users service.UserService, authConfig auth.Config, ttl time.Duration, revocations *auth.RevocationList, auditClient *audit.Client, logger *logger.Logger) *ImpersonationHandler {
+	if ttl < authConfig.TokenTTL {
+		authConfig.TokenTTL = ttl
+	}
+	return &ImpersonationHandler{
+		users:       users,
+		authConfig:  authConfig,
+		revocations: revocations,
+		auditClient: auditClient,
+		logger:      logger,
+	}
+}
+
+// Start issues a short-lived token whose subject is the user and whose act
+// claim records the admin. Only users can impersonate, so End can recognise
+// the token, and callers holding a role listed in MFARequiredRoles must have
+// passed MFA. No refresh token is issued.
+// @Summary Impersonate a user
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 201 {object} response.Response{data=ImpersonationResponse}
+// @Failure 403 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /users/{id}/impersonate [post]
+func (h *ImpersonationHandler) Start(c *gin.Context) {
+	if h.authConfig.TokenTTL <= 0 {
+		response.Error(c, errors.NewBadRequest("impersonation is not enabled"))
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.logger.Warn("Invalid user ID", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+
+	admin, ok := auth.ClaimsFromContext(c.Request.Context())
+	if !ok {
+		response.Error(c, errors.New(errors.ErrCodeUnauthorized, "missing authentication context", nil))
+		return
+	}
+	if admin.Act != nil {
+		response.Error(c, errors.New(errors.ErrCodeForbidden, "delegated tokens cannot start impersonation", nil))
+		return
+	}
+	if _, err := strconv.ParseUint(admin.Subject, 10, 32); err != nil {
+		response.Error(c, errors.New(errors.ErrCodeForbidden, "only user accounts can impersonate", nil))
+		return
+	}
+	if h.requiresMFA(admin.Roles) && !admin.MFAVerified() {
+		response.Error(c, errors.New(errors.ErrCodeForbidden, "impersonation requires a multi-factor login", nil))
+		return
+	}
+
+	user, err := h.users.GetUser(c.Request.Context(), uint(id))
+	if err != nil {
+		h.logger.Warn("Failed to load impersonation target", zap.Uint64("user_id", id), zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+
+	subject := userSubject(user.ID)
+	roles := userRoles(user)
+	switch {
+	case subject == admin.Subject:
+		err = errors.NewBadRequest("cannot impersonate yourself")
+	case user.Status != model.UserStatusActive:
+		err = errors.New(errors.ErrCodeForbidden, "only active users can be impersonated", nil)
+	case hasRole(roles, "admin"):
+		err = errors.New(errors.ErrCodeForbidden, "administrators cannot be impersonated", nil)
+	case !h.grantsHeldByCaller(c, roles):
+		err = errors.New(errors.ErrCodeForbidden, "cannot impersonate a user with permissions you do not hold", nil)
+	}
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	token, err := auth.GenerateDelegatedToken(h.authConfig, auth.DelegatedToken{
+		Subject: subject,
+		Actor:   admin.Subject,
+		Roles:   roles,
+		AMR:     admin.AMR,
+	})
+	if err != nil {
+		h.logger.Error("Failed to generate impersonation token", zap.Error(err))
+		response.Error(c, errors.New(errors.ErrCodeInternal, "failed to generate token", err))
+		return
+	}
+	expiresAt := time.Now().UTC().Add(h.authConfig.TokenTTL)
+
+	h.logger.Info("Impersonation started", zap.String("admin", admin.Subject), zap.String("user", subject))
+	h.trackAudit(c, audit.Event{
+		Actor:        admin.Subject,
+		Action:       "auth.impersonation.started",
+		ResourceType: "user",
+		ResourceID:   subject,
+		Description:  "Admin started impersonating user",
+		Metadata: encodeMetadata(map[string]interface{}{
+			"roles":      roles,
+			"expires_at": expiresAt,
+		}),
+	})
+	response.Created(c, ImpersonationResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresAt:   expiresAt,
+		Subject:     subject,
+		ActedBy:     admin.Subject,
+		Roles:       roles,
+	})
+}
+
+// End revokes the impersonation token used to call it.
+// @Summary End impersonation
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Router /auth/impersonation/end [post]
+func (h *ImpersonationHandler) End(c *gin.Context) {
+	claims, ok := auth.ClaimsFromContext(c.Request.Context())
+	if !ok || !isImpersonation(claims) {
+		response.Error(c, errors.NewBadRequest("not an impersonation token"))
+		return
+	}
+
+	if h.revocations != nil {
+		if err := h.revocations.RevokeToken(c.Request.Context(), claims.ID); err != nil {
+			h.logger.Error("Failed to revoke impersonation token", zap.Error(err))
+			response.Error(c, errors.New(errors.ErrCodeServiceUnavail, "failed to end impersonation", err))
+			return
+		}
+	}
+
+	h.logger.Info("Impersonation ended", zap.String("admin", claims.ActorSubject()), zap.String("user", claims.Subject))
+	h.trackAudit(c, audit.Event{
+		Actor:        claims.Subject,
+		ActedBy:      claims.Act.String(),
+		Action:       "auth.impersonation.ended",
+		ResourceType: "user",
+		ResourceID:   claims.Subject,
+		Description:  "Admin stopped impersonating user",
+	})
+	response.Success(c, gin.H{"message": "impersonation ended"})
+}
+
+func (h *ImpersonationHandler) trackAudit(c *gin.Context, event audit.Event) {
+	if h.auditClient == nil {
+		return
+	}
+	h.auditClient.Track(c.Request.Context(), event, c.GetHeader("Authorization"))
+}
+
+// isImpersonation reports whether claims were issued by Start: a user subject
+// acted on by another user rather than by a service.
+func isImpersonation(claims *auth.Claims) bool {
+	if claims.Act == nil || claims.Act.Act != nil {
+		return false
+	}
+	_, subjectErr := strconv.ParseUint(claims.Subject, 10, 32)
+	_, actorErr := strconv.ParseUint(claims.Act.Subject, 10, 32)
+	return subjectErr == nil && actorErr == nil
+}
+
+// requiresMFA reports whether any of roles is listed in MFARequiredRoles.
+func (h *ImpersonationHandler) requiresMFA(roles []string) bool {
+	for _, role := range h.authConfig.MFARequiredRoles {
+		if hasRole(roles, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// grantsHeldByCaller reports whether every permission roles grant is also
+// granted to the caller, so impersonation never widens what the caller can do.
+func (h *ImpersonationHandler) grantsHeldByCaller(c *gin.Context, roles []string) bool {
+	permissions := h.authConfig.Permissions
+	if permissions == nil {
+		permissions = auth.NewRolePermissions(auth.DefaultRoleGrants())
+	}
+	for _, permission := range permissions.Resolve(roles) {
+		if !middleware.HasPermission(c, permission) {
+			return false
+		}
+	}
+	return true
+}
+
+func hasRole(roles []string, role string) bool {
+	for _, assigned := range roles {
+		if assigned == role {
+			return true
+		}
+	}
+	return false
+}
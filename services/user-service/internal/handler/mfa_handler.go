@@ -0,0 +1,243 @@
+package handler
+
+import (
+	"github.com/RashadTanjim/enterprise-microservice-system/common/audit"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/auth"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/errors"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/logger"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/response"
+	"enterprise-microservice-system/services/user-service/internal/model"
+	"enterprise-microservice-system/services/user-service/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// MFAHandler handles TOTP enrollment for the authenticated user
+type MFAHandler struct {
+	service       service.MFAService
+	lockout       service.LoginThrottle
+	refreshTokens service.RefreshTokenService
+	revocations   *auth.RevocationList
+	auditClient   *audit.Client
+	logger        *logger.Logger
+}
+
+// NewMFAHandler creates a new MFA handler. Codes presented to disable MFA or
+// regenerate recovery codes count towards the same lockout as login codes;
+// lockout, refreshTokens and revocations may be nil.
+func NewMFAHandler(service service.MFAService, lockout service.LoginThrottle, refreshTokens service.RefreshTokenService, revocations *auth.RevocationList, auditClient *audit.Client, logger *logger.Logger) *MFAHandler {
+	return &MFAHandler{
+		service:       service,
+		lockout:       lockout,
+		refreshTokens: refreshTokens,
+		revocations:   revocations,
+		auditClient:   auditClient,
+		logger:        logger,
+	}
+}
+
+// Status handles reading the caller's MFA status
+// @Summary Get MFA status
+// @Tags mfa
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} response.Response{data=model.MFAStatusResponse}
+// @Failure 403 {object} response.Response
+// @Router /auth/mfa [get]
+func (h *MFAHandler) Status(c *gin.Context) {
+	userID, ok := h.callerID(c)
+	if !ok {
+		return
+	}
+
+	status, err := h.service.Status(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.Error("Failed to get mfa status", zap.Uint("user_id", userID), zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+	response.Success(c, status)
+}
+
+// EnrollTOTP handles starting TOTP enrollment
+// @Summary Start TOTP enrollment
+// @Tags mfa
+// @Produce json
+// @Security BearerAuth
+// @Success 201 {object} response.Response{data=model.TOTPEnrollmentResponse}
+// @Failure 409 {object} response.Response
+// @Router /auth/mfa/totp [post]
+func (h *MFAHandler) EnrollTOTP(c *gin.Context) {
+	userID, ok := h.callerID(c)
+	if !ok {
+		return
+	}
+
+	enrollment, err := h.service.EnrollTOTP(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.Warn("Failed to start totp enrollment", zap.Uint("user_id", userID), zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+
+	h.trackAudit(c, userID, "auth.mfa.enrollment_started", "TOTP enrollment started")
+	response.Created(c, enrollment)
+}
+
+// ConfirmTOTP handles confirming TOTP enrollment with a first code
+// @Summary Confirm TOTP enrollment
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param code body model.MFACodeRequest true "Current TOTP code"
+// @Success 200 {object} response.Response{data=model.RecoveryCodesResponse}
+// @Failure 401 {object} response.Response
+// @Router /auth/mfa/totp/confirm [post]
+func (h *MFAHandler) ConfirmTOTP(c *gin.Context) {
+	userID, req, ok := h.bindCode(c)
+	if !ok {
+		return
+	}
+
+	codes, err := h.service.ConfirmTOTP(c.Request.Context(), userID, req.Code)
+	if err != nil {
+		h.logger.Warn("Failed to confirm totp enrollment", zap.Uint("user_id", userID), zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+
+	h.logger.Info("MFA enabled", zap.Uint("user_id", userID))
+	h.trackAudit(c, userID, "auth.mfa.enabled", "TOTP MFA enabled")
+	response.Success(c, model.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableTOTP handles removing MFA after verifying a code. Existing sessions
+// are revoked, since they may have been established with the second factor.
+// @Summary Disable MFA
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param code body model.MFACodeRequest true "TOTP or recovery code"
+// @Success 200 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 429 {object} response.Response
+// @Router /auth/mfa/totp/disable [post]
+func (h *MFAHandler) DisableTOTP(c *gin.Context) {
+	userID, req, ok := h.bindCode(c)
+	if !ok {
+		return
+	}
+
+	err := h.verifyingCode(c, userID, func() error {
+		return h.service.Disable(c.Request.Context(), userID, req.Code)
+	})
+	if err != nil {
+		h.logger.Warn("Failed to disable mfa", zap.Uint("user_id", userID), zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+
+	subject := userSubject(userID)
+	revokeSubjectTokens(c, h.logger, h.revocations, h.refreshTokens, subject, subject)
+
+	h.logger.Info("MFA disabled", zap.Uint("user_id", userID))
+	h.trackAudit(c, userID, "auth.mfa.disabled", "TOTP MFA disabled")
+	response.Success(c, gin.H{"message": "mfa disabled successfully"})
+}
+
+// RegenerateRecoveryCodes handles replacing the caller's recovery codes
+// @Summary Regenerate recovery codes
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param code body model.MFACodeRequest true "TOTP or recovery code"
+// @Success 200 {object} response.Response{data=model.RecoveryCodesResponse}
+// @Failure 401 {object} response.Response
+// @Failure 429 {object} response.Response
+// @Router /auth/mfa/recovery-codes [post]
+func (h *MFAHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, req, ok := h.bindCode(c)
+	if !ok {
+		return
+	}
+
+	var codes []string
+	err := h.verifyingCode(c, userID, func() error {
+		var err error
+		codes, err = h.service.RegenerateRecoveryCodes(c.Request.Context(), userID, req.Code)
+		return err
+	})
+	if err != nil {
+		h.logger.Warn("Failed to regenerate recovery codes", zap.Uint("user_id", userID), zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+
+	h.trackAudit(c, userID, "auth.mfa.recovery_codes_regenerated", "Recovery codes regenerated")
+	response.Success(c, model.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// callerID resolves the authenticated user; clients and API keys without a
+// user subject cannot enroll.
+func (h *MFAHandler) callerID(c *gin.Context) (uint, bool) {
+	userID, err := callerUserID(c, "only user accounts can enroll in mfa")
+	if err != nil {
+		response.Error(c, err)
+		return 0, false
+	}
+	return userID, true
+}
+
+// verifyingCode runs verify, which checks an MFA code, under the user's MFA
+// lockout so these endpoints cannot be used to guess codes that login would
+// throttle.
+func (h *MFAHandler) verifyingCode(c *gin.Context, userID uint, verify func() error) error {
+	principal := mfaPrincipal(userID)
+	if checkLockout(c, h.lockout, h.logger, principal) {
+		return errors.New(errors.ErrCodeRateLimit, lockoutMessage, nil)
+	}
+
+	if err := verify(); err != nil {
+		recordLockoutFailure(c, h.lockout, h.logger, func(event audit.Event) {
+			if h.auditClient != nil {
+				h.auditClient.Track(c.Request.Context(), event, c.GetHeader("Authorization"))
+			}
+		}, principal, err)
+		return err
+	}
+	recordLockoutSuccess(c, h.lockout, h.logger, principal)
+	return nil
+}
+
+func (h *MFAHandler) bindCode(c *gin.Context) (uint, *model.MFACodeRequest, bool) {
+	userID, ok := h.callerID(c)
+	if !ok {
+		return 0, nil, false
+	}
+
+	var req model.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Invalid mfa request", zap.Error(err))
+		response.Error(c, err)
+		return 0, nil, false
+	}
+	return userID, &req, true
+}
+
+func (h *MFAHandler) trackAudit(c *gin.Context, userID uint, action, description string) {
+	if h.auditClient == nil {
+		return
+	}
+	subject := userSubject(userID)
+	h.auditClient.Track(c.Request.Context(), audit.Event{
+		Actor:        subject,
+		Action:       action,
+		ResourceType: "user",
+		ResourceID:   subject,
+		Description:  description,
+	}, c.GetHeader("Authorization"))
+}
@@ -0,0 +1,304 @@
+package handler
+
+import (
+	"github.com/RashadTanjim/enterprise-microservice-system/common/audit"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/auth"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/logger"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/response"
+	"enterprise-microservice-system/services/user-service/internal/model"
+	"enterprise-microservice-system/services/user-service/internal/service"
+	"math"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// OAuthClientHandler handles admin management of OAuth clients
+type OAuthClientHandler struct {
+	service       service.OAuthClientService
+	refreshTokens service.RefreshTokenService
+	revocations   *auth.RevocationList
+	auditClient   *audit.Client
+	logger        *logger.Logger
+}
+
+// NewOAuthClientHandler creates a new OAuth client handler. refreshTokens and
+// revocations may be nil; when set, disabling or deleting a client also revokes
+// the tokens already issued to it.
+func NewOAuthClientHandler(service service.OAuthClientService, refreshTokens service.RefreshTokenService, revocations *auth.RevocationList, auditClient *audit.Client, logger *logger.Logger) *OAuthClientHandler {
+	return &OAuthClientHandler{
+		service:       service,
+		refreshTokens: refreshTokens,
+		revocations:   revocations,
+		auditClient:   auditClient,
+		logger:        logger,
+	}
+}
+
+// CreateClient handles client registration
+// @Summary Register an OAuth client
+// @Tags oauth-clients
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param client body model.CreateOAuthClientRequest true "Client data"
+// @Success 201 {object} response.Response{data=model.OAuthClientSecretResponse}
+// @Failure 400 {object} response.Response
+// @Failure 409 {object} response.Response
+// @Router /auth/clients [post]
+func (h *OAuthClientHandler) CreateClient(c *gin.Context) {
+	var req model.CreateOAuthClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Invalid request body", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+
+	actor := resolveActor(c)
+	client, secret, err := h.service.CreateClient(c.Request.Context(), &req, actor)
+	if err != nil {
+		h.logger.Error("Failed to create client", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+
+	h.logger.Info("OAuth client created", zap.String("client_id", client.ClientID))
+	h.trackAudit(c, audit.Event{
+		Actor:        actor,
+		Action:       "oauth_client.create",
+		ResourceType: "oauth_client",
+		ResourceID:   client.ClientID,
+		Description:  "OAuth client created",
+		Metadata: encodeMetadata(map[string]interface{}{
+			"allowed_roles": client.RoleList(),
+			"redirect_uris": client.RedirectURIList(),
+			"public":        client.Public,
+		}),
+	})
+	response.Created(c, model.OAuthClientSecretResponse{
+		OAuthClientResponse: client.ToResponse(),
+		ClientSecret:        secret,
+	})
+}
+
+// GetClient handles retrieving a client
+// @Summary Get an OAuth client
+// @Tags oauth-clients
+// @Produce json
+// @Security BearerAuth
+// @Param client_id path string true "Client ID"
+// @Success 200 {object} response.Response{data=model.OAuthClientResponse}
+// @Failure 404 {object} response.Response
+// @Router /auth/clients/{client_id} [get]
+func (h *OAuthClientHandler) GetClient(c *gin.Context) {
+	clientID := c.Param("client_id")
+
+	client, err := h.service.GetClient(c.Request.Context(), clientID)
+	if err != nil {
+		h.logger.Error("Failed to get client", zap.String("client_id", clientID), zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+
+	response.Success(c, client.ToResponse())
+}
+
+// UpdateClient handles updating a client
+// @Summary Update an OAuth client
+// @Tags oauth-clients
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param client_id path string true "Client ID"
+// @Param client body model.UpdateOAuthClientRequest true "Client update data"
+// @Success 200 {object} response.Response{data=model.OAuthClientResponse}
+// @Failure 400 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /auth/clients/{client_id} [put]
+func (h *OAuthClientHandler) UpdateClient(c *gin.Context) {
+	clientID := c.Param("client_id")
+
+	var req model.UpdateOAuthClientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Invalid request body", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+
+	previous, err := h.service.GetClient(c.Request.Context(), clientID)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	previousRoles := previous.RoleList()
+
+	actor := resolveActor(c)
+	client, err := h.service.UpdateClient(c.Request.Context(), clientID, &req, actor)
+	if err != nil {
+		h.logger.Error("Failed to update client", zap.String("client_id", clientID), zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+
+	// Tokens carrying roles the client just lost must not outlive the change.
+	if client.Status == model.OAuthClientStatusDisabled || !rolesAllowed(previousRoles, client.RoleList()) {
+		h.revokeClientTokens(c, clientID, actor)
+	}
+
+	h.logger.Info("OAuth client updated", zap.String("client_id", clientID))
+	h.trackAudit(c, audit.Event{
+		Actor:        actor,
+		Action:       "oauth_client.update",
+		ResourceType: "oauth_client",
+		ResourceID:   clientID,
+		Description:  "OAuth client updated",
+		Metadata: encodeMetadata(map[string]interface{}{
+			"status":        client.Status,
+			"allowed_roles": client.RoleList(),
+			"redirect_uris": client.RedirectURIList(),
+			"public":        client.Public,
+		}),
+	})
+	response.Success(c, client.ToResponse())
+}
+
+// DeleteClient handles deleting a client
+// @Summary Delete an OAuth client
+// @Tags oauth-clients
+// @Produce json
+// @Security BearerAuth
+// @Param client_id path string true "Client ID"
+// @Success 200 {object} response.Response
+// @Failure 404 {object} response.Response
+// @Router /auth/clients/{client_id} [delete]
+func (h *OAuthClientHandler) DeleteClient(c *gin.Context) {
+	clientID := c.Param("client_id")
+
+	actor := resolveActor(c)
+	if err := h.service.DeleteClient(c.Request.Context(), clientID, actor); err != nil {
+		h.logger.Error("Failed to delete client", zap.String("client_id", clientID), zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+
+	h.revokeClientTokens(c, clientID, actor)
+
+	h.logger.Info("OAuth client deleted", zap.String("client_id", clientID))
+	h.trackAudit(c, audit.Event{
+		Actor:        actor,
+		Action:       "oauth_client.delete",
+		ResourceType: "oauth_client",
+		ResourceID:   clientID,
+		Description:  "OAuth client deleted",
+	})
+	response.Success(c, gin.H{"message": "client deleted successfully"})
+}
+
+// ListClients handles listing clients with pagination
+// @Summary List OAuth clients
+// @Tags oauth-clients
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Page size" default(10)
+// @Param status query string false "Filter by status (active/disabled/deleted)"
+// @Success 200 {object} response.Response{data=[]model.OAuthClientResponse}
+// @Router /auth/clients [get]
+func (h *OAuthClientHandler) ListClients(c *gin.Context) {
+	var query model.ListOAuthClientsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		h.logger.Warn("Invalid query parameters", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+
+	clients, total, err := h.service.ListClients(c.Request.Context(), &query)
+	if err != nil {
+		h.logger.Error("Failed to list clients", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+
+	items := make([]model.OAuthClientResponse, 0, len(clients))
+	for _, client := range clients {
+		items = append(items, client.ToResponse())
+	}
+
+	totalPages := int(math.Ceil(float64(total) / float64(query.PageSize)))
+	response.SuccessWithMeta(c, items, &response.Meta{
+		Page:       query.Page,
+		PageSize:   query.PageSize,
+		TotalPages: totalPages,
+		TotalCount: total,
+	})
+}
+
+// RotateSecret handles client secret rotation
+// @Summary Rotate an OAuth client secret
+// @Tags oauth-clients
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param client_id path string true "Client ID"
+// @Param rotation body model.RotateOAuthClientSecretRequest false "Rotation options"
+// @Success 200 {object} response.Response{data=model.OAuthClientSecretResponse}
+// @Failure 404 {object} response.Response
+// @Router /auth/clients/{client_id}/secret [post]
+func (h *OAuthClientHandler) RotateSecret(c *gin.Context) {
+	clientID := c.Param("client_id")
+
+	var req model.RotateOAuthClientSecretRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.logger.Warn("Invalid request body", zap.Error(err))
+			response.Error(c, err)
+			return
+		}
+	}
+
+	actor := resolveActor(c)
+	client, secret, err := h.service.RotateSecret(c.Request.Context(), clientID, &req, actor)
+	if err != nil {
+		h.logger.Error("Failed to rotate client secret", zap.String("client_id", clientID), zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+
+	h.logger.Info("OAuth client secret rotated", zap.String("client_id", clientID))
+	h.trackAudit(c, audit.Event{
+		Actor:        actor,
+		Action:       "oauth_client.secret_rotated",
+		ResourceType: "oauth_client",
+		ResourceID:   clientID,
+		Description:  "OAuth client secret rotated",
+		Metadata: encodeMetadata(map[string]interface{}{
+			"secret_expires_at": client.SecretExpiresAt,
+		}),
+	})
+	response.Success(c, model.OAuthClientSecretResponse{
+		OAuthClientResponse: client.ToResponse(),
+		ClientSecret:        secret,
+	})
+}
+
+// revokeClientTokens invalidates tokens already issued to a client that can no longer authenticate.
+func (h *OAuthClientHandler) revokeClientTokens(c *gin.Context, clientID, actor string) {
+	ctx := c.Request.Context()
+	if h.revocations != nil {
+		if err := h.revocations.RevokeSubject(ctx, clientID); err != nil {
+			h.logger.Error("Failed to revoke client access tokens", zap.String("client_id", clientID), zap.Error(err))
+		}
+	}
+	if h.refreshTokens != nil {
+		if err := h.refreshTokens.RevokeSubject(ctx, clientID, actor); err != nil {
+			h.logger.Error("Failed to revoke client refresh tokens", zap.String("client_id", clientID), zap.Error(err))
+		}
+	}
+}
+
+func (h *OAuthClientHandler) trackAudit(c *gin.Context, event audit.Event) {
+	if h.auditClient == nil {
+		return
+	}
+	h.auditClient.Track(c.Request.Context(), event, c.GetHeader("Authorization"))
+}
@@ -0,0 +1,648 @@
+package handler
+
+import (
+	"context"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/audit"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/auth"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/errors"
+	"enterprise-microservice-system/services/user-service/internal/model"
+	"enterprise-microservice-system/services/user-service/internal/service"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"go.uber.org/zap"
+)
+
+// OAuth2 error codes (RFC 6749 section 5.2, RFC 7009 section 2.2.1).
+const (
+	OAuthErrInvalidRequest       = "invalid_request"
+	OAuthErrInvalidClient        = "invalid_client"
+	OAuthErrInvalidGrant         = "invalid_grant"
+	OAuthErrInvalidScope         = "invalid_scope"
+	OAuthErrUnsupportedGrantType = "unsupported_grant_type"
+	OAuthErrServerError          = "server_error"
+)
+
+// Token type hints accepted by introspection and revocation.
+const (
+	TokenTypeHintAccessToken  = "access_token"
+	TokenTypeHintRefreshToken = "refresh_token"
+)
+
+// Token type identifiers for the token exchange grant (RFC 8693 section 3).
+const (
+	TokenTypeAccessToken = "urn:ietf:params:oauth:token-type:access_token"
+	TokenTypeJWT         = "urn:ietf:params:oauth:token-type:jwt"
+)
+
+// OAuthTokenRequest is an RFC 6749 token request sent as
+// application/x-www-form-urlencoded. Client credentials may be sent in the
+// body or, preferably, with HTTP Basic authentication.
+type OAuthTokenRequest struct {
+	GrantType    string `form:"grant_type"`
+	Scope        string `form:"scope"`
+	RefreshToken string `form:"refresh_token"`
+	Code         string `form:"code"`
+	RedirectURI  string `form:"redirect_uri"`
+	CodeVerifier string `form:"code_verifier"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+
+	SubjectToken     string `form:"subject_token"`
+	SubjectTokenType string `form:"subject_token_type"`
+}
+
+// OAuthTokenResponse is an RFC 6749 section 5.1 access token response.
+type OAuthTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	Scope        string `json:"scope,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	// IssuedTokenType is only set by the token exchange grant (RFC 8693 section 2.2.1).
+	IssuedTokenType string `json:"issued_token_type,omitempty"`
+}
+
+// OAuthErrorResponse is an RFC 6749 section 5.2 error response.
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+// TokenIntrospectionRequest is an RFC 7662 introspection request.
+type TokenIntrospectionRequest struct {
+	Token         string `form:"token"`
+	TokenTypeHint string `form:"token_type_hint"`
+	ClientID      string `form:"client_id"`
+	ClientSecret  string `form:"client_secret"`
+}
+
+// TokenIntrospectionResponse is an RFC 7662 introspection response. Inactive
+// tokens only carry active=false.
+type TokenIntrospectionResponse struct {
+	Active    bool        `json:"active"`
+	Scope     string      `json:"scope,omitempty"`
+	ClientID  string      `json:"client_id,omitempty"`
+	Subject   string      `json:"sub,omitempty"`
+	TokenType string      `json:"token_type,omitempty"`
+	ExpiresAt int64       `json:"exp,omitempty"`
+	IssuedAt  int64       `json:"iat,omitempty"`
+	NotBefore int64       `json:"nbf,omitempty"`
+	Issuer    string      `json:"iss,omitempty"`
+	Audience  []string    `json:"aud,omitempty"`
+	JTI       string      `json:"jti,omitempty"`
+	ACR       string      `json:"acr,omitempty"`
+	AMR       []string    `json:"amr,omitempty"`
+	Act       *auth.Actor `json:"act,omitempty"`
+}
+
+// TokenRevocationRequest is an RFC 7009 revocation request.
+type TokenRevocationRequest struct {
+	Token         string `form:"token"`
+	TokenTypeHint string `form:"token_type_hint"`
+	ClientID      string `form:"client_id"`
+	ClientSecret  string `form:"client_secret"`
+}
+
+// OAuthToken issues tokens following RFC 6749 for the client_credentials,
+// refresh_token and authorization_code grants, and RFC 8693 token exchange.
+// @Summary OAuth2 token endpoint
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param grant_type formData string true "client_credentials, refresh_token, authorization_code or urn:ietf:params:oauth:grant-type:token-exchange"
+// @Param scope formData string false "Space-delimited scopes"
+// @Param refresh_token formData string false "Refresh token for the refresh_token grant"
+// @Param code formData string false "Authorization code for the authorization_code grant"
+// @Param redirect_uri formData string false "Redirect URI used in the authorization request"
+// @Param code_verifier formData string false "PKCE code verifier"
+// @Param subject_token formData string false "Access token of the user the client acts for (token exchange)"
+// @Param subject_token_type formData string false "urn:ietf:params:oauth:token-type:access_token or urn:ietf:params:oauth:token-type:jwt"
+// @Param client_id formData string false "Client ID when not using HTTP Basic"
+// @Param client_secret formData string false "Client secret when not using HTTP Basic"
+// @Success 200 {object} OAuthTokenResponse
+// @Failure 400 {object} OAuthErrorResponse
+// @Failure 401 {object} OAuthErrorResponse
+// @Router /oauth/token [post]
+func (h *AuthHandler) OAuthToken(c *gin.Context) {
+	var req OAuthTokenRequest
+	if err := c.ShouldBindWith(&req, binding.FormPost); err != nil {
+		h.oauthError(c, http.StatusBadRequest, OAuthErrInvalidRequest, "malformed token request")
+		return
+	}
+
+	if req.GrantType == "" {
+		h.oauthError(c, http.StatusBadRequest, OAuthErrInvalidRequest, "grant_type is required")
+		return
+	}
+	if req.GrantType == GrantTypeAuthorizationCode {
+		h.exchangeAuthorizationCode(c, &req)
+		return
+	}
+	switch req.GrantType {
+	case GrantTypeClientCredentials, GrantTypeRefreshToken, GrantTypeTokenExchange:
+	default:
+		h.oauthError(c, http.StatusBadRequest, OAuthErrUnsupportedGrantType, "")
+		return
+	}
+
+	client, ok := h.authenticateClient(c, req.ClientID, req.ClientSecret)
+	if !ok {
+		return
+	}
+
+	requested := h.scopeToRoles(req.Scope)
+
+	if req.GrantType == GrantTypeRefreshToken {
+		h.oauthRefresh(c, client, req.RefreshToken, requested)
+		return
+	}
+	if req.GrantType == GrantTypeTokenExchange {
+		h.exchangeToken(c, client, &req, requested)
+		return
+	}
+
+	roles := client.RoleList()
+	if len(requested) > 0 {
+		if !rolesAllowed(requested, roles) {
+			h.oauthError(c, http.StatusBadRequest, OAuthErrInvalidScope, "requested scope is not allowed for this client")
+			return
+		}
+		roles = requested
+	}
+
+	// RFC 6749 section 4.4.3: no refresh token for the client_credentials grant.
+	payload, err := h.issueAccessToken(client.ClientID, roles, clientAMR, nil)
+	if err != nil {
+		h.oauthError(c, http.StatusInternalServerError, OAuthErrServerError, "")
+		return
+	}
+
+	h.writeOAuthToken(c, payload)
+	h.trackTokenIssued(c, client.ClientID, payload, nil, "auth.token.issued", "OAuth2 access token issued")
+}
+
+// oauthRefresh rotates a refresh token presented by the client it was issued to.
+func (h *AuthHandler) oauthRefresh(c *gin.Context, client *model.OAuthClient, value string, requested []string) {
+	if h.refreshTokens == nil {
+		h.oauthError(c, http.StatusBadRequest, OAuthErrUnsupportedGrantType, "refresh tokens are not enabled")
+		return
+	}
+	if value == "" {
+		h.oauthError(c, http.StatusBadRequest, OAuthErrInvalidRequest, "refresh_token is required")
+		return
+	}
+
+	ctx := c.Request.Context()
+	current, err := h.refreshTokens.Inspect(ctx, value)
+	if err != nil || current.Subject != client.ClientID {
+		h.oauthError(c, http.StatusBadRequest, OAuthErrInvalidGrant, "invalid refresh token")
+		return
+	}
+
+	if len(requested) > 0 && !rolesAllowed(requested, current.RoleList()) {
+		h.oauthError(c, http.StatusBadRequest, OAuthErrInvalidScope, "requested scope exceeds the original grant")
+		return
+	}
+
+	issued, err := h.refreshTokens.Rotate(ctx, value)
+	if err != nil {
+		if err == service.ErrRefreshTokenReuse {
+			h.logger.Warn("Refresh token reuse detected, token family revoked", zap.String("client_id", client.ClientID))
+			h.trackAudit(c, audit.Event{
+				Actor:        client.ClientID,
+				Action:       "auth.refresh_token.reuse_detected",
+				ResourceType: "auth",
+				ResourceID:   current.FamilyID,
+				Description:  "Rotated refresh token presented again; token family revoked",
+			}, "")
+		}
+		if appErr, ok := err.(*errors.AppError); ok && appErr.Code == errors.ErrCodeUnauthorized {
+			h.oauthError(c, http.StatusBadRequest, OAuthErrInvalidGrant, appErr.Message)
+			return
+		}
+		h.oauthError(c, http.StatusInternalServerError, OAuthErrServerError, "")
+		return
+	}
+
+	// Rotation drops family roles the client no longer holds; narrow the
+	// requested scope to match.
+	roles := issued.Roles
+	if len(requested) > 0 {
+		roles = nil
+		for _, role := range requested {
+			if rolesAllowed([]string{role}, issued.Roles) {
+				roles = append(roles, role)
+			}
+		}
+	}
+	if len(roles) == 0 {
+		h.oauthError(c, http.StatusBadRequest, OAuthErrInvalidScope, "requested scope is no longer granted to the client")
+		return
+	}
+
+	payload, err := h.issueAccessToken(issued.Subject, roles, issued.AMR, issued)
+	if err != nil {
+		h.oauthError(c, http.StatusInternalServerError, OAuthErrServerError, "")
+		return
+	}
+
+	h.writeOAuthToken(c, payload)
+	h.trackTokenIssued(c, issued.Subject, payload, issued, "auth.token.refreshed", "OAuth2 access token refreshed")
+}
+
+// exchangeToken implements the RFC 8693 token exchange grant: the client trades
+// an access token it received from a user for a token to call other services on
+// that user's behalf. The issued token keeps the user as sub and names the
+// client in the act claim; it carries the client's roles, never the user's, so
+// the exchange attributes calls without widening what the client may do.
+func (h *AuthHandler) exchangeToken(c *gin.Context, client *model.OAuthClient, req *OAuthTokenRequest, requested []string) {
+	if req.SubjectToken == "" {
+		h.oauthError(c, http.StatusBadRequest, OAuthErrInvalidRequest, "subject_token is required")
+		return
+	}
+	if req.SubjectTokenType != TokenTypeAccessToken && req.SubjectTokenType != TokenTypeJWT {
+		h.oauthError(c, http.StatusBadRequest, OAuthErrInvalidRequest, "unsupported subject_token_type")
+		return
+	}
+
+	subject, err := auth.ParseToken(h.authConfig, req.SubjectToken)
+	if err != nil {
+		h.oauthError(c, http.StatusBadRequest, OAuthErrInvalidGrant, "invalid subject token")
+		return
+	}
+	if h.revocations != nil {
+		if revoked, _ := h.revocations.IsRevoked(c.Request.Context(), subject); revoked {
+			h.oauthError(c, http.StatusBadRequest, OAuthErrInvalidGrant, "subject token has been revoked")
+			return
+		}
+	}
+	// Only the caller's own token, or an impersonation token, may be exchanged;
+	// a token that is already the result of an exchange is not re-delegated.
+	if subject.Act != nil && !isImpersonation(subject) {
+		h.oauthError(c, http.StatusBadRequest, OAuthErrInvalidGrant, "delegated tokens cannot be exchanged")
+		return
+	}
+
+	roles := client.RoleList()
+	if len(requested) > 0 {
+		if !rolesAllowed(requested, roles) {
+			h.oauthError(c, http.StatusBadRequest, OAuthErrInvalidScope, "requested scope is not allowed for this client")
+			return
+		}
+		roles = requested
+	}
+
+	delegated := subject.DelegateTo(client.ClientID, roles)
+	token, err := auth.GenerateDelegatedToken(h.authConfig, delegated)
+	if err != nil {
+		h.logger.Error("Failed to generate delegated token", zap.Error(err))
+		h.oauthError(c, http.StatusInternalServerError, OAuthErrServerError, "")
+		return
+	}
+
+	// RFC 8693 section 2.2.1: no refresh token, the client exchanges again instead.
+	result := h.oauthTokenResponse(&TokenResponse{AccessToken: token, TokenType: "Bearer", Roles: roles})
+	result.IssuedTokenType = TokenTypeAccessToken
+	if remaining := int64(time.Until(delegated.ExpiresAt).Seconds()); !delegated.ExpiresAt.IsZero() && remaining < result.ExpiresIn {
+		result.ExpiresIn = remaining
+	}
+	setNoStore(c)
+	c.JSON(http.StatusOK, result)
+
+	h.trackAudit(c, audit.Event{
+		Actor:        subject.Subject,
+		ActedBy:      client.ClientID,
+		Action:       "auth.token.exchanged",
+		ResourceType: "auth",
+		ResourceID:   subject.Subject,
+		Description:  "Access token exchanged for a delegated token",
+		Metadata: encodeMetadata(map[string]interface{}{
+			"roles":       roles,
+			"actor_chain": append([]string{client.ClientID}, subject.Act.Chain()...),
+		}),
+	}, "")
+}
+
+// Introspect reports whether a token is active (RFC 7662). Callers authenticate as a registered client.
+// @Summary OAuth2 token introspection
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param token formData string true "Token to introspect"
+// @Param token_type_hint formData string false "access_token or refresh_token"
+// @Success 200 {object} TokenIntrospectionResponse
+// @Failure 400 {object} OAuthErrorResponse
+// @Failure 401 {object} OAuthErrorResponse
+// @Router /oauth/introspect [post]
+func (h *AuthHandler) Introspect(c *gin.Context) {
+	var req TokenIntrospectionRequest
+	if err := c.ShouldBindWith(&req, binding.FormPost); err != nil || req.Token == "" {
+		h.oauthError(c, http.StatusBadRequest, OAuthErrInvalidRequest, "token is required")
+		return
+	}
+
+	if _, ok := h.authenticateClient(c, req.ClientID, req.ClientSecret); !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	lookups := []func(context.Context, string) *TokenIntrospectionResponse{h.introspectAccessToken, h.introspectRefreshToken}
+	if req.TokenTypeHint == TokenTypeHintRefreshToken {
+		lookups[0], lookups[1] = lookups[1], lookups[0]
+	}
+
+	result := &TokenIntrospectionResponse{Active: false}
+	for _, lookup := range lookups {
+		if found := lookup(ctx, req.Token); found != nil {
+			result = found
+			break
+		}
+	}
+
+	setNoStore(c)
+	c.JSON(http.StatusOK, result)
+}
+
+// OAuthRevoke revokes an access or refresh token owned by the calling client (RFC 7009).
+// Unknown, invalid or foreign tokens are ignored and still answered with 200.
+// @Summary OAuth2 token revocation
+// @Tags oauth
+// @Accept x-www-form-urlencoded
+// @Param token formData string true "Token to revoke"
+// @Param token_type_hint formData string false "access_token or refresh_token"
+// @Success 200
+// @Failure 400 {object} OAuthErrorResponse
+// @Failure 401 {object} OAuthErrorResponse
+// @Router /oauth/revoke [post]
+func (h *AuthHandler) OAuthRevoke(c *gin.Context) {
+	var req TokenRevocationRequest
+	if err := c.ShouldBindWith(&req, binding.FormPost); err != nil || req.Token == "" {
+		h.oauthError(c, http.StatusBadRequest, OAuthErrInvalidRequest, "token is required")
+		return
+	}
+
+	client, ok := h.authenticateClient(c, req.ClientID, req.ClientSecret)
+	if !ok {
+		return
+	}
+
+	ctx := c.Request.Context()
+	revokers := []func(context.Context, *model.OAuthClient, string) (string, error){h.revokeAccessToken, h.revokeRefreshToken}
+	if req.TokenTypeHint == TokenTypeHintRefreshToken {
+		revokers[0], revokers[1] = revokers[1], revokers[0]
+	}
+
+	for _, revoke := range revokers {
+		resourceID, err := revoke(ctx, client, req.Token)
+		if err != nil {
+			h.logger.Error("Failed to revoke token", zap.String("client_id", client.ClientID), zap.Error(err))
+			h.oauthError(c, http.StatusServiceUnavailable, OAuthErrServerError, "")
+			return
+		}
+		if resourceID != "" {
+			h.trackAudit(c, audit.Event{
+				Actor:        client.ClientID,
+				Action:       "auth.token.revoked",
+				ResourceType: "auth",
+				ResourceID:   resourceID,
+				Description:  "Token revoked by client",
+			}, "")
+			break
+		}
+	}
+
+	setNoStore(c)
+	c.Status(http.StatusOK)
+}
+
+func (h *AuthHandler) introspectAccessToken(ctx context.Context, token string) *TokenIntrospectionResponse {
+	claims, err := auth.ParseToken(h.authConfig, token)
+	if err != nil {
+		return nil
+	}
+
+	if h.revocations != nil {
+		if revoked, _ := h.revocations.IsRevoked(ctx, claims); revoked {
+			return &TokenIntrospectionResponse{Active: false}
+		}
+	}
+
+	result := &TokenIntrospectionResponse{
+		Active:    true,
+		Scope:     h.rolesToScope(claims.Roles),
+		ClientID:  tokenHolder(claims),
+		Subject:   claims.Subject,
+		TokenType: "Bearer",
+		Issuer:    claims.Issuer,
+		Audience:  claims.Audience,
+		JTI:       claims.ID,
+		ACR:       claims.ACR,
+		AMR:       claims.AMR,
+		Act:       claims.Act,
+	}
+	if claims.ExpiresAt != nil {
+		result.ExpiresAt = claims.ExpiresAt.Unix()
+	}
+	if claims.IssuedAt != nil {
+		result.IssuedAt = claims.IssuedAt.Unix()
+	}
+	if claims.NotBefore != nil {
+		result.NotBefore = claims.NotBefore.Unix()
+	}
+	return result
+}
+
+func (h *AuthHandler) introspectRefreshToken(ctx context.Context, token string) *TokenIntrospectionResponse {
+	if h.refreshTokens == nil {
+		return nil
+	}
+
+	stored, err := h.refreshTokens.Inspect(ctx, token)
+	if err != nil {
+		return nil
+	}
+	if !stored.Usable(time.Now().UTC()) {
+		return &TokenIntrospectionResponse{Active: false}
+	}
+
+	return &TokenIntrospectionResponse{
+		Active:    true,
+		Scope:     h.rolesToScope(stored.RoleList()),
+		ClientID:  stored.Subject,
+		Subject:   stored.Subject,
+		ExpiresAt: stored.ExpiresAt.Unix(),
+		IssuedAt:  stored.CreatedAt.Unix(),
+	}
+}
+
+// tokenHolder returns the client an access token was issued to: the actor of a
+// delegated token, otherwise its subject.
+func tokenHolder(claims *auth.Claims) string {
+	if actor := claims.ActorSubject(); actor != "" {
+		return actor
+	}
+	return claims.Subject
+}
+
+// revokeAccessToken adds the token's jti to the revocation list. It returns the
+// revoked jti, or an empty string when the token is not a valid access token of client.
+func (h *AuthHandler) revokeAccessToken(ctx context.Context, client *model.OAuthClient, token string) (string, error) {
+	if h.revocations == nil {
+		return "", nil
+	}
+
+	claims, err := auth.ParseToken(h.authConfig, token)
+	if err != nil || tokenHolder(claims) != client.ClientID || claims.ID == "" {
+		return "", nil
+	}
+
+	return claims.ID, h.revocations.RevokeToken(ctx, claims.ID)
+}
+
+// revokeRefreshToken revokes the refresh token family. It returns the family ID,
+// or an empty string when the token is not a refresh token of client.
+func (h *AuthHandler) revokeRefreshToken(ctx context.Context, client *model.OAuthClient, token string) (string, error) {
+	if h.refreshTokens == nil {
+		return "", nil
+	}
+
+	stored, err := h.refreshTokens.Inspect(ctx, token)
+	if err != nil || stored.Subject != client.ClientID {
+		return "", nil
+	}
+
+	if _, err := h.refreshTokens.Revoke(ctx, token, client.ClientID); err != nil {
+		return "", err
+	}
+	return stored.FamilyID, nil
+}
+
+// authenticateClient authenticates the caller with HTTP Basic credentials
+// (RFC 6749 section 2.3.1) or client_id/client_secret form parameters. On
+// failure it writes the OAuth2 error response and returns false.
+func (h *AuthHandler) authenticateClient(c *gin.Context, formID, formSecret string) (*model.OAuthClient, bool) {
+	clientID, clientSecret, basic := c.Request.BasicAuth()
+	if basic {
+		if formID != "" || formSecret != "" {
+			h.oauthError(c, http.StatusBadRequest, OAuthErrInvalidRequest, "multiple client authentication methods")
+			return nil, false
+		}
+		var err error
+		if clientID, err = url.QueryUnescape(clientID); err == nil {
+			clientSecret, err = url.QueryUnescape(clientSecret)
+		}
+		if err != nil {
+			h.oauthError(c, http.StatusBadRequest, OAuthErrInvalidRequest, "malformed client credentials")
+			return nil, false
+		}
+	} else {
+		clientID, clientSecret = formID, formSecret
+	}
+
+	if clientID == "" || clientSecret == "" {
+		h.oauthError(c, http.StatusUnauthorized, OAuthErrInvalidClient, "client authentication required")
+		return nil, false
+	}
+
+	if h.lockedOut(c, clientID) {
+		h.oauthError(c, http.StatusTooManyRequests, OAuthErrInvalidClient, lockoutMessage)
+		return nil, false
+	}
+
+	client, err := h.clients.Authenticate(c.Request.Context(), clientID, clientSecret)
+	if err != nil {
+		h.logger.Warn("Client authentication failed", zap.String("client_id", clientID), zap.Error(err))
+		h.recordAuthFailure(c, clientID, err)
+		if appErr, ok := err.(*errors.AppError); ok && appErr.Code == errors.ErrCodeUnauthorized {
+			h.oauthError(c, http.StatusUnauthorized, OAuthErrInvalidClient, appErr.Message)
+		} else {
+			h.oauthError(c, http.StatusInternalServerError, OAuthErrServerError, "")
+		}
+		return nil, false
+	}
+	h.recordAuthSuccess(c, clientID)
+
+	return client, true
+}
+
+func (h *AuthHandler) writeOAuthToken(c *gin.Context, payload *TokenResponse) {
+	setNoStore(c)
+	c.JSON(http.StatusOK, h.oauthTokenResponse(payload))
+}
+
+func (h *AuthHandler) oauthTokenResponse(payload *TokenResponse) OAuthTokenResponse {
+	return OAuthTokenResponse{
+		AccessToken:  payload.AccessToken,
+		TokenType:    payload.TokenType,
+		ExpiresIn:    int64(h.authConfig.TokenTTL.Seconds()),
+		Scope:        h.rolesToScope(payload.Roles),
+		RefreshToken: payload.RefreshToken,
+		IDToken:      payload.IDToken,
+	}
+}
+
+func (h *AuthHandler) oauthError(c *gin.Context, status int, code, description string) {
+	if status == http.StatusUnauthorized {
+		c.Header("WWW-Authenticate", `Basic realm="user-service"`)
+	}
+	setNoStore(c)
+	c.AbortWithStatusJSON(status, OAuthErrorResponse{
+		Error:            code,
+		ErrorDescription: description,
+	})
+}
+
+// scopeToRoles maps a space-delimited scope string onto roles.
+func (h *AuthHandler) scopeToRoles(scope string) []string {
+	fields := strings.Fields(scope)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	roles := make([]string, 0, len(fields))
+	seen := make(map[string]struct{}, len(fields))
+	for _, value := range fields {
+		role := value
+		if mapped, ok := h.scopes[value]; ok {
+			role = mapped
+		}
+		if _, dup := seen[role]; dup {
+			continue
+		}
+		seen[role] = struct{}{}
+		roles = append(roles, role)
+	}
+	return roles
+}
+
+// rolesToScope renders roles as a space-delimited scope string using the
+// configured scope names where a mapping exists.
+func (h *AuthHandler) rolesToScope(roles []string) string {
+	names := make(map[string]string, len(h.scopes))
+	for scope, role := range h.scopes {
+		if existing, ok := names[role]; !ok || scope < existing {
+			names[role] = scope
+		}
+	}
+
+	scopes := make([]string, 0, len(roles))
+	for _, role := range roles {
+		if scope, ok := names[role]; ok {
+			scopes = append(scopes, scope)
+			continue
+		}
+		scopes = append(scopes, role)
+	}
+	return strings.Join(scopes, " ")
+}
+
+func setNoStore(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+}
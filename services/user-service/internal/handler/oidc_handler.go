@@ -0,0 +1,516 @@
+package handler
+
+import (
+	"github.com/RashadTanjim/enterprise-microservice-system/common/audit"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/auth"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/errors"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/response"
+	"enterprise-microservice-system/services/user-service/internal/model"
+	"enterprise-microservice-system/services/user-service/internal/service"
+	"html/template"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"go.uber.org/zap"
+)
+
+// OAuthErrUnsupportedResponseType is returned by the authorization endpoint
+// for anything but response_type=code (RFC 6749 section 4.1.2.1).
+const OAuthErrUnsupportedResponseType = "unsupported_response_type"
+
+// OpenIDConfiguration is the OpenID Connect discovery document.
+type OpenIDConfiguration struct {
+	Issuer                            string   `json:"issuer"`
+	AuthorizationEndpoint             string   `json:"authorization_endpoint"`
+	TokenEndpoint                     string   `json:"token_endpoint"`
+	UserInfoEndpoint                  string   `json:"userinfo_endpoint"`
+	JWKSURI                           string   `json:"jwks_uri"`
+	IntrospectionEndpoint             string   `json:"introspection_endpoint"`
+	RevocationEndpoint                string   `json:"revocation_endpoint"`
+	ScopesSupported                   []string `json:"scopes_supported"`
+	ResponseTypesSupported            []string `json:"response_types_supported"`
+	GrantTypesSupported               []string `json:"grant_types_supported"`
+	SubjectTypesSupported             []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported  []string `json:"id_token_signing_alg_values_supported"`
+	TokenEndpointAuthMethodsSupported []string `json:"token_endpoint_auth_methods_supported"`
+	CodeChallengeMethodsSupported     []string `json:"code_challenge_methods_supported"`
+	ClaimsSupported                   []string `json:"claims_supported"`
+}
+
+// AuthorizeRequest is an OAuth2 authorization request (RFC 6749 section 4.1.1)
+// with the PKCE challenge (RFC 7636) and OpenID Connect nonce.
+type AuthorizeRequest struct {
+	ResponseType        string `form:"response_type"`
+	ClientID            string `form:"client_id"`
+	RedirectURI         string `form:"redirect_uri"`
+	Scope               string `form:"scope"`
+	State               string `form:"state"`
+	Nonce               string `form:"nonce"`
+	CodeChallenge       string `form:"code_challenge"`
+	CodeChallengeMethod string `form:"code_challenge_method"`
+}
+
+// AuthorizeLoginRequest is the sign-in form posted back to the authorization endpoint.
+type AuthorizeLoginRequest struct {
+	AuthorizeRequest
+	Email    string `form:"email"`
+	Password string `form:"password"`
+	MFACode  string `form:"mfa_code"`
+}
+
+type authorizePage struct {
+	ClientName string
+	Request    *AuthorizeRequest
+	Email      string
+	Error      string
+}
+
+var authorizeTemplate = template.Must(template.New("authorize").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Sign in</title>
+<style>
+body { font-family: system-ui, sans-serif; background: #f4f5f7; display: flex; justify-content: center; padding-top: 10vh; }
+main { background: #fff; padding: 2rem; border-radius: 8px; width: 100%; max-width: 22rem; box-shadow: 0 1px 4px rgba(0, 0, 0, .1); }
+label { display: block; margin-top: 1rem; font-size: .9rem; }
+input { display: block; width: 100%; box-sizing: border-box; margin-top: .25rem; padding: .5rem; }
+button { margin-top: 1.5rem; width: 100%; padding: .6rem; }
+.error { color: #b00020; }
+</style>
+</head>
+<body>
+<main>
+{{if .Request}}
+<h1>Sign in to {{.ClientName}}</h1>
+{{if .Error}}<p class="error" role="alert">{{.Error}}</p>{{end}}
+<form method="post" action="/oauth/authorize">
+<input type="hidden" name="response_type" value="{{.Request.ResponseType}}">
+<input type="hidden" name="client_id" value="{{.Request.ClientID}}">
+<input type="hidden" name="redirect_uri" value="{{.Request.RedirectURI}}">
+<input type="hidden" name="scope" value="{{.Request.Scope}}">
+<input type="hidden" name="state" value="{{.Request.State}}">
+<input type="hidden" name="nonce" value="{{.Request.Nonce}}">
+<input type="hidden" name="code_challenge" value="{{.Request.CodeChallenge}}">
+<input type="hidden" name="code_challenge_method" value="{{.Request.CodeChallengeMethod}}">
+<label>Email<input type="email" name="email" value="{{.Email}}" autocomplete="username" required autofocus></label>
+<label>Password<input type="password" name="password" autocomplete="current-password" required></label>
+<label>Authentication code (if enabled)<input type="text" name="mfa_code" inputmode="numeric" autocomplete="one-time-code"></label>
+<button type="submit">Sign in</button>
+</form>
+{{else}}
+<h1>Sign-in request rejected</h1>
+<p class="error" role="alert">{{.Error}}</p>
+{{end}}
+</main>
+</body>
+</html>
+`))
+
+// OpenIDConfiguration publishes the OpenID Connect discovery document.
+// @Summary OpenID Connect discovery
+// @Tags oidc
+// @Produce json
+// @Success 200 {object} OpenIDConfiguration
+// @Router /.well-known/openid-configuration [get]
+func (h *AuthHandler) OpenIDConfiguration(c *gin.Context) {
+	if h.oidc == nil {
+		response.Error(c, errors.NewNotFound("openid configuration"))
+		return
+	}
+
+	alg, err := h.authConfig.SigningAlgorithm()
+	if err != nil {
+		h.logger.Error("Failed to resolve signing algorithm", zap.Error(err))
+		response.Error(c, errors.NewInternal("failed to build openid configuration", err))
+		return
+	}
+
+	scopes := []string{service.ScopeOpenID, service.ScopeProfile, service.ScopeEmail}
+	extra := make([]string, 0, len(h.scopes))
+	for scope := range h.scopes {
+		extra = append(extra, scope)
+	}
+	sort.Strings(extra)
+
+	issuer := h.oidc.Issuer()
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, OpenIDConfiguration{
+		Issuer:                            issuer,
+		AuthorizationEndpoint:             issuer + "/oauth/authorize",
+		TokenEndpoint:                     issuer + "/oauth/token",
+		UserInfoEndpoint:                  issuer + "/userinfo",
+		JWKSURI:                           issuer + "/.well-known/jwks.json",
+		IntrospectionEndpoint:             issuer + "/oauth/introspect",
+		RevocationEndpoint:                issuer + "/oauth/revoke",
+		ScopesSupported:                   append(scopes, extra...),
+		ResponseTypesSupported:            []string{"code"},
+		GrantTypesSupported:               []string{GrantTypeAuthorizationCode, GrantTypeClientCredentials, GrantTypeRefreshToken, GrantTypeTokenExchange},
+		SubjectTypesSupported:             []string{"public"},
+		IDTokenSigningAlgValuesSupported:  []string{alg},
+		TokenEndpointAuthMethodsSupported: []string{"client_secret_basic", "client_secret_post", "none"},
+		CodeChallengeMethodsSupported:     []string{service.CodeChallengeMethodS256},
+		ClaimsSupported:                   []string{"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce", "acr", "amr", "azp", "email", "email_verified", "name"},
+	})
+}
+
+// Authorize validates an authorization request and renders the sign-in form.
+// @Summary OAuth2 authorization endpoint
+// @Tags oidc
+// @Produce html
+// @Param response_type query string true "code"
+// @Param client_id query string true "Client ID"
+// @Param redirect_uri query string true "Registered redirect URI"
+// @Param scope query string false "Space-delimited scopes, e.g. openid profile email"
+// @Param state query string false "Opaque value returned to the client"
+// @Param nonce query string false "Value echoed in the ID token"
+// @Param code_challenge query string true "PKCE S256 code challenge"
+// @Param code_challenge_method query string true "S256"
+// @Success 200 {string} string "Sign-in form"
+// @Failure 302 {string} string "Redirect with an error to the client"
+// @Failure 400 {string} string "Unknown client or redirect URI"
+// @Router /oauth/authorize [get]
+func (h *AuthHandler) Authorize(c *gin.Context) {
+	var req AuthorizeRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		h.renderAuthorize(c, http.StatusBadRequest, authorizePage{Error: "malformed authorization request"})
+		return
+	}
+
+	client, ok := h.validateAuthorize(c, &req)
+	if !ok {
+		return
+	}
+
+	h.renderAuthorize(c, http.StatusOK, authorizePage{ClientName: client.Name, Request: &req})
+}
+
+// AuthorizeLogin signs the user in from the authorization form and redirects
+// back to the client with an authorization code.
+// @Summary Sign in for an authorization request
+// @Tags oidc
+// @Accept x-www-form-urlencoded
+// @Produce html
+// @Param email formData string true "Email"
+// @Param password formData string true "Password"
+// @Param mfa_code formData string false "TOTP or recovery code once MFA is enabled"
+// @Success 302 {string} string "Redirect with the authorization code"
+// @Failure 401 {string} string "Sign-in form with an error"
+// @Failure 429 {string} string "Sign-in form with an error"
+// @Router /oauth/authorize [post]
+func (h *AuthHandler) AuthorizeLogin(c *gin.Context) {
+	var req AuthorizeLoginRequest
+	if err := c.ShouldBindWith(&req, binding.FormPost); err != nil {
+		h.renderAuthorize(c, http.StatusBadRequest, authorizePage{Error: "malformed authorization request"})
+		return
+	}
+
+	client, ok := h.validateAuthorize(c, &req.AuthorizeRequest)
+	if !ok {
+		return
+	}
+
+	page := authorizePage{ClientName: client.Name, Request: &req.AuthorizeRequest, Email: req.Email}
+	if h.credentials == nil {
+		page.Error = "password login is not enabled"
+		h.renderAuthorize(c, http.StatusBadRequest, page)
+		return
+	}
+
+	user, amr, err := h.authenticateUser(c, req.Email, req.Password, req.MFACode)
+	if err != nil {
+		status := http.StatusUnauthorized
+		page.Error = "sign-in failed"
+		if appErr, ok := err.(*errors.AppError); ok {
+			page.Error = appErr.Message
+			switch appErr.Code {
+			case errors.ErrCodeRateLimit:
+				status = http.StatusTooManyRequests
+			case errors.ErrCodeUnauthorized:
+			default:
+				status = http.StatusInternalServerError
+				page.Error = "sign-in is temporarily unavailable"
+			}
+		}
+		h.renderAuthorize(c, status, page)
+		return
+	}
+
+	code, err := h.oidc.CreateCode(c.Request.Context(), &service.AuthorizationRequest{
+		ClientID:      client.ClientID,
+		RedirectURI:   req.RedirectURI,
+		Scope:         req.Scope,
+		Nonce:         req.Nonce,
+		CodeChallenge: req.CodeChallenge,
+		UserID:        user.ID,
+		AMR:           amr,
+		AuthTime:      time.Now().UTC(),
+	})
+	if err != nil {
+		h.logger.Error("Failed to create authorization code", zap.String("client_id", client.ClientID), zap.Error(err))
+		h.redirectAuthorizeError(c, &req.AuthorizeRequest, OAuthErrServerError, "")
+		return
+	}
+
+	subject := userSubject(user.ID)
+	h.logger.Info("Authorization code issued", zap.String("client_id", client.ClientID), zap.Uint("user_id", user.ID))
+	h.trackAudit(c, audit.Event{
+		Actor:        subject,
+		Action:       "auth.authorize",
+		ResourceType: "oauth_client",
+		ResourceID:   client.ClientID,
+		Description:  "User signed in and authorized client",
+		Metadata: encodeMetadata(map[string]interface{}{
+			"scope":        req.Scope,
+			"redirect_uri": req.RedirectURI,
+			"amr":          amr,
+		}),
+	}, "")
+
+	h.redirectAuthorize(c, &req.AuthorizeRequest, url.Values{"code": {code}})
+}
+
+// validateAuthorize checks an authorization request. Errors about the client or
+// redirect URI are shown to the user; once the redirect URI is trusted, other
+// errors are returned to the client (RFC 6749 section 4.1.2.1).
+func (h *AuthHandler) validateAuthorize(c *gin.Context, req *AuthorizeRequest) (*model.OAuthClient, bool) {
+	if h.oidc == nil {
+		h.renderAuthorize(c, http.StatusNotFound, authorizePage{Error: "the authorization code flow is not enabled"})
+		return nil, false
+	}
+
+	client, err := h.clients.GetClient(c.Request.Context(), req.ClientID)
+	if err != nil || client.Status != model.OAuthClientStatusActive {
+		h.renderAuthorize(c, http.StatusBadRequest, authorizePage{Error: "unknown or disabled client"})
+		return nil, false
+	}
+	if req.RedirectURI == "" || !client.AllowsRedirect(req.RedirectURI) {
+		h.renderAuthorize(c, http.StatusBadRequest, authorizePage{Error: "redirect_uri is not registered for this client"})
+		return nil, false
+	}
+
+	if req.ResponseType != "code" {
+		h.redirectAuthorizeError(c, req, OAuthErrUnsupportedResponseType, "only response_type=code is supported")
+		return nil, false
+	}
+	if req.CodeChallenge == "" || req.CodeChallengeMethod != service.CodeChallengeMethodS256 {
+		h.redirectAuthorizeError(c, req, OAuthErrInvalidRequest, "PKCE with code_challenge_method=S256 is required")
+		return nil, false
+	}
+	if _, roles := h.splitScope(req.Scope); len(roles) > 0 && !rolesAllowed(roles, client.RoleList()) {
+		h.redirectAuthorizeError(c, req, OAuthErrInvalidScope, "requested scope is not allowed for this client")
+		return nil, false
+	}
+
+	return client, true
+}
+
+// exchangeAuthorizationCode redeems an authorization code for an access token
+// and, for openid requests, an ID token. No refresh token is issued.
+func (h *AuthHandler) exchangeAuthorizationCode(c *gin.Context, req *OAuthTokenRequest) {
+	if h.oidc == nil {
+		h.oauthError(c, http.StatusBadRequest, OAuthErrUnsupportedGrantType, "authorization_code grant is not enabled")
+		return
+	}
+
+	client, ok := h.authenticateCodeClient(c, req.ClientID, req.ClientSecret)
+	if !ok {
+		return
+	}
+
+	if req.Code == "" || req.RedirectURI == "" || req.CodeVerifier == "" {
+		h.oauthError(c, http.StatusBadRequest, OAuthErrInvalidRequest, "code, redirect_uri and code_verifier are required")
+		return
+	}
+
+	code, user, err := h.oidc.RedeemCode(c.Request.Context(), req.Code, client.ClientID, req.RedirectURI, req.CodeVerifier)
+	if err != nil {
+		h.logger.Warn("Authorization code rejected", zap.String("client_id", client.ClientID), zap.Error(err))
+		if appErr, ok := err.(*errors.AppError); ok && appErr.Code == errors.ErrCodeUnauthorized {
+			h.oauthError(c, http.StatusBadRequest, OAuthErrInvalidGrant, appErr.Message)
+			return
+		}
+		h.oauthError(c, http.StatusInternalServerError, OAuthErrServerError, "")
+		return
+	}
+
+	oidcScopes, requested := h.splitScope(code.Scope)
+	roles := grantedRoles(userRoles(user), client.RoleList(), requested)
+	if len(roles) == 0 {
+		h.oauthError(c, http.StatusBadRequest, OAuthErrInvalidScope, "the user has no roles this client may receive")
+		return
+	}
+
+	subject := userSubject(user.ID)
+	payload, err := h.issueAccessToken(subject, roles, code.AMRList(), nil)
+	if err != nil {
+		h.oauthError(c, http.StatusInternalServerError, OAuthErrServerError, "")
+		return
+	}
+
+	if containsScope(oidcScopes, service.ScopeOpenID) {
+		idToken := auth.IDToken{
+			Subject:  subject,
+			ClientID: client.ClientID,
+			Nonce:    code.Nonce,
+			AuthTime: code.AuthTime,
+			AMR:      code.AMRList(),
+		}
+		if containsScope(oidcScopes, service.ScopeEmail) {
+			idToken.Email = user.Email
+			idToken.EmailVerified = user.EmailVerified
+		}
+		if containsScope(oidcScopes, service.ScopeProfile) {
+			idToken.Name = user.Name
+		}
+
+		idConfig := h.authConfig
+		idConfig.Issuer = h.oidc.Issuer()
+		payload.IDToken, err = auth.GenerateIDToken(idConfig, idToken)
+		if err != nil {
+			h.logger.Error("Failed to generate id token", zap.Error(err))
+			h.oauthError(c, http.StatusInternalServerError, OAuthErrServerError, "")
+			return
+		}
+	}
+
+	h.writeOAuthToken(c, payload)
+	h.trackTokenIssued(c, subject, payload, nil, "auth.token.issued", "OAuth2 authorization code exchanged by "+client.ClientID)
+}
+
+// authenticateCodeClient authenticates the client redeeming an authorization
+// code. Public clients send only client_id and rely on PKCE.
+func (h *AuthHandler) authenticateCodeClient(c *gin.Context, formID, formSecret string) (*model.OAuthClient, bool) {
+	if _, _, basic := c.Request.BasicAuth(); basic || formSecret != "" {
+		return h.authenticateClient(c, formID, formSecret)
+	}
+
+	if formID != "" {
+		client, err := h.clients.GetClient(c.Request.Context(), formID)
+		if err == nil && client.Public && client.Status == model.OAuthClientStatusActive {
+			return client, true
+		}
+	}
+	h.oauthError(c, http.StatusUnauthorized, OAuthErrInvalidClient, "client authentication required")
+	return nil, false
+}
+
+// UserInfo returns the OpenID Connect claims of the user the access token was issued to.
+// @Summary OpenID Connect userinfo
+// @Tags oidc
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} model.UserInfoResponse
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Router /userinfo [get]
+func (h *AuthHandler) UserInfo(c *gin.Context) {
+	if h.oidc == nil {
+		response.Error(c, errors.NewNotFound("userinfo"))
+		return
+	}
+
+	userID, err := callerUserID(c, "userinfo is only available for user tokens")
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	info, err := h.oidc.UserInfo(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.Warn("Failed to load userinfo", zap.Uint("user_id", userID), zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+
+	setNoStore(c)
+	c.JSON(http.StatusOK, info)
+}
+
+func (h *AuthHandler) renderAuthorize(c *gin.Context, status int, page authorizePage) {
+	setNoStore(c)
+	c.Header("X-Frame-Options", "DENY")
+	c.Header("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'; frame-ancestors 'none'")
+	c.Header("Content-Type", "text/html; charset=utf-8")
+	c.Status(status)
+	if err := authorizeTemplate.Execute(c.Writer, page); err != nil {
+		h.logger.Error("Failed to render authorization page", zap.Error(err))
+	}
+	c.Abort()
+}
+
+func (h *AuthHandler) redirectAuthorizeError(c *gin.Context, req *AuthorizeRequest, code, description string) {
+	params := url.Values{"error": {code}}
+	if description != "" {
+		params.Set("error_description", description)
+	}
+	h.redirectAuthorize(c, req, params)
+}
+
+// redirectAuthorize sends the browser back to the client's registered redirect
+// URI with params and the original state.
+func (h *AuthHandler) redirectAuthorize(c *gin.Context, req *AuthorizeRequest, params url.Values) {
+	target, err := url.Parse(req.RedirectURI)
+	if err != nil {
+		h.renderAuthorize(c, http.StatusBadRequest, authorizePage{Error: "invalid redirect_uri"})
+		return
+	}
+
+	query := target.Query()
+	for key, values := range params {
+		query[key] = values
+	}
+	if req.State != "" {
+		query.Set("state", req.State)
+	}
+	target.RawQuery = query.Encode()
+
+	setNoStore(c)
+	c.Redirect(http.StatusFound, target.String())
+	c.Abort()
+}
+
+// splitScope separates OpenID Connect scopes from scopes that map to roles.
+func (h *AuthHandler) splitScope(scope string) ([]string, []string) {
+	var oidcScopes, roleScopes []string
+	for _, value := range strings.Fields(scope) {
+		switch value {
+		case service.ScopeOpenID, service.ScopeProfile, service.ScopeEmail:
+			oidcScopes = append(oidcScopes, value)
+		default:
+			roleScopes = append(roleScopes, value)
+		}
+	}
+	return oidcScopes, h.scopeToRoles(strings.Join(roleScopes, " "))
+}
+
+// grantedRoles returns the user's roles that the client may receive, narrowed
+// to the requested roles when any were requested.
+func grantedRoles(userRoles, clientRoles, requested []string) []string {
+	granted := make([]string, 0, len(userRoles))
+	for _, role := range userRoles {
+		if !rolesAllowed([]string{role}, clientRoles) {
+			continue
+		}
+		if len(requested) > 0 && !rolesAllowed([]string{role}, requested) {
+			continue
+		}
+		granted = append(granted, role)
+	}
+	return granted
+}
+
+func containsScope(scopes []string, scope string) bool {
+	for _, value := range scopes {
+		if value == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,368 @@
+package handler
+
+import (
+	"github.com/RashadTanjim/enterprise-microservice-system/common/audit"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/auth"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/errors"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/logger"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/middleware"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/response"
+	"enterprise-microservice-system/services/user-service/internal/model"
+	"enterprise-microservice-system/services/user-service/internal/service"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Login authenticates an end user by email and password, plus a TOTP or
+// recovery code once MFA is enabled, and issues a JWT whose subject is the user ID.
+// @Summary Log in with email and password
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param login body model.LoginRequest true "Login request"
+// @Success 200 {object} response.Response{data=TokenResponse}
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Router /auth/login [post]
+func (h *AuthHandler) Login(c *gin.Context) {
+	if h.credentials == nil {
+		response.Error(c, errors.NewBadRequest("password login is not enabled"))
+		return
+	}
+
+	var req model.LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Invalid login request", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+
+	user, amr, err := h.authenticateUser(c, req.Email, req.Password, req.MFACode)
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+
+	subject := userSubject(user.ID)
+	roles := userRoles(user)
+
+	var refreshToken *service.IssuedRefreshToken
+	if h.refreshTokens != nil {
+		issued, err := h.refreshTokens.Issue(c.Request.Context(), subject, roles, amr)
+		if err != nil {
+			h.logger.Error("Failed to issue refresh token", zap.Error(err))
+			response.Error(c, err)
+			return
+		}
+		refreshToken = issued
+	}
+
+	h.respondWithToken(c, subject, roles, amr, refreshToken, "auth.login", "User logged in")
+}
+
+// authenticateUser checks an end user's email and password and, once MFA is
+// enabled, their second factor. Failed passwords count towards the lockout of
+// "email:<email>" and the caller's IP, which is cleared only once the second
+// factor passes. It returns the user and the amr claim for the session; the
+// caller writes the error response.
+func (h *AuthHandler) authenticateUser(c *gin.Context, email, password, mfaCode string) (*model.User, []string, error) {
+	principal := emailPrincipal(email)
+	if h.lockedOut(c, principal) {
+		return nil, nil, errors.New(errors.ErrCodeRateLimit, lockoutMessage, nil)
+	}
+
+	user, err := h.credentials.Authenticate(c.Request.Context(), email, password)
+	if err != nil {
+		h.logger.Warn("User login failed", zap.Error(err))
+		h.recordAuthFailure(c, principal, err)
+		h.trackAudit(c, audit.Event{
+			Actor:        "anonymous",
+			Action:       "auth.login_failed",
+			ResourceType: "auth",
+			ResourceID:   "login",
+			Description:  "Password login failed",
+		}, "")
+		return nil, nil, err
+	}
+
+	amr, err := h.verifySecondFactor(c, user.ID, mfaCode)
+	if err != nil {
+		return nil, nil, err
+	}
+	h.recordAuthSuccess(c, principal)
+	return user, amr, nil
+}
+
+// verifySecondFactor checks the MFA code of a user whose password was accepted
+// and returns the amr claim for the session. Failed codes count towards the
+// lockout of "mfa:<user id>".
+func (h *AuthHandler) verifySecondFactor(c *gin.Context, userID uint, code string) ([]string, error) {
+	if h.mfa == nil {
+		return []string{auth.AMRPassword}, nil
+	}
+
+	ctx := c.Request.Context()
+	enabled, err := h.mfa.Enabled(ctx, userID)
+	if err != nil {
+		h.logger.Error("Failed to load mfa enrollment", zap.Uint("user_id", userID), zap.Error(err))
+		return nil, err
+	}
+	if !enabled {
+		return []string{auth.AMRPassword}, nil
+	}
+	if code == "" {
+		return nil, service.ErrMFACodeRequired
+	}
+
+	principal := mfaPrincipal(userID)
+	if h.lockedOut(c, principal) {
+		return nil, errors.New(errors.ErrCodeRateLimit, lockoutMessage, nil)
+	}
+
+	recovery, err := h.mfa.Verify(ctx, userID, code)
+	if err != nil {
+		h.logger.Warn("MFA verification failed", zap.Uint("user_id", userID), zap.Error(err))
+		h.recordAuthFailure(c, principal, err)
+		h.trackAudit(c, audit.Event{
+			Actor:        userSubject(userID),
+			Action:       "auth.mfa_failed",
+			ResourceType: "auth",
+			ResourceID:   userSubject(userID),
+			Description:  "MFA code rejected at login",
+		}, "")
+		return nil, err
+	}
+	h.recordAuthSuccess(c, principal)
+
+	if recovery {
+		h.trackAudit(c, audit.Event{
+			Actor:        userSubject(userID),
+			Action:       "auth.mfa.recovery_code_used",
+			ResourceType: "user",
+			ResourceID:   userSubject(userID),
+			Description:  "Recovery code redeemed at login",
+		}, "")
+		return []string{auth.AMRPassword, auth.AMRMFA}, nil
+	}
+	return []string{auth.AMRPassword, auth.AMROTP, auth.AMRMFA}, nil
+}
+
+// userRoles returns the roles carried by a user's tokens.
+func userRoles(user *model.User) []string {
+	if len(user.Roles) == 0 {
+		return []string{model.DefaultUserRole}
+	}
+	return user.Roles
+}
+
+// mfaPrincipal is the lockout key for a user's second factor.
+func mfaPrincipal(userID uint) string {
+	return "mfa:" + userSubject(userID)
+}
+
+// emailPrincipal is the lockout key for password logins to an email address,
+// whether or not a user has it.
+func emailPrincipal(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// passwordPrincipal is the lockout key for a signed-in user's current
+// password check.
+func passwordPrincipal(userID uint) string {
+	return "password:" + userSubject(userID)
+}
+
+// ChangePassword changes the password of the authenticated user and signs out
+// every existing session.
+// @Summary Change the current user's password
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param password body model.ChangePasswordRequest true "Password change"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Failure 403 {object} response.Response
+// @Router /auth/password [post]
+func (h *AuthHandler) ChangePassword(c *gin.Context) {
+	if h.credentials == nil {
+		response.Error(c, errors.NewBadRequest("password login is not enabled"))
+		return
+	}
+
+	userID, err := callerUserID(c, "only user accounts have passwords")
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	subject := userSubject(userID)
+
+	var req model.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Invalid change password request", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+
+	principal := passwordPrincipal(userID)
+	if h.lockedOut(c, principal) {
+		response.Error(c, errors.New(errors.ErrCodeRateLimit, lockoutMessage, nil))
+		return
+	}
+
+	if err := h.credentials.ChangePassword(c.Request.Context(), userID, req.CurrentPassword, req.NewPassword); err != nil {
+		h.logger.Warn("Failed to change password", zap.Uint("user_id", userID), zap.Error(err))
+		h.recordAuthFailure(c, principal, err)
+		response.Error(c, err)
+		return
+	}
+	h.recordAuthSuccess(c, principal)
+
+	h.revokeSessions(c, subject)
+
+	h.logger.Info("Password changed", zap.Uint("user_id", userID))
+	h.trackAudit(c, audit.Event{
+		Actor:        subject,
+		Action:       "auth.password.changed",
+		ResourceType: "user",
+		ResourceID:   subject,
+		Description:  "Password changed; existing sessions revoked",
+	}, c.GetHeader("Authorization"))
+	response.Success(c, gin.H{"message": "password changed successfully"})
+}
+
+// IssuePasswordReset creates a single-use password reset token for a user. The
+// token is returned to the admin for out-of-band delivery.
+// @Summary Issue a password reset token
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 201 {object} response.Response{data=model.PasswordResetTokenResponse}
+// @Failure 404 {object} response.Response
+// @Router /users/{id}/password-reset-token [post]
+func (h *AuthHandler) IssuePasswordReset(c *gin.Context) {
+	if h.credentials == nil {
+		response.Error(c, errors.NewBadRequest("password login is not enabled"))
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		h.logger.Warn("Invalid user ID", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+
+	actor := resolveActor(c)
+	token, err := h.credentials.IssueResetToken(c.Request.Context(), uint(id), actor)
+	if err != nil {
+		h.logger.Error("Failed to issue password reset token", zap.Uint64("user_id", id), zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+
+	h.logger.Info("Password reset token issued", zap.Uint64("user_id", id))
+	h.trackAudit(c, audit.Event{
+		Actor:        actor,
+		Action:       "auth.password_reset.issued",
+		ResourceType: "user",
+		ResourceID:   fmt.Sprintf("%d", id),
+		Description:  "Password reset token issued",
+		Metadata: encodeMetadata(map[string]interface{}{
+			"expires_at": token.ExpiresAt,
+		}),
+	}, c.GetHeader("Authorization"))
+	response.Created(c, token)
+}
+
+// ResetPassword redeems a password reset token and sets a new password.
+// @Summary Reset a password with a reset token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param reset body model.ResetPasswordRequest true "Password reset"
+// @Success 200 {object} response.Response
+// @Failure 400 {object} response.Response
+// @Failure 401 {object} response.Response
+// @Router /auth/password/reset [post]
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	if h.credentials == nil {
+		response.Error(c, errors.NewBadRequest("password login is not enabled"))
+		return
+	}
+
+	var req model.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.Warn("Invalid password reset request", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+
+	user, err := h.credentials.ResetPassword(c.Request.Context(), req.Token, req.NewPassword)
+	if err != nil {
+		h.logger.Warn("Password reset failed", zap.Error(err))
+		response.Error(c, err)
+		return
+	}
+
+	subject := userSubject(user.ID)
+	h.revokeSessions(c, subject)
+
+	h.logger.Info("Password reset completed", zap.Uint("user_id", user.ID))
+	h.trackAudit(c, audit.Event{
+		Actor:        subject,
+		Action:       "auth.password_reset.completed",
+		ResourceType: "user",
+		ResourceID:   subject,
+		Description:  "Password reset with reset token; existing sessions revoked",
+	}, "")
+	response.Success(c, gin.H{"message": "password reset successfully"})
+}
+
+// revokeSessions invalidates the subject's outstanding access and refresh tokens.
+func (h *AuthHandler) revokeSessions(c *gin.Context, subject string) {
+	revokeSubjectTokens(c, h.logger, h.revocations, h.refreshTokens, subject, subject)
+}
+
+// revokeSubjectTokens implements revokeSessions for any handler; revocations
+// and refreshTokens may be nil. Failures are logged, not returned.
+func revokeSubjectTokens(c *gin.Context, log *logger.Logger, revocations *auth.RevocationList, refreshTokens service.RefreshTokenService, subject, actor string) {
+	ctx := c.Request.Context()
+	if revocations != nil {
+		if err := revocations.RevokeSubject(ctx, subject); err != nil {
+			log.Error("Failed to revoke access tokens", zap.String("subject", subject), zap.Error(err))
+		}
+	}
+	if refreshTokens != nil {
+		if err := refreshTokens.RevokeSubject(ctx, subject, actor); err != nil {
+			log.Error("Failed to revoke refresh tokens", zap.String("subject", subject), zap.Error(err))
+		}
+	}
+}
+
+// callerUserID returns the ID of the user calling for themselves. Delegated
+// tokens are refused: neither an impersonating admin nor a service acting for
+// the user may manage the user's own credentials. notUser is the message for
+// callers that are not users.
+func callerUserID(c *gin.Context, notUser string) (uint, error) {
+	if actor, _ := middleware.GetAuthActor(c); actor != "" {
+		return 0, errors.New(errors.ErrCodeForbidden, "delegated tokens cannot be used for this request", nil)
+	}
+	subject, _ := middleware.GetAuthSubject(c)
+	userID, err := strconv.ParseUint(subject, 10, 32)
+	if err != nil {
+		return 0, errors.New(errors.ErrCodeForbidden, notUser, nil)
+	}
+	return uint(userID), nil
+}
+
+// userSubject formats a user ID as a token subject.
+func userSubject(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
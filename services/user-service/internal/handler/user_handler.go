@@ -2,6 +2,8 @@ package handler
 
 import (
 	"github.com/RashadTanjim/enterprise-microservice-system/common/audit"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/auth"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/errors"
 	"github.com/RashadTanjim/enterprise-microservice-system/common/logger"
 	"github.com/RashadTanjim/enterprise-microservice-system/common/middleware"
 	"github.com/RashadTanjim/enterprise-microservice-system/common/response"
@@ -17,17 +19,24 @@ import (
 
 // UserHandler handles HTTP requests for users
 type UserHandler struct {
-	service     service.UserService
-	auditClient *audit.Client
-	logger      *logger.Logger
+	service       service.UserService
+	credentials   service.CredentialService
+	refreshTokens service.RefreshTokenService
+	revocations   *auth.RevocationList
+	auditClient   *audit.Client
+	logger        *logger.Logger
 }
 
-// NewUserHandler creates a new user handler
-func NewUserHandler(service service.UserService, auditClient *audit.Client, logger *logger.Logger) *UserHandler {
+// NewUserHandler creates a new user handler. credentials may be nil, in which
+// case initial passwords are rejected; refreshTokens and revocations may be nil.
+func NewUserHandler(service service.UserService, credentials service.CredentialService, refreshTokens service.RefreshTokenService, revocations *auth.RevocationList, auditClient *audit.Client, logger *logger.Logger) *UserHandler {
 	return &UserHandler{
-		service:     service,
-		auditClient: auditClient,
-		logger:      logger,
+		service:       service,
+		credentials:   credentials,
+		refreshTokens: refreshTokens,
+		revocations:   revocations,
+		auditClient:   auditClient,
+		logger:        logger,
 	}
 }
 
@@ -50,6 +59,19 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		return
 	}
 
+	if req.Password != "" {
+		if h.credentials == nil {
+			response.Error(c, errors.NewBadRequest("password login is not enabled"))
+			return
+		}
+		hash, err := h.credentials.HashPassword(req.Password)
+		if err != nil {
+			response.Error(c, err)
+			return
+		}
+		req.PasswordHash = hash
+	}
+
 	actor := resolveActor(c)
 	user, err := h.service.CreateUser(c.Request.Context(), &req, actor)
 	if err != nil {
@@ -66,8 +88,10 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 		ResourceID:   fmt.Sprintf("%d", user.ID),
 		Description:  "User created",
 		Metadata: encodeMetadata(map[string]interface{}{
-			"email": user.Email,
-			"name":  user.Name,
+			"email":        user.Email,
+			"name":         user.Name,
+			"roles":        user.Roles,
+			"has_password": req.Password != "",
 		}),
 	})
 	response.Created(c, user)
@@ -134,6 +158,13 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
+	previous, err := h.service.GetUser(c.Request.Context(), uint(id))
+	if err != nil {
+		response.Error(c, err)
+		return
+	}
+	previousStatus, previousRoles := previous.Status, previous.Roles
+
 	actor := resolveActor(c)
 	user, err := h.service.UpdateUser(c.Request.Context(), uint(id), &req, actor)
 	if err != nil {
@@ -142,6 +173,11 @@ func (h *UserHandler) UpdateUser(c *gin.Context) {
 		return
 	}
 
+	// Sessions carry the status and roles they were issued with.
+	if user.Status != previousStatus || !sameRoles(user.Roles, previousRoles) {
+		h.revokeUserTokens(c, user.ID, actor)
+	}
+
 	h.logger.Info("User updated successfully", zap.Uint64("user_id", id))
 	h.trackAudit(c, audit.Event{
 		Actor:        actor,
@@ -180,6 +216,8 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 		return
 	}
 
+	h.revokeUserTokens(c, uint(id), actor)
+
 	h.logger.Info("User deleted successfully", zap.Uint64("user_id", id))
 	h.trackAudit(c, audit.Event{
 		Actor:        actor,
@@ -241,13 +279,25 @@ func (h *UserHandler) ListUsers(c *gin.Context) {
 	})
 }
 
+// resolveActor identifies the caller for created_by/updated_by and audit
+// events. Delegated and impersonation tokens record both identities, e.g. "42 via 1".
 func resolveActor(c *gin.Context) string {
-	if subject, ok := middleware.GetAuthSubject(c); ok && subject != "" {
-		return subject
+	if principal, ok := middleware.GetAuthPrincipal(c); ok && principal != "" {
+		return principal
 	}
 	return "system"
 }
 
+// revokeUserTokens invalidates the access and refresh tokens already issued to a user.
+func (h *UserHandler) revokeUserTokens(c *gin.Context, userID uint, actor string) {
+	revokeSubjectTokens(c, h.logger, h.revocations, h.refreshTokens, userSubject(userID), actor)
+}
+
+// sameRoles reports whether a and b hold the same roles, in any order.
+func sameRoles(a, b []string) bool {
+	return len(a) == len(b) && rolesAllowed(a, b) && rolesAllowed(b, a)
+}
+
 func (h *UserHandler) trackAudit(c *gin.Context, event audit.Event) {
 	if h.auditClient == nil {
 		return
@@ -0,0 +1,81 @@
+package model
+
+import "time"
+
+const (
+	APIKeyStatusActive  = "active"
+	APIKeyStatusRevoked = "revoked"
+)
+
+// APIKey is a long-lived credential for partners and services. The key ID is
+// public; only a SHA-256 hash of the secret part is stored.
+type APIKey struct {
+	ID         uint       `gorm:"primarykey" json:"id"`
+	KeyID      string     `gorm:"type:varchar(32);uniqueIndex;not null" json:"key_id"`
+	SecretHash string     `gorm:"type:varchar(64);not null" json:"-"`
+	Name       string     `gorm:"type:varchar(100);not null" json:"name"`
+	Owner      string     `gorm:"type:varchar(100);not null;index" json:"owner"`
+	Roles      []string   `gorm:"type:text;serializer:json" json:"roles"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	Status     string     `gorm:"type:varchar(20);not null;default:'active';index" json:"status"`
+	CreatedBy  string     `gorm:"type:varchar(100);not null;default:'system'" json:"created_by"`
+	UpdatedBy  string     `gorm:"type:varchar(100);not null;default:'system'" json:"updated_by"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// TableName overrides the default table name
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// Expired reports whether the key has passed its expiry.
+func (k *APIKey) Expired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+// CreatedAPIKeyResponse is returned when a key is created. The full key is only
+// ever shown in this response.
+type CreatedAPIKeyResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// CreateAPIKeyRequest represents the request to create an API key. Owner becomes
+// the subject of requests made with the key; use a user ID to scope it to that
+// user's orders.
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required,min=2,max=100"`
+	Owner     string     `json:"owner" binding:"required,max=100"`
+	Roles     []string   `json:"roles" binding:"required,min=1,dive,required"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// VerifyAPIKeyRequest represents a request to resolve an API key
+type VerifyAPIKeyRequest struct {
+	APIKey string `json:"api_key" binding:"required"`
+}
+
+// ListAPIKeysQuery represents query parameters for listing API keys
+type ListAPIKeysQuery struct {
+	Page     int     `form:"page" binding:"omitempty,min=1"`
+	PageSize int     `form:"page_size" binding:"omitempty,min=1,max=100"`
+	Owner    string  `form:"owner"`
+	Status   *string `form:"status" binding:"omitempty,oneof=active revoked"`
+}
+
+// ApplyDefaults applies default values to the query
+func (q *ListAPIKeysQuery) ApplyDefaults() {
+	if q.Page <= 0 {
+		q.Page = 1
+	}
+	if q.PageSize <= 0 {
+		q.PageSize = 10
+	}
+}
+
+// Offset calculates the offset for pagination
+func (q *ListAPIKeysQuery) Offset() int {
+	return (q.Page - 1) * q.PageSize
+}
@@ -0,0 +1,59 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	AuthorizationCodeStatusActive = "active"
+	AuthorizationCodeStatusUsed   = "used"
+)
+
+// AuthorizationCode is a short-lived, single-use OAuth2 authorization code
+// bound to a client, redirect URI and PKCE challenge. Only a SHA-256 hash of
+// the code is stored.
+type AuthorizationCode struct {
+	ID            uint      `gorm:"primarykey" json:"id"`
+	CodeHash      string    `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	ClientID      string    `gorm:"type:varchar(100);not null;index" json:"client_id"`
+	UserID        uint      `gorm:"not null;index" json:"user_id"`
+	RedirectURI   string    `gorm:"type:text;not null" json:"redirect_uri"`
+	Scope         string    `gorm:"type:text;not null;default:''" json:"scope"`
+	Nonce         string    `gorm:"type:varchar(255);not null;default:''" json:"-"`
+	CodeChallenge string    `gorm:"type:varchar(128);not null" json:"-"`
+	AMR           string    `gorm:"type:varchar(100);not null;default:''" json:"-"`
+	AuthTime      time.Time `gorm:"not null" json:"auth_time"`
+	ExpiresAt     time.Time `gorm:"not null" json:"expires_at"`
+	Status        string    `gorm:"type:varchar(20);not null;default:'active';index" json:"status"`
+	CreatedBy     string    `gorm:"type:varchar(100);not null;default:'system'" json:"created_by"`
+	UpdatedBy     string    `gorm:"type:varchar(100);not null;default:'system'" json:"updated_by"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// TableName overrides the default table name
+func (AuthorizationCode) TableName() string {
+	return "authorization_codes"
+}
+
+// AMRList returns the authentication methods of the login that created the code.
+func (c *AuthorizationCode) AMRList() []string {
+	if c.AMR == "" {
+		return nil
+	}
+	return strings.Split(c.AMR, ",")
+}
+
+// SetAMR stores authentication methods in their persisted comma-separated form.
+func (c *AuthorizationCode) SetAMR(amr []string) {
+	c.AMR = strings.Join(amr, ",")
+}
+
+// UserInfoResponse is the OpenID Connect userinfo response.
+type UserInfoResponse struct {
+	Subject       string `json:"sub"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	Name          string `json:"name"`
+}
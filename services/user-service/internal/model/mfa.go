@@ -0,0 +1,77 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	MFAStatusPending = "pending"
+	MFAStatusActive  = "active"
+)
+
+// UserMFA holds a user's TOTP enrollment. The secret is encrypted at rest and
+// recovery codes are stored as SHA-256 hashes. LastUsedStep records the last
+// accepted TOTP time step so a code cannot be replayed.
+type UserMFA struct {
+	ID            uint       `gorm:"primarykey" json:"id"`
+	UserID        uint       `gorm:"uniqueIndex;not null" json:"user_id"`
+	SecretCipher  string     `gorm:"type:text;not null" json:"-"`
+	RecoveryCodes string     `gorm:"type:text;not null;default:''" json:"-"`
+	LastUsedStep  int64      `gorm:"not null;default:0" json:"-"`
+	EnabledAt     *time.Time `json:"enabled_at,omitempty"`
+	Status        string     `gorm:"type:varchar(20);not null;default:'pending';index" json:"status"`
+	CreatedBy     string     `gorm:"type:varchar(100);not null;default:'system'" json:"created_by"`
+	UpdatedBy     string     `gorm:"type:varchar(100);not null;default:'system'" json:"updated_by"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// TableName overrides the default table name
+func (UserMFA) TableName() string {
+	return "user_mfa"
+}
+
+// RecoveryCodeHashes returns the hashes of the unused recovery codes.
+func (m *UserMFA) RecoveryCodeHashes() []string {
+	if m.RecoveryCodes == "" {
+		return nil
+	}
+	return strings.Split(m.RecoveryCodes, ",")
+}
+
+// SetRecoveryCodeHashes stores recovery code hashes in their persisted form.
+func (m *UserMFA) SetRecoveryCodeHashes(hashes []string) {
+	m.RecoveryCodes = strings.Join(hashes, ",")
+}
+
+// Active reports whether MFA has been confirmed and is enforced at login.
+func (m *UserMFA) Active() bool {
+	return m.Status == MFAStatusActive
+}
+
+// MFACodeRequest carries a TOTP code or, where accepted, a recovery code
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// TOTPEnrollmentResponse is returned when TOTP enrollment starts. The secret is
+// only shown here; add it to an authenticator app and confirm with a code.
+type TOTPEnrollmentResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+	Digits          int    `json:"digits"`
+	Period          int    `json:"period"`
+}
+
+// RecoveryCodesResponse returns freshly generated recovery codes once
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MFAStatusResponse describes a user's MFA enrollment
+type MFAStatusResponse struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabled_at,omitempty"`
+	RecoveryCodesRemaining int        `json:"recovery_codes_remaining"`
+}
@@ -0,0 +1,167 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	OAuthClientStatusActive   = "active"
+	OAuthClientStatusDisabled = "disabled"
+	OAuthClientStatusDeleted  = "deleted"
+)
+
+// OAuthClient is a registered API client allowed to obtain tokens. Only a
+// bcrypt hash of the client secret is stored. RedirectURIs lists the exact
+// callback URLs accepted by the authorization code flow; Public clients (e.g.
+// browser apps) redeem codes with PKCE alone instead of their secret.
+type OAuthClient struct {
+	ID              uint       `gorm:"primarykey" json:"id"`
+	ClientID        string     `gorm:"type:varchar(100);uniqueIndex;not null" json:"client_id"`
+	Name            string     `gorm:"type:varchar(100);not null" json:"name"`
+	SecretHash      string     `gorm:"type:varchar(100);not null" json:"-"`
+	AllowedRoles    string     `gorm:"type:text;not null;default:''" json:"-"`
+	RedirectURIs    string     `gorm:"type:text;not null;default:''" json:"-"`
+	Public          bool       `gorm:"not null;default:false" json:"public"`
+	SecretExpiresAt *time.Time `json:"secret_expires_at,omitempty"`
+	Status          string     `gorm:"type:varchar(20);not null;default:'active';index" json:"status"`
+	CreatedBy       string     `gorm:"type:varchar(100);not null;default:'system'" json:"created_by"`
+	UpdatedBy       string     `gorm:"type:varchar(100);not null;default:'system'" json:"updated_by"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// TableName overrides the default table name
+func (OAuthClient) TableName() string {
+	return "oauth_clients"
+}
+
+// RoleList returns the roles the client may request.
+func (c *OAuthClient) RoleList() []string {
+	if c.AllowedRoles == "" {
+		return nil
+	}
+	return strings.Split(c.AllowedRoles, ",")
+}
+
+// SetRoles stores roles in their persisted comma-separated form.
+func (c *OAuthClient) SetRoles(roles []string) {
+	c.AllowedRoles = strings.Join(roles, ",")
+}
+
+// RedirectURIList returns the registered redirect URIs.
+func (c *OAuthClient) RedirectURIList() []string {
+	if c.RedirectURIs == "" {
+		return nil
+	}
+	return strings.Split(c.RedirectURIs, ",")
+}
+
+// SetRedirectURIs stores redirect URIs in their persisted comma-separated form.
+func (c *OAuthClient) SetRedirectURIs(uris []string) {
+	c.RedirectURIs = strings.Join(uris, ",")
+}
+
+// AllowsRedirect reports whether uri exactly matches a registered redirect URI.
+func (c *OAuthClient) AllowsRedirect(uri string) bool {
+	for _, registered := range c.RedirectURIList() {
+		if registered == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// SecretExpired reports whether the client secret has passed its expiry.
+func (c *OAuthClient) SecretExpired(now time.Time) bool {
+	return c.SecretExpiresAt != nil && !now.Before(*c.SecretExpiresAt)
+}
+
+// OAuthClientResponse is the API representation of a client.
+type OAuthClientResponse struct {
+	ID              uint       `json:"id"`
+	ClientID        string     `json:"client_id"`
+	Name            string     `json:"name"`
+	AllowedRoles    []string   `json:"allowed_roles"`
+	RedirectURIs    []string   `json:"redirect_uris,omitempty"`
+	Public          bool       `json:"public"`
+	SecretExpiresAt *time.Time `json:"secret_expires_at,omitempty"`
+	Status          string     `json:"status"`
+	CreatedBy       string     `json:"created_by"`
+	UpdatedBy       string     `json:"updated_by"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// ToResponse converts the client into its API representation.
+func (c *OAuthClient) ToResponse() OAuthClientResponse {
+	return OAuthClientResponse{
+		ID:              c.ID,
+		ClientID:        c.ClientID,
+		Name:            c.Name,
+		AllowedRoles:    c.RoleList(),
+		RedirectURIs:    c.RedirectURIList(),
+		Public:          c.Public,
+		SecretExpiresAt: c.SecretExpiresAt,
+		Status:          c.Status,
+		CreatedBy:       c.CreatedBy,
+		UpdatedBy:       c.UpdatedBy,
+		CreatedAt:       c.CreatedAt,
+		UpdatedAt:       c.UpdatedAt,
+	}
+}
+
+// OAuthClientSecretResponse is returned when a secret is created or rotated.
+// The plaintext secret is only ever shown in this response.
+type OAuthClientSecretResponse struct {
+	OAuthClientResponse
+	ClientSecret string `json:"client_secret"`
+}
+
+// CreateOAuthClientRequest represents the request to register a client
+type CreateOAuthClientRequest struct {
+	ClientID        string     `json:"client_id" binding:"required,min=3,max=100"`
+	Name            string     `json:"name" binding:"required,min=2,max=100"`
+	AllowedRoles    []string   `json:"allowed_roles" binding:"required,min=1,dive,required"`
+	RedirectURIs    []string   `json:"redirect_uris" binding:"omitempty,dive,url"`
+	Public          bool       `json:"public"`
+	SecretExpiresAt *time.Time `json:"secret_expires_at"`
+}
+
+// UpdateOAuthClientRequest represents the request to update a client
+type UpdateOAuthClientRequest struct {
+	Name            *string    `json:"name" binding:"omitempty,min=2,max=100"`
+	AllowedRoles    []string   `json:"allowed_roles" binding:"omitempty,min=1,dive,required"`
+	RedirectURIs    []string   `json:"redirect_uris" binding:"omitempty,dive,url"`
+	Public          *bool      `json:"public"`
+	Status          *string    `json:"status" binding:"omitempty,oneof=active disabled"`
+	SecretExpiresAt *time.Time `json:"secret_expires_at"`
+}
+
+// RotateOAuthClientSecretRequest represents the request to rotate a client secret.
+// When SecretExpiresAt is omitted the configured default secret lifetime applies.
+type RotateOAuthClientSecretRequest struct {
+	SecretExpiresAt *time.Time `json:"secret_expires_at"`
+}
+
+// ListOAuthClientsQuery represents query parameters for listing clients
+type ListOAuthClientsQuery struct {
+	Page     int     `form:"page" binding:"omitempty,min=1"`
+	PageSize int     `form:"page_size" binding:"omitempty,min=1,max=100"`
+	Status   *string `form:"status" binding:"omitempty,oneof=active disabled deleted"`
+}
+
+// ApplyDefaults applies default values to the query
+func (q *ListOAuthClientsQuery) ApplyDefaults() {
+	if q.Page <= 0 {
+		q.Page = 1
+	}
+	if q.PageSize <= 0 {
+		q.PageSize = 10
+	}
+}
+
+// Offset calculates the offset for pagination
+func (q *ListOAuthClientsQuery) Offset() int {
+	return (q.Page - 1) * q.PageSize
+}
@@ -0,0 +1,28 @@
+package model
+
+import "time"
+
+const (
+	PasswordResetTokenStatusActive  = "active"
+	PasswordResetTokenStatusUsed    = "used"
+	PasswordResetTokenStatusRevoked = "revoked"
+)
+
+// PasswordResetToken is a single-use token that lets a user set a new
+// password. Only a SHA-256 hash of the token value is stored.
+type PasswordResetToken struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	TokenHash string    `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	UserID    uint      `gorm:"not null;index" json:"user_id"`
+	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
+	Status    string    `gorm:"type:varchar(20);not null;default:'active';index" json:"status"`
+	CreatedBy string    `gorm:"type:varchar(100);not null;default:'system'" json:"created_by"`
+	UpdatedBy string    `gorm:"type:varchar(100);not null;default:'system'" json:"updated_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName overrides the default table name
+func (PasswordResetToken) TableName() string {
+	return "password_reset_tokens"
+}
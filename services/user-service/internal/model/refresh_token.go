@@ -0,0 +1,68 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	RefreshTokenStatusActive  = "active"
+	RefreshTokenStatusRotated = "rotated"
+	RefreshTokenStatusRevoked = "revoked"
+)
+
+// RefreshToken is a persisted, single-use refresh token. Tokens issued by
+// rotating one another share a FamilyID so a replayed token can revoke the
+// whole chain, and a FamilyExpiresAt that rotation never extends. Only a
+// SHA-256 hash of the token value is stored.
+type RefreshToken struct {
+	ID              uint      `gorm:"primarykey" json:"id"`
+	TokenHash       string    `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"`
+	FamilyID        string    `gorm:"type:varchar(64);not null;index" json:"family_id"`
+	Subject         string    `gorm:"type:varchar(100);not null;index" json:"subject"`
+	Roles           string    `gorm:"type:text;not null;default:''" json:"-"`
+	AMR             string    `gorm:"type:varchar(100);not null;default:''" json:"-"`
+	ExpiresAt       time.Time `gorm:"not null" json:"expires_at"`
+	FamilyExpiresAt time.Time `gorm:"not null" json:"family_expires_at"`
+	Status          string    `gorm:"type:varchar(20);not null;default:'active';index" json:"status"`
+	CreatedBy       string    `gorm:"type:varchar(100);not null;default:'system'" json:"created_by"`
+	UpdatedBy       string    `gorm:"type:varchar(100);not null;default:'system'" json:"updated_by"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// TableName overrides the default table name
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+// RoleList returns the roles granted to the token family.
+func (t *RefreshToken) RoleList() []string {
+	if t.Roles == "" {
+		return nil
+	}
+	return strings.Split(t.Roles, ",")
+}
+
+// AMRList returns the authentication methods of the login that started the family.
+func (t *RefreshToken) AMRList() []string {
+	if t.AMR == "" {
+		return nil
+	}
+	return strings.Split(t.AMR, ",")
+}
+
+// SetAMR stores authentication methods in their persisted comma-separated form.
+func (t *RefreshToken) SetAMR(amr []string) {
+	t.AMR = strings.Join(amr, ",")
+}
+
+// Usable reports whether the token is active and not yet expired.
+func (t *RefreshToken) Usable(now time.Time) bool {
+	return t.Status == RefreshTokenStatusActive && now.Before(t.ExpiresAt)
+}
+
+// SetRoles stores roles in their persisted comma-separated form.
+func (t *RefreshToken) SetRoles(roles []string) {
+	t.Roles = strings.Join(roles, ",")
+}
@@ -8,17 +8,25 @@ const (
 	UserStatusDeleted  = "deleted"
 )
 
-// User represents a user in the system
+// DefaultUserRole is assigned to users created without explicit roles.
+const DefaultUserRole = "user"
+
+// User represents a user in the system. PasswordHash holds an argon2id or
+// bcrypt hash and is empty for users that cannot log in with a password.
 type User struct {
-	ID        uint      `gorm:"primarykey" json:"id"`
-	Email     string    `gorm:"uniqueIndex;not null" json:"email"`
-	Name      string    `gorm:"not null" json:"name"`
-	Age       int       `json:"age"`
-	Status    string    `gorm:"type:varchar(20);not null;default:'active';index" json:"status"`
-	CreatedBy string    `gorm:"type:varchar(100);not null;default:'system'" json:"created_by"`
-	UpdatedBy string    `gorm:"type:varchar(100);not null;default:'system'" json:"updated_by"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID                uint       `gorm:"primarykey" json:"id"`
+	Email             string     `gorm:"uniqueIndex;not null" json:"email"`
+	EmailVerified     bool       `gorm:"not null;default:false" json:"email_verified"`
+	Name              string     `gorm:"not null" json:"name"`
+	Age               int        `json:"age"`
+	Roles             []string   `gorm:"type:text;serializer:json" json:"roles"`
+	PasswordHash      string     `gorm:"type:varchar(255);not null;default:''" json:"-"`
+	PasswordChangedAt *time.Time `json:"password_changed_at,omitempty"`
+	Status            string     `gorm:"type:varchar(20);not null;default:'active';index" json:"status"`
+	CreatedBy         string     `gorm:"type:varchar(100);not null;default:'system'" json:"created_by"`
+	UpdatedBy         string     `gorm:"type:varchar(100);not null;default:'system'" json:"updated_by"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
 }
 
 // TableName overrides the default table name
@@ -26,18 +34,57 @@ func (User) TableName() string {
 	return "users"
 }
 
-// CreateUserRequest represents the request to create a user
+// CreateUserRequest represents the request to create a user. PasswordHash is
+// set by the handler from Password so the user and its password are stored in
+// a single insert.
 type CreateUserRequest struct {
-	Email string `json:"email" binding:"required,email"`
-	Name  string `json:"name" binding:"required,min=2,max=100"`
-	Age   int    `json:"age" binding:"required,min=1,max=150"`
+	Email        string   `json:"email" binding:"required,email"`
+	Name         string   `json:"name" binding:"required,min=2,max=100"`
+	Age          int      `json:"age" binding:"required,min=1,max=150"`
+	Roles        []string `json:"roles" binding:"omitempty,dive,required"`
+	Password     string   `json:"password"`
+	PasswordHash string   `json:"-"`
 }
 
 // UpdateUserRequest represents the request to update a user
 type UpdateUserRequest struct {
-	Name   *string `json:"name" binding:"omitempty,min=2,max=100"`
-	Age    *int    `json:"age" binding:"omitempty,min=1,max=150"`
-	Status *string `json:"status" binding:"omitempty,oneof=active inactive"`
+	Name          *string  `json:"name" binding:"omitempty,min=2,max=100"`
+	Age           *int     `json:"age" binding:"omitempty,min=1,max=150"`
+	Status        *string  `json:"status" binding:"omitempty,oneof=active inactive"`
+	Roles         []string `json:"roles" binding:"omitempty,min=1,dive,required"`
+	EmailVerified *bool    `json:"email_verified"`
+}
+
+// HasPassword reports whether the user can log in with a password.
+func (u *User) HasPassword() bool {
+	return u.PasswordHash != ""
+}
+
+// LoginRequest represents an end-user password login. MFACode is a TOTP code
+// or recovery code and is required once the user has enabled MFA.
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+	MFACode  string `json:"mfa_code"`
+}
+
+// ChangePasswordRequest represents a password change by the authenticated user
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// ResetPasswordRequest represents redeeming a password reset token
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// PasswordResetTokenResponse is returned to an admin issuing a reset token.
+// The token must be delivered to the user out of band.
+type PasswordResetTokenResponse struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
 }
 
 // ListUsersQuery represents query parameters for listing users
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"enterprise-microservice-system/services/user-service/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// APIKeyRepository defines the interface for API key persistence
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *model.APIKey) error
+	FindByKeyID(ctx context.Context, keyID string) (*model.APIKey, error)
+	List(ctx context.Context, query *model.ListAPIKeysQuery) ([]*model.APIKey, int64, error)
+	Revoke(ctx context.Context, keyID string, updatedBy string) error
+	TouchLastUsed(ctx context.Context, id uint, usedAt time.Time) error
+}
+
+// apiKeyRepository implements APIKeyRepository
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository creates a new API key repository
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+// Create stores a new key
+func (r *apiKeyRepository) Create(ctx context.Context, key *model.APIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+// FindByKeyID finds a key by its public key ID regardless of status
+func (r *apiKeyRepository) FindByKeyID(ctx context.Context, keyID string) (*model.APIKey, error) {
+	var key model.APIKey
+	err := r.db.WithContext(ctx).
+		Where("key_id = ?", keyID).
+		First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// List retrieves a paginated list of keys
+func (r *apiKeyRepository) List(ctx context.Context, query *model.ListAPIKeysQuery) ([]*model.APIKey, int64, error) {
+	var keys []*model.APIKey
+	var total int64
+
+	db := r.db.WithContext(ctx).Model(&model.APIKey{})
+
+	if query.Owner != "" {
+		db = db.Where("owner = ?", query.Owner)
+	}
+	if query.Status != nil {
+		db = db.Where("status = ?", *query.Status)
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := db.Offset(query.Offset()).
+		Limit(query.PageSize).
+		Order("created_at DESC").
+		Find(&keys).Error
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return keys, total, nil
+}
+
+// Revoke marks a key as revoked
+func (r *apiKeyRepository) Revoke(ctx context.Context, keyID string, updatedBy string) error {
+	if updatedBy == "" {
+		updatedBy = "system"
+	}
+
+	return r.db.WithContext(ctx).
+		Model(&model.APIKey{}).
+		Where("key_id = ?", keyID).
+		Updates(map[string]interface{}{
+			"status":     model.APIKeyStatusRevoked,
+			"updated_by": updatedBy,
+			"updated_at": time.Now().UTC(),
+		}).Error
+}
+
+// TouchLastUsed records when a key was last used without changing updated_at
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, id uint, usedAt time.Time) error {
+	return r.db.WithContext(ctx).
+		Model(&model.APIKey{}).
+		Where("id = ?", id).
+		UpdateColumn("last_used_at", usedAt).Error
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"enterprise-microservice-system/services/user-service/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// AuthorizationCodeRepository defines the interface for authorization code persistence
+type AuthorizationCodeRepository interface {
+	Create(ctx context.Context, code *model.AuthorizationCode) error
+	FindByHash(ctx context.Context, codeHash string) (*model.AuthorizationCode, error)
+	MarkUsed(ctx context.Context, id uint) (bool, error)
+}
+
+// authorizationCodeRepository implements AuthorizationCodeRepository
+type authorizationCodeRepository struct {
+	db *gorm.DB
+}
+
+// NewAuthorizationCodeRepository creates a new authorization code repository
+func NewAuthorizationCodeRepository(db *gorm.DB) AuthorizationCodeRepository {
+	return &authorizationCodeRepository{db: db}
+}
+
+// Create stores a new authorization code
+func (r *authorizationCodeRepository) Create(ctx context.Context, code *model.AuthorizationCode) error {
+	return r.db.WithContext(ctx).Create(code).Error
+}
+
+// FindByHash finds an authorization code by its hash regardless of status
+func (r *authorizationCodeRepository) FindByHash(ctx context.Context, codeHash string) (*model.AuthorizationCode, error) {
+	var code model.AuthorizationCode
+	err := r.db.WithContext(ctx).
+		Where("code_hash = ?", codeHash).
+		First(&code).Error
+	if err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+// MarkUsed atomically consumes an active code. It returns false when the code
+// was already redeemed.
+func (r *authorizationCodeRepository) MarkUsed(ctx context.Context, id uint) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&model.AuthorizationCode{}).
+		Where("id = ? AND status = ?", id, model.AuthorizationCodeStatusActive).
+		Updates(map[string]interface{}{
+			"status":     model.AuthorizationCodeStatusUsed,
+			"updated_at": time.Now().UTC(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"enterprise-microservice-system/services/user-service/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// MFARepository defines the interface for MFA enrollment persistence
+type MFARepository interface {
+	FindByUserID(ctx context.Context, userID uint) (*model.UserMFA, error)
+	Save(ctx context.Context, mfa *model.UserMFA) error
+	DeleteByUserID(ctx context.Context, userID uint) error
+	UseStep(ctx context.Context, id uint, step int64) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, id uint, current, next string) (bool, error)
+}
+
+// mfaRepository implements MFARepository
+type mfaRepository struct {
+	db *gorm.DB
+}
+
+// NewMFARepository creates a new MFA repository
+func NewMFARepository(db *gorm.DB) MFARepository {
+	return &mfaRepository{db: db}
+}
+
+// FindByUserID finds the enrollment of a user regardless of status
+func (r *mfaRepository) FindByUserID(ctx context.Context, userID uint) (*model.UserMFA, error) {
+	var mfa model.UserMFA
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		First(&mfa).Error
+	if err != nil {
+		return nil, err
+	}
+	return &mfa, nil
+}
+
+// Save creates or updates an enrollment
+func (r *mfaRepository) Save(ctx context.Context, mfa *model.UserMFA) error {
+	return r.db.WithContext(ctx).Save(mfa).Error
+}
+
+// DeleteByUserID removes a user's enrollment
+func (r *mfaRepository) DeleteByUserID(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Delete(&model.UserMFA{}).Error
+}
+
+// UseStep records a TOTP time step as used. It reports false when the step,
+// or a later one, was already used so concurrent replays are rejected.
+func (r *mfaRepository) UseStep(ctx context.Context, id uint, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&model.UserMFA{}).
+		Where("id = ? AND last_used_step < ?", id, step).
+		Updates(map[string]interface{}{
+			"last_used_step": step,
+			"updated_at":     time.Now().UTC(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// ReplaceRecoveryCodes swaps the stored recovery codes only if they still
+// match current, so a code cannot be redeemed twice concurrently.
+func (r *mfaRepository) ReplaceRecoveryCodes(ctx context.Context, id uint, current, next string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&model.UserMFA{}).
+		Where("id = ? AND recovery_codes = ?", id, current).
+		Updates(map[string]interface{}{
+			"recovery_codes": next,
+			"updated_at":     time.Now().UTC(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
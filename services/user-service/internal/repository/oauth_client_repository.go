@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"enterprise-microservice-system/services/user-service/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// OAuthClientRepository defines the interface for OAuth client persistence
+type OAuthClientRepository interface {
+	Create(ctx context.Context, client *model.OAuthClient) error
+	FindByClientID(ctx context.Context, clientID string) (*model.OAuthClient, error)
+	Update(ctx context.Context, client *model.OAuthClient) error
+	Delete(ctx context.Context, clientID string, updatedBy string) error
+	List(ctx context.Context, query *model.ListOAuthClientsQuery) ([]*model.OAuthClient, int64, error)
+}
+
+// oauthClientRepository implements OAuthClientRepository
+type oauthClientRepository struct {
+	db *gorm.DB
+}
+
+// NewOAuthClientRepository creates a new OAuth client repository
+func NewOAuthClientRepository(db *gorm.DB) OAuthClientRepository {
+	return &oauthClientRepository{db: db}
+}
+
+// Create registers a new client
+func (r *oauthClientRepository) Create(ctx context.Context, client *model.OAuthClient) error {
+	return r.db.WithContext(ctx).Create(client).Error
+}
+
+// FindByClientID finds a client by its client_id regardless of status, since
+// deleted clients still reserve their identifier.
+func (r *oauthClientRepository) FindByClientID(ctx context.Context, clientID string) (*model.OAuthClient, error) {
+	var client model.OAuthClient
+	err := r.db.WithContext(ctx).
+		Where("client_id = ?", clientID).
+		First(&client).Error
+	if err != nil {
+		return nil, err
+	}
+	return &client, nil
+}
+
+// Update updates a client
+func (r *oauthClientRepository) Update(ctx context.Context, client *model.OAuthClient) error {
+	return r.db.WithContext(ctx).Save(client).Error
+}
+
+// Delete soft deletes a client
+func (r *oauthClientRepository) Delete(ctx context.Context, clientID string, updatedBy string) error {
+	if updatedBy == "" {
+		updatedBy = "system"
+	}
+
+	return r.db.WithContext(ctx).
+		Model(&model.OAuthClient{}).
+		Where("client_id = ?", clientID).
+		Updates(map[string]interface{}{
+			"status":     model.OAuthClientStatusDeleted,
+			"updated_by": updatedBy,
+			"updated_at": time.Now().UTC(),
+		}).Error
+}
+
+// List retrieves a paginated list of clients
+func (r *oauthClientRepository) List(ctx context.Context, query *model.ListOAuthClientsQuery) ([]*model.OAuthClient, int64, error) {
+	var clients []*model.OAuthClient
+	var total int64
+
+	db := r.db.WithContext(ctx).Model(&model.OAuthClient{})
+
+	if query.Status != nil {
+		db = db.Where("status = ?", *query.Status)
+	} else {
+		db = db.Where("status <> ?", model.OAuthClientStatusDeleted)
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := db.Offset(query.Offset()).
+		Limit(query.PageSize).
+		Order("created_at DESC").
+		Find(&clients).Error
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return clients, total, nil
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"enterprise-microservice-system/services/user-service/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// PasswordResetTokenRepository defines the interface for password reset token persistence
+type PasswordResetTokenRepository interface {
+	Create(ctx context.Context, token *model.PasswordResetToken) error
+	FindByHash(ctx context.Context, tokenHash string) (*model.PasswordResetToken, error)
+	MarkUsed(ctx context.Context, id uint) (bool, error)
+	RevokeForUser(ctx context.Context, userID uint, updatedBy string) error
+}
+
+// passwordResetTokenRepository implements PasswordResetTokenRepository
+type passwordResetTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewPasswordResetTokenRepository creates a new password reset token repository
+func NewPasswordResetTokenRepository(db *gorm.DB) PasswordResetTokenRepository {
+	return &passwordResetTokenRepository{db: db}
+}
+
+// Create stores a new reset token
+func (r *passwordResetTokenRepository) Create(ctx context.Context, token *model.PasswordResetToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+// FindByHash finds a reset token by its hash regardless of status
+func (r *passwordResetTokenRepository) FindByHash(ctx context.Context, tokenHash string) (*model.PasswordResetToken, error) {
+	var token model.PasswordResetToken
+	err := r.db.WithContext(ctx).
+		Where("token_hash = ?", tokenHash).
+		First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkUsed atomically consumes an active token. It returns false when the
+// token was already used or revoked.
+func (r *passwordResetTokenRepository) MarkUsed(ctx context.Context, id uint) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&model.PasswordResetToken{}).
+		Where("id = ? AND status = ?", id, model.PasswordResetTokenStatusActive).
+		Updates(map[string]interface{}{
+			"status":     model.PasswordResetTokenStatusUsed,
+			"updated_at": time.Now().UTC(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// RevokeForUser revokes every outstanding reset token of a user
+func (r *passwordResetTokenRepository) RevokeForUser(ctx context.Context, userID uint, updatedBy string) error {
+	if updatedBy == "" {
+		updatedBy = "system"
+	}
+
+	return r.db.WithContext(ctx).
+		Model(&model.PasswordResetToken{}).
+		Where("user_id = ? AND status = ?", userID, model.PasswordResetTokenStatusActive).
+		Updates(map[string]interface{}{
+			"status":     model.PasswordResetTokenStatusRevoked,
+			"updated_by": updatedBy,
+			"updated_at": time.Now().UTC(),
+		}).Error
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"enterprise-microservice-system/services/user-service/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// RefreshTokenRepository defines the interface for refresh token persistence
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *model.RefreshToken) error
+	FindByHash(ctx context.Context, tokenHash string) (*model.RefreshToken, error)
+	MarkRotated(ctx context.Context, id uint) (bool, error)
+	RevokeFamily(ctx context.Context, familyID string, updatedBy string) error
+	RevokeSubject(ctx context.Context, subject string, updatedBy string) error
+}
+
+// refreshTokenRepository implements RefreshTokenRepository
+type refreshTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewRefreshTokenRepository creates a new refresh token repository
+func NewRefreshTokenRepository(db *gorm.DB) RefreshTokenRepository {
+	return &refreshTokenRepository{db: db}
+}
+
+// Create stores a new refresh token
+func (r *refreshTokenRepository) Create(ctx context.Context, token *model.RefreshToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+// FindByHash finds a refresh token by its hash regardless of status
+func (r *refreshTokenRepository) FindByHash(ctx context.Context, tokenHash string) (*model.RefreshToken, error) {
+	var token model.RefreshToken
+	err := r.db.WithContext(ctx).
+		Where("token_hash = ?", tokenHash).
+		First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkRotated atomically moves an active token to rotated. It returns false when
+// the token was no longer active, which means a concurrent or repeated use.
+func (r *refreshTokenRepository) MarkRotated(ctx context.Context, id uint) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&model.RefreshToken{}).
+		Where("id = ? AND status = ?", id, model.RefreshTokenStatusActive).
+		Updates(map[string]interface{}{
+			"status":     model.RefreshTokenStatusRotated,
+			"updated_at": time.Now().UTC(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// RevokeFamily revokes every token in a family
+func (r *refreshTokenRepository) RevokeFamily(ctx context.Context, familyID string, updatedBy string) error {
+	if updatedBy == "" {
+		updatedBy = "system"
+	}
+
+	return r.db.WithContext(ctx).
+		Model(&model.RefreshToken{}).
+		Where("family_id = ? AND status <> ?", familyID, model.RefreshTokenStatusRevoked).
+		Updates(map[string]interface{}{
+			"status":     model.RefreshTokenStatusRevoked,
+			"updated_by": updatedBy,
+			"updated_at": time.Now().UTC(),
+		}).Error
+}
+
+// RevokeSubject revokes every refresh token issued to a subject
+func (r *refreshTokenRepository) RevokeSubject(ctx context.Context, subject string, updatedBy string) error {
+	if updatedBy == "" {
+		updatedBy = "system"
+	}
+
+	return r.db.WithContext(ctx).
+		Model(&model.RefreshToken{}).
+		Where("subject = ? AND status <> ?", subject, model.RefreshTokenStatusRevoked).
+		Updates(map[string]interface{}{
+			"status":     model.RefreshTokenStatusRevoked,
+			"updated_by": updatedBy,
+			"updated_at": time.Now().UTC(),
+		}).Error
+}
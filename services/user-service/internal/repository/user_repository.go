@@ -15,6 +15,7 @@ type UserRepository interface {
 	FindByID(ctx context.Context, id uint) (*model.User, error)
 	FindByEmail(ctx context.Context, email string) (*model.User, error)
 	Update(ctx context.Context, user *model.User) error
+	UpdatePassword(ctx context.Context, id uint, passwordHash string, updatedBy string) error
 	Delete(ctx context.Context, id uint, updatedBy string) error
 	List(ctx context.Context, query *model.ListUsersQuery) ([]*model.User, int64, error)
 }
@@ -63,6 +64,24 @@ func (r *userRepository) Update(ctx context.Context, user *model.User) error {
 	return r.db.WithContext(ctx).Save(user).Error
 }
 
+// UpdatePassword replaces a user's password hash
+func (r *userRepository) UpdatePassword(ctx context.Context, id uint, passwordHash string, updatedBy string) error {
+	if updatedBy == "" {
+		updatedBy = "system"
+	}
+
+	now := time.Now().UTC()
+	return r.db.WithContext(ctx).
+		Model(&model.User{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"password_hash":       passwordHash,
+			"password_changed_at": now,
+			"updated_by":          updatedBy,
+			"updated_at":          now,
+		}).Error
+}
+
 // Delete soft deletes a user
 func (r *userRepository) Delete(ctx context.Context, id uint, updatedBy string) error {
 	if updatedBy == "" {
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"crypto/subtle"
+	"strconv"
+	"time"
+
+	"enterprise-microservice-system/services/user-service/internal/model"
+	"enterprise-microservice-system/services/user-service/internal/repository"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/auth"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/errors"
+
+	"gorm.io/gorm"
+)
+
+// apiKeyTouchInterval limits how often last_used_at is written for a busy key.
+const apiKeyTouchInterval = time.Minute
+
+// APIKeyService defines API key management and verification. It implements
+// auth.APIKeyVerifier so user-service can accept keys directly.
+type APIKeyService interface {
+	CreateKey(ctx context.Context, req *model.CreateAPIKeyRequest, actor string) (*model.APIKey, string, error)
+	ListKeys(ctx context.Context, query *model.ListAPIKeysQuery) ([]*model.APIKey, int64, error)
+	RevokeKey(ctx context.Context, keyID string, actor string) (*model.APIKey, error)
+	VerifyAPIKey(ctx context.Context, key string) (*auth.APIKeyPrincipal, error)
+}
+
+// apiKeyService implements APIKeyService
+type apiKeyService struct {
+	repo    repository.APIKeyRepository
+	users   repository.UserRepository
+	clients repository.OAuthClientRepository
+}
+
+// NewAPIKeyService creates a new API key service. Keys owned by a user or a
+// registered client only verify while that owner is active; users and clients
+// may be nil to skip the check.
+func NewAPIKeyService(repo repository.APIKeyRepository, users repository.UserRepository, clients repository.OAuthClientRepository) APIKeyService {
+	return &apiKeyService{repo: repo, users: users, clients: clients}
+}
+
+// CreateKey generates a key and returns it once alongside the stored record
+func (s *apiKeyService) CreateKey(ctx context.Context, req *model.CreateAPIKeyRequest, actor string) (*model.APIKey, string, error) {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, "", errors.NewValidation("expires_at must be in the future")
+	}
+
+	keyID, value, secretHash, err := auth.GenerateAPIKey()
+	if err != nil {
+		return nil, "", errors.NewInternal("failed to generate api key", err)
+	}
+
+	if actor == "" {
+		actor = "system"
+	}
+
+	key := &model.APIKey{
+		KeyID:      keyID,
+		SecretHash: secretHash,
+		Name:       req.Name,
+		Owner:      req.Owner,
+		Roles:      req.Roles,
+		ExpiresAt:  req.ExpiresAt,
+		Status:     model.APIKeyStatusActive,
+		CreatedBy:  actor,
+		UpdatedBy:  actor,
+	}
+	if err := s.repo.Create(ctx, key); err != nil {
+		return nil, "", errors.NewInternal("failed to create api key", err)
+	}
+
+	return key, value, nil
+}
+
+// ListKeys retrieves a paginated list of keys
+func (s *apiKeyService) ListKeys(ctx context.Context, query *model.ListAPIKeysQuery) ([]*model.APIKey, int64, error) {
+	query.ApplyDefaults()
+
+	keys, total, err := s.repo.List(ctx, query)
+	if err != nil {
+		return nil, 0, errors.NewInternal("failed to list api keys", err)
+	}
+	return keys, total, nil
+}
+
+// RevokeKey permanently disables a key
+func (s *apiKeyService) RevokeKey(ctx context.Context, keyID string, actor string) (*model.APIKey, error) {
+	key, err := s.repo.FindByKeyID(ctx, keyID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFound("api key")
+		}
+		return nil, errors.NewInternal("failed to get api key", err)
+	}
+
+	if key.Status != model.APIKeyStatusRevoked {
+		if err := s.repo.Revoke(ctx, keyID, actor); err != nil {
+			return nil, errors.NewInternal("failed to revoke api key", err)
+		}
+		key.Status = model.APIKeyStatusRevoked
+	}
+	return key, nil
+}
+
+// VerifyAPIKey implements auth.APIKeyVerifier
+func (s *apiKeyService) VerifyAPIKey(ctx context.Context, value string) (*auth.APIKeyPrincipal, error) {
+	keyID, secret, err := auth.ParseAPIKey(value)
+	if err != nil {
+		return nil, auth.ErrInvalidAPIKey
+	}
+
+	key, err := s.repo.FindByKeyID(ctx, keyID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, auth.ErrInvalidAPIKey
+		}
+		return nil, errors.NewInternal("failed to get api key", err)
+	}
+
+	now := time.Now().UTC()
+	if subtle.ConstantTimeCompare([]byte(key.SecretHash), []byte(auth.HashAPIKeySecret(secret))) != 1 ||
+		key.Status != model.APIKeyStatusActive || key.Expired(now) {
+		return nil, auth.ErrInvalidAPIKey
+	}
+	active, err := s.ownerActive(ctx, key.Owner)
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		return nil, auth.ErrInvalidAPIKey
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		// Usage tracking is best-effort and must not fail authentication.
+		_ = s.repo.TouchLastUsed(ctx, key.ID, now)
+	}
+
+	return &auth.APIKeyPrincipal{
+		KeyID:     key.KeyID,
+		Subject:   key.Owner,
+		Roles:     key.Roles,
+		IssuedAt:  key.CreatedAt,
+		ExpiresAt: key.ExpiresAt,
+	}, nil
+}
+
+// ownerActive reports whether the owner of a key may still use it. A numeric
+// owner is a user ID and a missing or inactive user disables the key; an owner
+// matching a registered client follows that client's status. Other owners,
+// such as partner names, are not backed by an account and always pass.
+func (s *apiKeyService) ownerActive(ctx context.Context, owner string) (bool, error) {
+	if id, err := strconv.ParseUint(owner, 10, 64); err == nil {
+		if s.users == nil {
+			return true, nil
+		}
+		user, err := s.users.FindByID(ctx, uint(id))
+		if err == gorm.ErrRecordNotFound {
+			return false, nil
+		}
+		if err != nil {
+			return false, errors.NewInternal("failed to load api key owner", err)
+		}
+		return user.Status == model.UserStatusActive, nil
+	}
+
+	if s.clients == nil {
+		return true, nil
+	}
+	client, err := s.clients.FindByClientID(ctx, owner)
+	if err == gorm.ErrRecordNotFound {
+		return true, nil
+	}
+	if err != nil {
+		return false, errors.NewInternal("failed to load api key owner", err)
+	}
+	return client.Status == model.OAuthClientStatusActive, nil
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"enterprise-microservice-system/services/user-service/internal/model"
+	"enterprise-microservice-system/services/user-service/internal/repository"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/cache"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidUserCredentials is returned for an unknown email or a wrong password.
+var ErrInvalidUserCredentials = errors.New(errors.ErrCodeUnauthorized, "invalid email or password", nil)
+
+// CredentialService defines end-user password authentication and management
+type CredentialService interface {
+	Authenticate(ctx context.Context, email, password string) (*model.User, error)
+	ValidatePassword(password string) error
+	HashPassword(password string) (string, error)
+	SetPassword(ctx context.Context, userID uint, password string, actor string) error
+	ChangePassword(ctx context.Context, userID uint, current, next string) error
+	IssueResetToken(ctx context.Context, userID uint, actor string) (*model.PasswordResetTokenResponse, error)
+	ResetPassword(ctx context.Context, token, password string) (*model.User, error)
+}
+
+// CredentialConfig configures password hashing, policy and reset tokens
+type CredentialConfig struct {
+	Algorithm     string
+	Policy        PasswordPolicy
+	ResetTokenTTL time.Duration
+}
+
+// credentialService implements CredentialService
+type credentialService struct {
+	users       repository.UserRepository
+	resetTokens repository.PasswordResetTokenRepository
+	cache       cache.Cache
+	cfg         CredentialConfig
+}
+
+// NewCredentialService creates a new credential service. cacheClient is the
+// user cache, invalidated when a password changes.
+func NewCredentialService(users repository.UserRepository, resetTokens repository.PasswordResetTokenRepository, cacheClient cache.Cache, cfg CredentialConfig) CredentialService {
+	if cacheClient == nil {
+		cacheClient = cache.NewNoopCache()
+	}
+	if cfg.Algorithm == "" {
+		cfg.Algorithm = PasswordHashArgon2id
+	}
+	if cfg.ResetTokenTTL <= 0 {
+		cfg.ResetTokenTTL = 30 * time.Minute
+	}
+	if cfg.Algorithm == PasswordHashBcrypt && (cfg.Policy.MaxBytes <= 0 || cfg.Policy.MaxBytes > 72) {
+		// bcrypt only considers the first 72 bytes of a password.
+		cfg.Policy.MaxBytes = 72
+	}
+
+	return &credentialService{
+		users:       users,
+		resetTokens: resetTokens,
+		cache:       cacheClient,
+		cfg:         cfg,
+	}
+}
+
+// Authenticate verifies an email and password. Unknown emails are checked
+// against a dummy hash so response timing does not reveal registered users.
+func (s *credentialService) Authenticate(ctx context.Context, email, password string) (*model.User, error) {
+	user, err := s.users.FindByEmail(ctx, email)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, errors.NewInternal("failed to load user", err)
+	}
+
+	hash := dummyPasswordHash()
+	if user != nil && user.HasPassword() {
+		hash = user.PasswordHash
+	}
+
+	if !verifyPassword(hash, password) || user == nil || !user.HasPassword() {
+		return nil, ErrInvalidUserCredentials
+	}
+
+	if user.Status != model.UserStatusActive {
+		return nil, errors.New(errors.ErrCodeUnauthorized, "user account is not active", nil)
+	}
+
+	return user, nil
+}
+
+// ValidatePassword checks a password against the configured policy
+func (s *credentialService) ValidatePassword(password string) error {
+	return s.cfg.Policy.Validate(password)
+}
+
+// HashPassword checks a password against the configured policy and returns
+// the hash to store for it, for users created with an initial password.
+func (s *credentialService) HashPassword(password string) (string, error) {
+	if err := s.cfg.Policy.Validate(password); err != nil {
+		return "", err
+	}
+	hash, err := hashPassword(s.cfg.Algorithm, password)
+	if err != nil {
+		return "", errors.NewInternal("failed to hash password", err)
+	}
+	return hash, nil
+}
+
+// SetPassword sets a user's password without requiring the current one (admin provisioning)
+func (s *credentialService) SetPassword(ctx context.Context, userID uint, password string, actor string) error {
+	if err := s.cfg.Policy.Validate(password); err != nil {
+		return err
+	}
+	if _, err := s.findUser(ctx, userID); err != nil {
+		return err
+	}
+	return s.storePassword(ctx, userID, password, actor)
+}
+
+// ChangePassword replaces the password after verifying the current one
+func (s *credentialService) ChangePassword(ctx context.Context, userID uint, current, next string) error {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !user.HasPassword() || !verifyPassword(user.PasswordHash, current) {
+		return errors.New(errors.ErrCodeUnauthorized, "current password is incorrect", nil)
+	}
+	if err := s.cfg.Policy.Validate(next); err != nil {
+		return err
+	}
+
+	return s.storePassword(ctx, userID, next, fmt.Sprintf("%d", userID))
+}
+
+// IssueResetToken creates a single-use reset token, revoking any earlier ones
+func (s *credentialService) IssueResetToken(ctx context.Context, userID uint, actor string) (*model.PasswordResetTokenResponse, error) {
+	if _, err := s.findUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	if actor == "" {
+		actor = "system"
+	}
+
+	if err := s.resetTokens.RevokeForUser(ctx, userID, actor); err != nil {
+		return nil, errors.NewInternal("failed to revoke previous reset tokens", err)
+	}
+
+	value, err := generateRefreshTokenValue()
+	if err != nil {
+		return nil, errors.NewInternal("failed to generate reset token", err)
+	}
+
+	expiresAt := time.Now().UTC().Add(s.cfg.ResetTokenTTL)
+	token := &model.PasswordResetToken{
+		TokenHash: hashRefreshToken(value),
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+		Status:    model.PasswordResetTokenStatusActive,
+		CreatedBy: actor,
+		UpdatedBy: actor,
+	}
+	if err := s.resetTokens.Create(ctx, token); err != nil {
+		return nil, errors.NewInternal("failed to store reset token", err)
+	}
+
+	return &model.PasswordResetTokenResponse{
+		Token:     value,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// ResetPassword redeems a reset token and sets the new password
+func (s *credentialService) ResetPassword(ctx context.Context, value, password string) (*model.User, error) {
+	invalid := errors.New(errors.ErrCodeUnauthorized, "invalid or expired reset token", nil)
+
+	token, err := s.resetTokens.FindByHash(ctx, hashRefreshToken(value))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, invalid
+		}
+		return nil, errors.NewInternal("failed to load reset token", err)
+	}
+	if token.Status != model.PasswordResetTokenStatusActive || !time.Now().UTC().Before(token.ExpiresAt) {
+		return nil, invalid
+	}
+
+	if err := s.cfg.Policy.Validate(password); err != nil {
+		return nil, err
+	}
+
+	user, err := s.findUser(ctx, token.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	used, err := s.resetTokens.MarkUsed(ctx, token.ID)
+	if err != nil {
+		return nil, errors.NewInternal("failed to consume reset token", err)
+	}
+	if !used {
+		return nil, invalid
+	}
+
+	if err := s.storePassword(ctx, user.ID, password, fmt.Sprintf("%d", user.ID)); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *credentialService) findUser(ctx context.Context, userID uint) (*model.User, error) {
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFound("user")
+		}
+		return nil, errors.NewInternal("failed to get user", err)
+	}
+	return user, nil
+}
+
+func (s *credentialService) storePassword(ctx context.Context, userID uint, password string, actor string) error {
+	hash, err := hashPassword(s.cfg.Algorithm, password)
+	if err != nil {
+		return errors.NewInternal("failed to hash password", err)
+	}
+
+	if err := s.users.UpdatePassword(ctx, userID, hash, actor); err != nil {
+		return errors.NewInternal("failed to update password", err)
+	}
+
+	if err := s.resetTokens.RevokeForUser(ctx, userID, actor); err != nil {
+		return errors.NewInternal("failed to revoke reset tokens", err)
+	}
+
+	if s.cache.Enabled() {
+		_ = s.cache.Delete(ctx, userCacheKey(userID))
+		_ = s.cache.InvalidateTags(ctx, userListCacheTag)
+	}
+	return nil
+}
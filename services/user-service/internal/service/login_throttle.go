@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/RashadTanjim/enterprise-microservice-system/common/cache"
+)
+
+// Lockout scopes reported with each lockout.
+const (
+	LockoutScopePrincipal = "principal"
+	LockoutScopeIP        = "ip"
+)
+
+// lockoutSweepThreshold bounds the in-memory mirror before expired entries are swept.
+const lockoutSweepThreshold = 10000
+
+// LockoutPolicy configures brute-force protection for credential checks. A
+// zero failure threshold disables tracking for that scope.
+type LockoutPolicy struct {
+	// MaxClientFailures is the number of failed attempts for one principal (a
+	// client_id, or a user for MFA codes) before it is locked.
+	MaxClientFailures int
+	// MaxIPFailures is the number of failed attempts from one IP before it is locked.
+	MaxIPFailures int
+	// Window is how long failures are counted before the counter starts over.
+	Window time.Duration
+	// BaseLockout is the first lockout duration; each further lockout doubles it.
+	BaseLockout time.Duration
+	// MaxLockout caps the exponential backoff.
+	MaxLockout time.Duration
+}
+
+// Lockout describes a lockout triggered by a failed attempt.
+type Lockout struct {
+	Scope    string
+	Key      string
+	Failures int
+	Count    int
+	Duration time.Duration
+	Until    time.Time
+}
+
+// LoginThrottle counts failed credential attempts per principal and per IP and
+// locks either out temporarily once its threshold is reached. The principal is
+// the client_id, or "mfa:<user id>" for second-factor codes.
+type LoginThrottle interface {
+	// Check returns how long the caller must wait before trying again; zero means allowed.
+	Check(ctx context.Context, principal, ip string) (time.Duration, error)
+	// RecordFailure counts a failed attempt and returns any lockouts it triggered.
+	RecordFailure(ctx context.Context, principal, ip string) ([]Lockout, error)
+	// RecordSuccess clears the failure history of a principal.
+	RecordSuccess(ctx context.Context, principal string) error
+}
+
+type lockoutState struct {
+	Failures     int       `json:"failures"`
+	FirstFailure time.Time `json:"first_failure"`
+	Lockouts     int       `json:"lockouts"`
+	LockedUntil  time.Time `json:"locked_until"`
+}
+
+type lockoutEntry struct {
+	state     lockoutState
+	expiresAt time.Time
+}
+
+// loginThrottle implements LoginThrottle. State is shared across replicas
+// through Redis and mirrored in memory, which also serves as the fallback when
+// Redis is disabled or unreachable. Redis updates are read-modify-write, so
+// concurrent failures on different replicas may be undercounted slightly.
+type loginThrottle struct {
+	cache  cache.Cache
+	policy LockoutPolicy
+
+	mu      sync.Mutex
+	entries map[string]lockoutEntry
+}
+
+// NewLoginThrottle creates a login throttle. cacheClient may be nil or disabled
+// to keep state in memory only.
+func NewLoginThrottle(cacheClient cache.Cache, policy LockoutPolicy) LoginThrottle {
+	if cacheClient == nil {
+		cacheClient = cache.NewNoopCache()
+	}
+	if policy.Window <= 0 {
+		policy.Window = 15 * time.Minute
+	}
+	if policy.BaseLockout <= 0 {
+		policy.BaseLockout = time.Minute
+	}
+	if policy.MaxLockout < policy.BaseLockout {
+		policy.MaxLockout = policy.BaseLockout
+	}
+
+	return &loginThrottle{
+		cache:   cacheClient,
+		policy:  policy,
+		entries: map[string]lockoutEntry{},
+	}
+}
+
+// Check implements LoginThrottle. Redis errors are returned but the in-memory
+// view is still applied.
+func (t *loginThrottle) Check(ctx context.Context, principal, ip string) (time.Duration, error) {
+	now := time.Now()
+	var wait time.Duration
+	var firstErr error
+
+	for _, key := range t.keys(principal, ip) {
+		state, err := t.load(ctx, key.name)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+		if remaining := state.LockedUntil.Sub(now); remaining > wait {
+			wait = remaining
+		}
+	}
+
+	return wait, firstErr
+}
+
+// RecordFailure implements LoginThrottle
+func (t *loginThrottle) RecordFailure(ctx context.Context, principal, ip string) ([]Lockout, error) {
+	now := time.Now()
+	var lockouts []Lockout
+	var firstErr error
+
+	for _, key := range t.keys(principal, ip) {
+		state, err := t.load(ctx, key.name)
+		if err != nil && firstErr == nil {
+			firstErr = err
+		}
+
+		if state.Failures > 0 && now.Sub(state.FirstFailure) > t.policy.Window {
+			state.Failures = 0
+		}
+		if state.Failures == 0 {
+			state.FirstFailure = now
+		}
+		state.Failures++
+
+		if state.Failures >= key.limit {
+			state.Lockouts++
+			duration := t.lockoutDuration(state.Lockouts)
+			state.LockedUntil = now.Add(duration)
+			lockouts = append(lockouts, Lockout{
+				Scope:    key.scope,
+				Key:      key.value,
+				Failures: state.Failures,
+				Count:    state.Lockouts,
+				Duration: duration,
+				Until:    state.LockedUntil,
+			})
+			state.Failures = 0
+		}
+
+		if err := t.store(ctx, key.name, state); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return lockouts, firstErr
+}
+
+// RecordSuccess implements LoginThrottle
+func (t *loginThrottle) RecordSuccess(ctx context.Context, principal string) error {
+	if principal == "" || t.policy.MaxClientFailures <= 0 {
+		return nil
+	}
+
+	name := principalLockoutKey(principal)
+	t.mu.Lock()
+	delete(t.entries, name)
+	t.mu.Unlock()
+
+	if !t.cache.Enabled() {
+		return nil
+	}
+	return t.cache.Delete(ctx, name)
+}
+
+// lockoutDuration doubles the base lockout for each consecutive lockout.
+func (t *loginThrottle) lockoutDuration(count int) time.Duration {
+	duration := t.policy.BaseLockout
+	for i := 1; i < count; i++ {
+		duration *= 2
+		if duration >= t.policy.MaxLockout {
+			return t.policy.MaxLockout
+		}
+	}
+	return duration
+}
+
+// stateTTL keeps the lockout count long enough for backoff to escalate, then
+// forgets it after a quiet period.
+func (t *loginThrottle) stateTTL() time.Duration {
+	return t.policy.Window + t.policy.MaxLockout
+}
+
+type throttleKey struct {
+	scope string
+	value string
+	name  string
+	limit int
+}
+
+func (t *loginThrottle) keys(principal, ip string) []throttleKey {
+	keys := make([]throttleKey, 0, 2)
+	if principal != "" && t.policy.MaxClientFailures > 0 {
+		keys = append(keys, throttleKey{LockoutScopePrincipal, principal, principalLockoutKey(principal), t.policy.MaxClientFailures})
+	}
+	if ip != "" && t.policy.MaxIPFailures > 0 {
+		keys = append(keys, throttleKey{LockoutScopeIP, ip, ipLockoutKey(ip), t.policy.MaxIPFailures})
+	}
+	return keys
+}
+
+func (t *loginThrottle) load(ctx context.Context, name string) (lockoutState, error) {
+	if t.cache.Enabled() {
+		var state lockoutState
+		found, err := t.cache.Get(ctx, name, &state)
+		if err == nil {
+			if !found {
+				return lockoutState{}, nil
+			}
+			return state, nil
+		}
+		local, _ := t.loadLocal(name)
+		return local, err
+	}
+
+	state, _ := t.loadLocal(name)
+	return state, nil
+}
+
+func (t *loginThrottle) loadLocal(name string) (lockoutState, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, found := t.entries[name]
+	if !found {
+		return lockoutState{}, false
+	}
+	if !time.Now().Before(entry.expiresAt) {
+		delete(t.entries, name)
+		return lockoutState{}, false
+	}
+	return entry.state, true
+}
+
+func (t *loginThrottle) store(ctx context.Context, name string, state lockoutState) error {
+	ttl := t.stateTTL()
+	now := time.Now()
+
+	t.mu.Lock()
+	if len(t.entries) >= lockoutSweepThreshold {
+		for key, entry := range t.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(t.entries, key)
+			}
+		}
+	}
+	t.entries[name] = lockoutEntry{state: state, expiresAt: now.Add(ttl)}
+	t.mu.Unlock()
+
+	if !t.cache.Enabled() {
+		return nil
+	}
+	return t.cache.Set(ctx, name, state, ttl)
+}
+
+func principalLockoutKey(principal string) string {
+	return "lockout:principal:" + principal
+}
+
+func ipLockoutKey(ip string) string {
+	return "lockout:ip:" + ip
+}
@@ -0,0 +1,386 @@
+package service
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"enterprise-microservice-system/services/user-service/internal/model"
+	"enterprise-microservice-system/services/user-service/internal/repository"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidMFACode is returned for a wrong, expired or replayed code.
+var ErrInvalidMFACode = errors.New(errors.ErrCodeUnauthorized, "invalid mfa code", nil)
+
+// ErrMFACodeRequired is returned when a user with MFA enabled logs in without a code.
+var ErrMFACodeRequired = errors.New(errors.ErrCodeUnauthorized, "mfa_code is required", nil)
+
+// recoveryCodeAlphabet avoids characters that are easily confused when typed.
+const recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// MFAService defines TOTP enrollment and verification
+type MFAService interface {
+	Status(ctx context.Context, userID uint) (*model.MFAStatusResponse, error)
+	Enabled(ctx context.Context, userID uint) (bool, error)
+	EnrollTOTP(ctx context.Context, userID uint) (*model.TOTPEnrollmentResponse, error)
+	ConfirmTOTP(ctx context.Context, userID uint, code string) ([]string, error)
+	Disable(ctx context.Context, userID uint, code string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) ([]string, error)
+	// Verify accepts a TOTP code or a recovery code. It reports whether a
+	// recovery code was used; recovery codes are consumed.
+	Verify(ctx context.Context, userID uint, code string) (bool, error)
+}
+
+// MFAConfig configures TOTP enrollment
+type MFAConfig struct {
+	// Issuer is shown by authenticator apps next to the account name.
+	Issuer string
+	// EncryptionKey encrypts TOTP secrets at rest; changing it invalidates enrollments.
+	EncryptionKey string
+	// RecoveryCodes is the number of recovery codes generated per user.
+	RecoveryCodes int
+}
+
+// mfaService implements MFAService
+type mfaService struct {
+	repo  repository.MFARepository
+	users repository.UserRepository
+	aead  cipher.AEAD
+	cfg   MFAConfig
+}
+
+// NewMFAService creates a new MFA service
+func NewMFAService(repo repository.MFARepository, users repository.UserRepository, cfg MFAConfig) (MFAService, error) {
+	if cfg.EncryptionKey == "" {
+		return nil, errors.NewValidation("mfa encryption key is required")
+	}
+	if cfg.Issuer == "" {
+		cfg.Issuer = "enterprise-microservice-system"
+	}
+	if cfg.RecoveryCodes <= 0 {
+		cfg.RecoveryCodes = 10
+	}
+
+	key := sha256.Sum256([]byte(cfg.EncryptionKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mfaService{
+		repo:  repo,
+		users: users,
+		aead:  aead,
+		cfg:   cfg,
+	}, nil
+}
+
+// Status reports whether the user has MFA enabled
+func (s *mfaService) Status(ctx context.Context, userID uint) (*model.MFAStatusResponse, error) {
+	mfa, err := s.find(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa == nil || !mfa.Active() {
+		return &model.MFAStatusResponse{}, nil
+	}
+	return &model.MFAStatusResponse{
+		Enabled:                true,
+		EnabledAt:              mfa.EnabledAt,
+		RecoveryCodesRemaining: len(mfa.RecoveryCodeHashes()),
+	}, nil
+}
+
+// Enabled reports whether login requires a second factor
+func (s *mfaService) Enabled(ctx context.Context, userID uint) (bool, error) {
+	mfa, err := s.find(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return mfa != nil && mfa.Active(), nil
+}
+
+// EnrollTOTP generates a new secret. Enrollment stays pending until confirmed
+// with a valid code, so restarting it replaces an unconfirmed secret.
+func (s *mfaService) EnrollTOTP(ctx context.Context, userID uint) (*model.TOTPEnrollmentResponse, error) {
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFound("user")
+		}
+		return nil, errors.NewInternal("failed to get user", err)
+	}
+
+	mfa, err := s.find(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa != nil && mfa.Active() {
+		return nil, errors.NewConflict("mfa is already enabled")
+	}
+
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		return nil, errors.NewInternal("failed to generate totp secret", err)
+	}
+	sealed, err := s.seal(secret)
+	if err != nil {
+		return nil, errors.NewInternal("failed to encrypt totp secret", err)
+	}
+
+	actor := userSubject(userID)
+	if mfa == nil {
+		mfa = &model.UserMFA{UserID: userID, CreatedBy: actor}
+	}
+	mfa.SecretCipher = sealed
+	mfa.Status = model.MFAStatusPending
+	mfa.LastUsedStep = 0
+	mfa.SetRecoveryCodeHashes(nil)
+	mfa.UpdatedBy = actor
+
+	if err := s.repo.Save(ctx, mfa); err != nil {
+		return nil, errors.NewInternal("failed to store mfa enrollment", err)
+	}
+
+	return &model.TOTPEnrollmentResponse{
+		Secret:          secret,
+		ProvisioningURI: TOTPProvisioningURI(s.cfg.Issuer, user.Email, secret),
+		Digits:          TOTPDigits,
+		Period:          int(TOTPPeriod.Seconds()),
+	}, nil
+}
+
+// ConfirmTOTP activates a pending enrollment and returns the recovery codes
+func (s *mfaService) ConfirmTOTP(ctx context.Context, userID uint, code string) ([]string, error) {
+	mfa, err := s.find(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa == nil {
+		return nil, errors.NewBadRequest("mfa enrollment has not been started")
+	}
+	if mfa.Active() {
+		return nil, errors.NewConflict("mfa is already enabled")
+	}
+
+	secret, err := s.open(mfa.SecretCipher)
+	if err != nil {
+		return nil, errors.NewInternal("failed to decrypt totp secret", err)
+	}
+	step, ok := ValidateTOTP(secret, normalizeMFACode(code), time.Now())
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, hashes, err := s.generateRecoveryCodes()
+	if err != nil {
+		return nil, errors.NewInternal("failed to generate recovery codes", err)
+	}
+
+	now := time.Now().UTC()
+	mfa.Status = model.MFAStatusActive
+	mfa.EnabledAt = &now
+	mfa.LastUsedStep = step
+	mfa.SetRecoveryCodeHashes(hashes)
+	mfa.UpdatedBy = userSubject(userID)
+
+	if err := s.repo.Save(ctx, mfa); err != nil {
+		return nil, errors.NewInternal("failed to enable mfa", err)
+	}
+	return codes, nil
+}
+
+// Disable removes the enrollment after verifying a code
+func (s *mfaService) Disable(ctx context.Context, userID uint, code string) error {
+	if _, err := s.Verify(ctx, userID, code); err != nil {
+		return err
+	}
+	if err := s.repo.DeleteByUserID(ctx, userID); err != nil {
+		return errors.NewInternal("failed to disable mfa", err)
+	}
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces every recovery code after verifying a code
+func (s *mfaService) RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) ([]string, error) {
+	if _, err := s.Verify(ctx, userID, code); err != nil {
+		return nil, err
+	}
+
+	mfa, err := s.find(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa == nil {
+		return nil, errors.NewBadRequest("mfa is not enabled")
+	}
+
+	codes, hashes, err := s.generateRecoveryCodes()
+	if err != nil {
+		return nil, errors.NewInternal("failed to generate recovery codes", err)
+	}
+
+	mfa.SetRecoveryCodeHashes(hashes)
+	mfa.UpdatedBy = userSubject(userID)
+	if err := s.repo.Save(ctx, mfa); err != nil {
+		return nil, errors.NewInternal("failed to store recovery codes", err)
+	}
+	return codes, nil
+}
+
+// Verify checks a TOTP code, rejecting replays of an already used time step,
+// or redeems a recovery code.
+func (s *mfaService) Verify(ctx context.Context, userID uint, code string) (bool, error) {
+	mfa, err := s.find(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	if mfa == nil || !mfa.Active() {
+		return false, errors.NewBadRequest("mfa is not enabled")
+	}
+
+	code = normalizeMFACode(code)
+	if len(code) == TOTPDigits {
+		secret, err := s.open(mfa.SecretCipher)
+		if err != nil {
+			return false, errors.NewInternal("failed to decrypt totp secret", err)
+		}
+		step, ok := ValidateTOTP(secret, code, time.Now())
+		if !ok {
+			return false, ErrInvalidMFACode
+		}
+		used, err := s.repo.UseStep(ctx, mfa.ID, step)
+		if err != nil {
+			return false, errors.NewInternal("failed to record totp use", err)
+		}
+		if !used {
+			return false, ErrInvalidMFACode
+		}
+		return false, nil
+	}
+
+	hash := hashRecoveryCode(code)
+	hashes := mfa.RecoveryCodeHashes()
+	remaining := make([]string, 0, len(hashes))
+	found := false
+	for _, candidate := range hashes {
+		if !found && candidate == hash {
+			found = true
+			continue
+		}
+		remaining = append(remaining, candidate)
+	}
+	if !found {
+		return false, ErrInvalidMFACode
+	}
+
+	next := &model.UserMFA{}
+	next.SetRecoveryCodeHashes(remaining)
+	replaced, err := s.repo.ReplaceRecoveryCodes(ctx, mfa.ID, mfa.RecoveryCodes, next.RecoveryCodes)
+	if err != nil {
+		return false, errors.NewInternal("failed to redeem recovery code", err)
+	}
+	if !replaced {
+		return false, ErrInvalidMFACode
+	}
+	return true, nil
+}
+
+func (s *mfaService) find(ctx context.Context, userID uint) (*model.UserMFA, error) {
+	mfa, err := s.repo.FindByUserID(ctx, userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, errors.NewInternal("failed to load mfa enrollment", err)
+	}
+	return mfa, nil
+}
+
+func (s *mfaService) generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, s.cfg.RecoveryCodes)
+	hashes := make([]string, 0, s.cfg.RecoveryCodes)
+	for i := 0; i < s.cfg.RecoveryCodes; i++ {
+		buf, err := randomRecoveryChars(10)
+		if err != nil {
+			return nil, nil, err
+		}
+		code := buf[:5] + "-" + buf[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(normalizeMFACode(code)))
+	}
+	return codes, hashes, nil
+}
+
+// randomRecoveryChars draws n characters from the alphabet without modulo bias.
+func randomRecoveryChars(n int) (string, error) {
+	limit := byte(256 - 256%len(recoveryCodeAlphabet))
+	result := make([]byte, 0, n)
+	buf := make([]byte, n)
+	for len(result) < n {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if b < limit && len(result) < n {
+				result = append(result, recoveryCodeAlphabet[int(b)%len(recoveryCodeAlphabet)])
+			}
+		}
+	}
+	return string(result), nil
+}
+
+func (s *mfaService) seal(secret string) (string, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := s.aead.Seal(nonce, nonce, []byte(secret), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (s *mfaService) open(value string) (string, error) {
+	sealed, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < s.aead.NonceSize() {
+		return "", errors.NewInternal("malformed totp secret", nil)
+	}
+	nonce, ciphertext := sealed[:s.aead.NonceSize()], sealed[s.aead.NonceSize():]
+	plain, err := s.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
+
+// normalizeMFACode strips separators and case so codes can be typed loosely.
+func normalizeMFACode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+func hashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// userSubject formats a user ID as the actor recorded on MFA changes.
+func userSubject(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
@@ -0,0 +1,343 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"enterprise-microservice-system/services/user-service/internal/model"
+	"enterprise-microservice-system/services/user-service/internal/repository"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/errors"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// ErrInvalidClientCredentials is returned for an unknown client or a wrong secret.
+var ErrInvalidClientCredentials = errors.New(errors.ErrCodeUnauthorized, "invalid client credentials", nil)
+
+// OAuthClientService defines client registry management and authentication
+type OAuthClientService interface {
+	CreateClient(ctx context.Context, req *model.CreateOAuthClientRequest, actor string) (*model.OAuthClient, string, error)
+	GetClient(ctx context.Context, clientID string) (*model.OAuthClient, error)
+	UpdateClient(ctx context.Context, clientID string, req *model.UpdateOAuthClientRequest, actor string) (*model.OAuthClient, error)
+	DeleteClient(ctx context.Context, clientID string, actor string) error
+	ListClients(ctx context.Context, query *model.ListOAuthClientsQuery) ([]*model.OAuthClient, int64, error)
+	RotateSecret(ctx context.Context, clientID string, req *model.RotateOAuthClientSecretRequest, actor string) (*model.OAuthClient, string, error)
+	Authenticate(ctx context.Context, clientID, secret string) (*model.OAuthClient, error)
+	EnsureClient(ctx context.Context, clientID, secret string, roles []string) error
+}
+
+// oauthClientService implements OAuthClientService
+type oauthClientService struct {
+	repo      repository.OAuthClientRepository
+	secretTTL time.Duration
+}
+
+// NewOAuthClientService creates a new OAuth client service. secretTTL is the
+// default lifetime of generated secrets; zero means secrets do not expire.
+func NewOAuthClientService(repo repository.OAuthClientRepository, secretTTL time.Duration) OAuthClientService {
+	return &oauthClientService{
+		repo:      repo,
+		secretTTL: secretTTL,
+	}
+}
+
+// CreateClient registers a client and returns it with its generated plaintext secret
+func (s *oauthClientService) CreateClient(ctx context.Context, req *model.CreateOAuthClientRequest, actor string) (*model.OAuthClient, string, error) {
+	// Numeric subjects identify users, so client IDs must not look like user IDs.
+	if _, err := strconv.ParseUint(req.ClientID, 10, 64); err == nil {
+		return nil, "", errors.NewValidation("client_id must not be numeric")
+	}
+
+	if err := validateRedirectURIs(req.RedirectURIs); err != nil {
+		return nil, "", err
+	}
+
+	existing, err := s.repo.FindByClientID(ctx, req.ClientID)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, "", errors.NewInternal("failed to check client_id uniqueness", err)
+	}
+	if existing != nil {
+		return nil, "", errors.NewConflict("client_id already exists")
+	}
+
+	secret, err := generateClientSecret()
+	if err != nil {
+		return nil, "", errors.NewInternal("failed to generate client secret", err)
+	}
+	hash, err := hashClientSecret(secret)
+	if err != nil {
+		return nil, "", errors.NewInternal("failed to hash client secret", err)
+	}
+
+	if actor == "" {
+		actor = "system"
+	}
+
+	client := &model.OAuthClient{
+		ClientID:        req.ClientID,
+		Name:            req.Name,
+		SecretHash:      hash,
+		Public:          req.Public,
+		SecretExpiresAt: s.secretExpiry(req.SecretExpiresAt),
+		Status:          model.OAuthClientStatusActive,
+		CreatedBy:       actor,
+		UpdatedBy:       actor,
+	}
+	client.SetRoles(req.AllowedRoles)
+	client.SetRedirectURIs(req.RedirectURIs)
+
+	if err := s.repo.Create(ctx, client); err != nil {
+		return nil, "", errors.NewInternal("failed to create client", err)
+	}
+
+	return client, secret, nil
+}
+
+// GetClient retrieves a client by client_id
+func (s *oauthClientService) GetClient(ctx context.Context, clientID string) (*model.OAuthClient, error) {
+	client, err := s.repo.FindByClientID(ctx, clientID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFound("client")
+		}
+		return nil, errors.NewInternal("failed to get client", err)
+	}
+	if client.Status == model.OAuthClientStatusDeleted {
+		return nil, errors.NewNotFound("client")
+	}
+	return client, nil
+}
+
+// UpdateClient updates a client's name, allowed roles, redirect URIs, type,
+// status or secret expiry
+func (s *oauthClientService) UpdateClient(ctx context.Context, clientID string, req *model.UpdateOAuthClientRequest, actor string) (*model.OAuthClient, error) {
+	client, err := s.GetClient(ctx, clientID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateRedirectURIs(req.RedirectURIs); err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		client.Name = *req.Name
+	}
+	if len(req.AllowedRoles) > 0 {
+		client.SetRoles(req.AllowedRoles)
+	}
+	if req.RedirectURIs != nil {
+		client.SetRedirectURIs(req.RedirectURIs)
+	}
+	if req.Public != nil {
+		client.Public = *req.Public
+	}
+	if req.Status != nil {
+		client.Status = *req.Status
+	}
+	if req.SecretExpiresAt != nil {
+		client.SecretExpiresAt = req.SecretExpiresAt
+	}
+	if actor == "" {
+		actor = "system"
+	}
+	client.UpdatedBy = actor
+
+	if err := s.repo.Update(ctx, client); err != nil {
+		return nil, errors.NewInternal("failed to update client", err)
+	}
+
+	return client, nil
+}
+
+// DeleteClient deletes a client
+func (s *oauthClientService) DeleteClient(ctx context.Context, clientID string, actor string) error {
+	if _, err := s.GetClient(ctx, clientID); err != nil {
+		return err
+	}
+
+	if actor == "" {
+		actor = "system"
+	}
+
+	if err := s.repo.Delete(ctx, clientID, actor); err != nil {
+		return errors.NewInternal("failed to delete client", err)
+	}
+
+	return nil
+}
+
+// ListClients retrieves a paginated list of clients
+func (s *oauthClientService) ListClients(ctx context.Context, query *model.ListOAuthClientsQuery) ([]*model.OAuthClient, int64, error) {
+	query.ApplyDefaults()
+
+	clients, total, err := s.repo.List(ctx, query)
+	if err != nil {
+		return nil, 0, errors.NewInternal("failed to list clients", err)
+	}
+	return clients, total, nil
+}
+
+// RotateSecret replaces the client secret; the previous secret stops working immediately
+func (s *oauthClientService) RotateSecret(ctx context.Context, clientID string, req *model.RotateOAuthClientSecretRequest, actor string) (*model.OAuthClient, string, error) {
+	client, err := s.GetClient(ctx, clientID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	secret, err := generateClientSecret()
+	if err != nil {
+		return nil, "", errors.NewInternal("failed to generate client secret", err)
+	}
+	hash, err := hashClientSecret(secret)
+	if err != nil {
+		return nil, "", errors.NewInternal("failed to hash client secret", err)
+	}
+
+	var requested *time.Time
+	if req != nil {
+		requested = req.SecretExpiresAt
+	}
+
+	if actor == "" {
+		actor = "system"
+	}
+	client.SecretHash = hash
+	client.SecretExpiresAt = s.secretExpiry(requested)
+	client.UpdatedBy = actor
+
+	if err := s.repo.Update(ctx, client); err != nil {
+		return nil, "", errors.NewInternal("failed to rotate client secret", err)
+	}
+
+	return client, secret, nil
+}
+
+// Authenticate verifies client credentials. The secret comparison is constant
+// time, and unknown clients are checked against a dummy hash so response
+// timing does not reveal which client IDs exist.
+func (s *oauthClientService) Authenticate(ctx context.Context, clientID, secret string) (*model.OAuthClient, error) {
+	client, err := s.repo.FindByClientID(ctx, clientID)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, errors.NewInternal("failed to load client", err)
+	}
+
+	hash := dummySecretHash()
+	if client != nil {
+		hash = client.SecretHash
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(secret)) != nil || client == nil {
+		return nil, ErrInvalidClientCredentials
+	}
+
+	switch client.Status {
+	case model.OAuthClientStatusActive:
+	case model.OAuthClientStatusDisabled:
+		return nil, errors.New(errors.ErrCodeUnauthorized, "client is disabled", nil)
+	default:
+		return nil, ErrInvalidClientCredentials
+	}
+
+	if client.SecretExpired(time.Now().UTC()) {
+		return nil, errors.New(errors.ErrCodeUnauthorized, "client secret expired", nil)
+	}
+
+	return client, nil
+}
+
+// EnsureClient registers a bootstrap client with a known secret when it does
+// not exist yet. Existing clients are left untouched so rotated secrets and
+// admin changes are never overwritten by configuration.
+func (s *oauthClientService) EnsureClient(ctx context.Context, clientID, secret string, roles []string) error {
+	if clientID == "" || secret == "" {
+		return nil
+	}
+
+	_, err := s.repo.FindByClientID(ctx, clientID)
+	if err == nil {
+		return nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return errors.NewInternal("failed to load client", err)
+	}
+
+	hash, err := hashClientSecret(secret)
+	if err != nil {
+		return errors.NewInternal("failed to hash client secret", err)
+	}
+
+	client := &model.OAuthClient{
+		ClientID:   clientID,
+		Name:       clientID,
+		SecretHash: hash,
+		Status:     model.OAuthClientStatusActive,
+		CreatedBy:  "system",
+		UpdatedBy:  "system",
+	}
+	client.SetRoles(roles)
+
+	if err := s.repo.Create(ctx, client); err != nil {
+		return errors.NewInternal("failed to create bootstrap client", err)
+	}
+	return nil
+}
+
+func (s *oauthClientService) secretExpiry(requested *time.Time) *time.Time {
+	if requested != nil {
+		expiresAt := requested.UTC()
+		return &expiresAt
+	}
+	if s.secretTTL <= 0 {
+		return nil
+	}
+	expiresAt := time.Now().UTC().Add(s.secretTTL)
+	return &expiresAt
+}
+
+// validateRedirectURIs requires absolute URIs without a fragment (RFC 6749
+// section 3.1.2). Commas are rejected because URIs are stored comma-separated.
+func validateRedirectURIs(uris []string) error {
+	for _, uri := range uris {
+		parsed, err := url.Parse(uri)
+		if err != nil || !parsed.IsAbs() || parsed.Host == "" || parsed.Fragment != "" || strings.Contains(uri, ",") {
+			return errors.NewValidation("redirect_uris must be absolute URIs without a fragment or comma")
+		}
+	}
+	return nil
+}
+
+func generateClientSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashClientSecret(secret string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(secret), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     string
+)
+
+func dummySecretHash() string {
+	dummyHashOnce.Do(func() {
+		hash, _ := hashClientSecret("dummy-client-secret")
+		dummyHash = hash
+	})
+	return dummyHash
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"enterprise-microservice-system/services/user-service/internal/model"
+	"enterprise-microservice-system/services/user-service/internal/repository"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/errors"
+
+	"gorm.io/gorm"
+)
+
+// OpenID Connect scopes. They select ID token claims and are never mapped to roles.
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+)
+
+// CodeChallengeMethodS256 is the only PKCE method accepted (RFC 7636).
+const CodeChallengeMethodS256 = "S256"
+
+// ErrInvalidAuthorizationCode is returned for an unknown, expired, reused or
+// mismatched authorization code, or a wrong PKCE verifier.
+var ErrInvalidAuthorizationCode = errors.New(errors.ErrCodeUnauthorized, "invalid or expired authorization code", nil)
+
+// AuthorizationRequest describes an approved authorization request for which
+// a code is issued.
+type AuthorizationRequest struct {
+	ClientID      string
+	RedirectURI   string
+	Scope         string
+	Nonce         string
+	CodeChallenge string
+	UserID        uint
+	AMR           []string
+	AuthTime      time.Time
+}
+
+// OIDCConfig configures the OpenID Connect provider
+type OIDCConfig struct {
+	// Issuer is the public base URL of user-service, used as iss in ID tokens.
+	Issuer  string
+	CodeTTL time.Duration
+}
+
+// OIDCService defines the authorization code flow and user claims for OpenID Connect
+type OIDCService interface {
+	Issuer() string
+	CreateCode(ctx context.Context, req *AuthorizationRequest) (string, error)
+	RedeemCode(ctx context.Context, code, clientID, redirectURI, verifier string) (*model.AuthorizationCode, *model.User, error)
+	UserInfo(ctx context.Context, userID uint) (*model.UserInfoResponse, error)
+}
+
+// oidcService implements OIDCService
+type oidcService struct {
+	codes repository.AuthorizationCodeRepository
+	users repository.UserRepository
+	cfg   OIDCConfig
+}
+
+// NewOIDCService creates a new OpenID Connect service
+func NewOIDCService(codes repository.AuthorizationCodeRepository, users repository.UserRepository, cfg OIDCConfig) OIDCService {
+	cfg.Issuer = strings.TrimRight(cfg.Issuer, "/")
+	if cfg.CodeTTL <= 0 {
+		cfg.CodeTTL = time.Minute
+	}
+
+	return &oidcService{
+		codes: codes,
+		users: users,
+		cfg:   cfg,
+	}
+}
+
+// Issuer returns the issuer identifier without a trailing slash
+func (s *oidcService) Issuer() string {
+	return s.cfg.Issuer
+}
+
+// CreateCode stores a single-use code for an approved authorization request
+// and returns its plaintext value
+func (s *oidcService) CreateCode(ctx context.Context, req *AuthorizationRequest) (string, error) {
+	if !validCodeChallenge(req.CodeChallenge) {
+		return "", errors.NewValidation("code_challenge must be a base64url-encoded SHA-256 digest")
+	}
+
+	value, err := generateRefreshTokenValue()
+	if err != nil {
+		return "", errors.NewInternal("failed to generate authorization code", err)
+	}
+
+	subject := userSubject(req.UserID)
+	code := &model.AuthorizationCode{
+		CodeHash:      hashRefreshToken(value),
+		ClientID:      req.ClientID,
+		UserID:        req.UserID,
+		RedirectURI:   req.RedirectURI,
+		Scope:         req.Scope,
+		Nonce:         req.Nonce,
+		CodeChallenge: req.CodeChallenge,
+		AuthTime:      req.AuthTime.UTC(),
+		ExpiresAt:     time.Now().UTC().Add(s.cfg.CodeTTL),
+		Status:        model.AuthorizationCodeStatusActive,
+		CreatedBy:     subject,
+		UpdatedBy:     subject,
+	}
+	code.SetAMR(req.AMR)
+
+	if err := s.codes.Create(ctx, code); err != nil {
+		return "", errors.NewInternal("failed to store authorization code", err)
+	}
+	return value, nil
+}
+
+// RedeemCode consumes a code presented by clientID with the same redirect URI
+// and a PKCE verifier matching the original challenge, and returns the code
+// with its (still active) user
+func (s *oidcService) RedeemCode(ctx context.Context, value, clientID, redirectURI, verifier string) (*model.AuthorizationCode, *model.User, error) {
+	code, err := s.codes.FindByHash(ctx, hashRefreshToken(value))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil, ErrInvalidAuthorizationCode
+		}
+		return nil, nil, errors.NewInternal("failed to load authorization code", err)
+	}
+
+	if code.Status != model.AuthorizationCodeStatusActive || !time.Now().UTC().Before(code.ExpiresAt) {
+		return nil, nil, ErrInvalidAuthorizationCode
+	}
+	if code.ClientID != clientID || code.RedirectURI != redirectURI || !verifyCodeChallenge(verifier, code.CodeChallenge) {
+		return nil, nil, ErrInvalidAuthorizationCode
+	}
+
+	used, err := s.codes.MarkUsed(ctx, code.ID)
+	if err != nil {
+		return nil, nil, errors.NewInternal("failed to consume authorization code", err)
+	}
+	if !used {
+		return nil, nil, ErrInvalidAuthorizationCode
+	}
+
+	user, err := s.activeUser(ctx, code.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return code, user, nil
+}
+
+// UserInfo returns the standard claims of an active user
+func (s *oidcService) UserInfo(ctx context.Context, userID uint) (*model.UserInfoResponse, error) {
+	user, err := s.activeUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.UserInfoResponse{
+		Subject:       userSubject(user.ID),
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		Name:          user.Name,
+	}, nil
+}
+
+func (s *oidcService) activeUser(ctx context.Context, userID uint) (*model.User, error) {
+	user, err := s.users.FindByID(ctx, userID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New(errors.ErrCodeUnauthorized, "user not found", nil)
+		}
+		return nil, errors.NewInternal("failed to get user", err)
+	}
+	if user.Status != model.UserStatusActive {
+		return nil, errors.New(errors.ErrCodeUnauthorized, "user account is not active", nil)
+	}
+	return user, nil
+}
+
+// validCodeChallenge accepts the 43-character base64url encoding of a SHA-256 digest.
+func validCodeChallenge(challenge string) bool {
+	decoded, err := base64.RawURLEncoding.DecodeString(challenge)
+	return err == nil && len(decoded) == sha256.Size
+}
+
+// verifyCodeChallenge checks a PKCE code_verifier (RFC 7636 section 4.1)
+// against an S256 challenge.
+func verifyCodeChallenge(verifier, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	for _, r := range verifier {
+		switch {
+		case r >= 'A' && r <= 'Z', r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+		case r == '-' || r == '.' || r == '_' || r == '~':
+		default:
+			return false
+		}
+	}
+
+	sum := sha256.Sum256([]byte(verifier))
+	computed := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
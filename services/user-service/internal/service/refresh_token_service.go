@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+	"time"
+
+	"enterprise-microservice-system/services/user-service/internal/model"
+	"enterprise-microservice-system/services/user-service/internal/repository"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/errors"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrRefreshTokenReuse is returned when an already rotated refresh token is presented again.
+var ErrRefreshTokenReuse = errors.New(errors.ErrCodeUnauthorized, "refresh token reuse detected", nil)
+
+// IssuedRefreshToken is a newly minted refresh token. Value is only available at issuance.
+type IssuedRefreshToken struct {
+	Value     string
+	FamilyID  string
+	Subject   string
+	Roles     []string
+	AMR       []string
+	ExpiresAt time.Time
+}
+
+// RefreshTokenService defines refresh token issuance, rotation and revocation
+type RefreshTokenService interface {
+	Issue(ctx context.Context, subject string, roles []string, amr []string) (*IssuedRefreshToken, error)
+	Rotate(ctx context.Context, value string) (*IssuedRefreshToken, error)
+	Revoke(ctx context.Context, value string, actor string) (*model.RefreshToken, error)
+	RevokeSubject(ctx context.Context, subject string, actor string) error
+	Inspect(ctx context.Context, value string) (*model.RefreshToken, error)
+}
+
+// ErrRefreshTokenSubjectInactive is returned when the user or client a token
+// family was issued to has been disabled, deleted or stripped of every role
+// the family carried.
+var ErrRefreshTokenSubjectInactive = errors.New(errors.ErrCodeUnauthorized, "refresh token subject is no longer active", nil)
+
+// refreshTokenService implements RefreshTokenService
+type refreshTokenService struct {
+	repo    repository.RefreshTokenRepository
+	users   repository.UserRepository
+	clients repository.OAuthClientRepository
+	ttl     time.Duration
+}
+
+// NewRefreshTokenService creates a new refresh token service. ttl is the
+// absolute lifetime of a token family: rotation never extends it. users and
+// clients are consulted on every rotation so a family stops working, or loses
+// roles, as soon as its subject does.
+func NewRefreshTokenService(repo repository.RefreshTokenRepository, users repository.UserRepository, clients repository.OAuthClientRepository, ttl time.Duration) RefreshTokenService {
+	if ttl <= 0 {
+		ttl = 30 * 24 * time.Hour
+	}
+
+	return &refreshTokenService{
+		repo:    repo,
+		users:   users,
+		clients: clients,
+		ttl:     ttl,
+	}
+}
+
+// Issue starts a new token family for the subject. amr records how the subject
+// authenticated and is carried over to every access token the family refreshes.
+func (s *refreshTokenService) Issue(ctx context.Context, subject string, roles []string, amr []string) (*IssuedRefreshToken, error) {
+	return s.create(ctx, uuid.NewString(), time.Now().UTC().Add(s.ttl), subject, roles, amr)
+}
+
+// Rotate exchanges a refresh token for a new one in the same family. Presenting
+// a token that was already rotated revokes the entire family, as does a subject
+// that is no longer active. The new token keeps the family's expiry and only
+// the family roles the subject still holds.
+func (s *refreshTokenService) Rotate(ctx context.Context, value string) (*IssuedRefreshToken, error) {
+	current, err := s.find(ctx, value)
+	if err != nil {
+		return nil, err
+	}
+
+	switch current.Status {
+	case model.RefreshTokenStatusRotated:
+		if err := s.repo.RevokeFamily(ctx, current.FamilyID, "system"); err != nil {
+			return nil, errors.NewInternal("failed to revoke refresh token family", err)
+		}
+		return nil, ErrRefreshTokenReuse
+	case model.RefreshTokenStatusRevoked:
+		return nil, errors.New(errors.ErrCodeUnauthorized, "refresh token revoked", nil)
+	}
+
+	now := time.Now().UTC()
+	if now.After(current.ExpiresAt) || now.After(current.FamilyExpiresAt) {
+		return nil, errors.New(errors.ErrCodeUnauthorized, "refresh token expired", nil)
+	}
+
+	roles, err := s.currentRoles(ctx, current.Subject, current.RoleList())
+	if err != nil {
+		if err == ErrRefreshTokenSubjectInactive {
+			if revokeErr := s.repo.RevokeFamily(ctx, current.FamilyID, "system"); revokeErr != nil {
+				return nil, errors.NewInternal("failed to revoke refresh token family", revokeErr)
+			}
+		}
+		return nil, err
+	}
+
+	rotated, err := s.repo.MarkRotated(ctx, current.ID)
+	if err != nil {
+		return nil, errors.NewInternal("failed to rotate refresh token", err)
+	}
+	if !rotated {
+		// Lost a race with another use of the same token: treat it as reuse.
+		if err := s.repo.RevokeFamily(ctx, current.FamilyID, "system"); err != nil {
+			return nil, errors.NewInternal("failed to revoke refresh token family", err)
+		}
+		return nil, ErrRefreshTokenReuse
+	}
+
+	return s.create(ctx, current.FamilyID, current.FamilyExpiresAt, current.Subject, roles, current.AMRList())
+}
+
+// currentRoles re-validates a family's subject against the user or client
+// registry and returns the family roles it still holds. Numeric subjects are
+// users; anything else is a client ID.
+func (s *refreshTokenService) currentRoles(ctx context.Context, subject string, roles []string) ([]string, error) {
+	var held []string
+	if id, err := strconv.ParseUint(subject, 10, 64); err == nil {
+		user, err := s.users.FindByID(ctx, uint(id))
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrRefreshTokenSubjectInactive
+		}
+		if err != nil {
+			return nil, errors.NewInternal("failed to load refresh token subject", err)
+		}
+		if user.Status != model.UserStatusActive {
+			return nil, ErrRefreshTokenSubjectInactive
+		}
+		held = user.Roles
+		if len(held) == 0 {
+			held = []string{model.DefaultUserRole}
+		}
+	} else {
+		client, err := s.clients.FindByClientID(ctx, subject)
+		if err == gorm.ErrRecordNotFound {
+			return nil, ErrRefreshTokenSubjectInactive
+		}
+		if err != nil {
+			return nil, errors.NewInternal("failed to load refresh token subject", err)
+		}
+		if client.Status != model.OAuthClientStatusActive {
+			return nil, ErrRefreshTokenSubjectInactive
+		}
+		held = client.RoleList()
+	}
+
+	heldSet := make(map[string]struct{}, len(held))
+	for _, role := range held {
+		heldSet[role] = struct{}{}
+	}
+	kept := make([]string, 0, len(roles))
+	for _, role := range roles {
+		if _, ok := heldSet[role]; ok {
+			kept = append(kept, role)
+		}
+	}
+	if len(kept) == 0 {
+		return nil, ErrRefreshTokenSubjectInactive
+	}
+	return kept, nil
+}
+
+// Revoke revokes the family the token belongs to (logout)
+func (s *refreshTokenService) Revoke(ctx context.Context, value string, actor string) (*model.RefreshToken, error) {
+	current, err := s.find(ctx, value)
+	if err != nil {
+		return nil, err
+	}
+
+	if actor == "" {
+		actor = current.Subject
+	}
+
+	if err := s.repo.RevokeFamily(ctx, current.FamilyID, actor); err != nil {
+		return nil, errors.NewInternal("failed to revoke refresh token family", err)
+	}
+
+	current.Status = model.RefreshTokenStatusRevoked
+	return current, nil
+}
+
+// RevokeSubject revokes every refresh token family issued to a subject
+func (s *refreshTokenService) RevokeSubject(ctx context.Context, subject string, actor string) error {
+	if err := s.repo.RevokeSubject(ctx, subject, actor); err != nil {
+		return errors.NewInternal("failed to revoke refresh tokens", err)
+	}
+	return nil
+}
+
+// Inspect returns the stored token for a value regardless of its status
+func (s *refreshTokenService) Inspect(ctx context.Context, value string) (*model.RefreshToken, error) {
+	return s.find(ctx, value)
+}
+
+func (s *refreshTokenService) find(ctx context.Context, value string) (*model.RefreshToken, error) {
+	if value == "" {
+		return nil, errors.NewBadRequest("refresh_token is required")
+	}
+
+	token, err := s.repo.FindByHash(ctx, hashRefreshToken(value))
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.New(errors.ErrCodeUnauthorized, "invalid refresh token", nil)
+		}
+		return nil, errors.NewInternal("failed to load refresh token", err)
+	}
+	return token, nil
+}
+
+func (s *refreshTokenService) create(ctx context.Context, familyID string, familyExpiresAt time.Time, subject string, roles []string, amr []string) (*IssuedRefreshToken, error) {
+	value, err := generateRefreshTokenValue()
+	if err != nil {
+		return nil, errors.NewInternal("failed to generate refresh token", err)
+	}
+
+	expiresAt := time.Now().UTC().Add(s.ttl)
+	if expiresAt.After(familyExpiresAt) {
+		expiresAt = familyExpiresAt
+	}
+	token := &model.RefreshToken{
+		TokenHash:       hashRefreshToken(value),
+		FamilyID:        familyID,
+		Subject:         subject,
+		ExpiresAt:       expiresAt,
+		FamilyExpiresAt: familyExpiresAt,
+		Status:          model.RefreshTokenStatusActive,
+		CreatedBy:       subject,
+		UpdatedBy:       subject,
+	}
+	token.SetRoles(roles)
+	token.SetAMR(amr)
+
+	if err := s.repo.Create(ctx, token); err != nil {
+		return nil, errors.NewInternal("failed to store refresh token", err)
+	}
+
+	return &IssuedRefreshToken{
+		Value:     value,
+		FamilyID:  familyID,
+		Subject:   subject,
+		Roles:     roles,
+		AMR:       amr,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func generateRefreshTokenValue() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashRefreshToken(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
@@ -23,14 +23,33 @@ type UserService interface {
 	ListUsers(ctx context.Context, query *model.ListUsersQuery) ([]*model.User, int64, error)
 }
 
+var (
+	// Missing users are cached briefly so lookups of unknown IDs do not all
+	// reach the database.
+	userCacheOptions     = cache.LoadOptions{NegativeTTL: 30 * time.Second, EarlyRefresh: 1}
+	userListCacheOptions = cache.LoadOptions{TTL: 60 * time.Second, EarlyRefresh: 1, Tags: []string{userListCacheTag}}
+)
+
+// userListCacheTag tags every cached ListUsers page.
+const userListCacheTag = "users:list"
+
+// userPage is the cached form of a ListUsers result.
+type userPage struct {
+	Users []*model.User `json:"users"`
+	Total int64         `json:"total"`
+}
+
 // userService implements UserService
 type userService struct {
 	repo  repository.UserRepository
-	cache *cache.Cache
+	cache cache.Cache
 }
 
 // NewUserService creates a new user service
-func NewUserService(repo repository.UserRepository, cacheClient *cache.Cache) UserService {
+func NewUserService(repo repository.UserRepository, cacheClient cache.Cache) UserService {
+	if cacheClient == nil {
+		cacheClient = cache.NewNoopCache()
+	}
 	return &userService{
 		repo:  repo,
 		cache: cacheClient,
@@ -49,11 +68,22 @@ func (s *userService) CreateUser(ctx context.Context, req *model.CreateUserReque
 	}
 
 	// Create user
+	roles := req.Roles
+	if len(roles) == 0 {
+		roles = []string{model.DefaultUserRole}
+	}
+
 	user := &model.User{
-		Email:  req.Email,
-		Name:   req.Name,
-		Age:    req.Age,
-		Status: model.UserStatusActive,
+		Email:        req.Email,
+		Name:         req.Name,
+		Age:          req.Age,
+		Roles:        roles,
+		PasswordHash: req.PasswordHash,
+		Status:       model.UserStatusActive,
+	}
+	if req.PasswordHash != "" {
+		now := time.Now().UTC()
+		user.PasswordChangedAt = &now
 	}
 
 	if actor == "" {
@@ -66,26 +96,26 @@ func (s *userService) CreateUser(ctx context.Context, req *model.CreateUserReque
 		return nil, errors.NewInternal("failed to create user", err)
 	}
 
-	s.cacheSetUser(ctx, user)
+	s.invalidateUser(ctx, user.ID)
 
 	return user, nil
 }
 
 // GetUser retrieves a user by ID
 func (s *userService) GetUser(ctx context.Context, id uint) (*model.User, error) {
-	if cached := s.cacheGetUser(ctx, id); cached != nil {
-		return cached, nil
-	}
-
-	user, err := s.repo.FindByID(ctx, id)
-	if err != nil {
+	user, err := cache.GetOrLoad(ctx, s.cache, userCacheKey(id), userCacheOptions, func(ctx context.Context) (*model.User, error) {
+		user, err := s.repo.FindByID(ctx, id)
 		if err == gorm.ErrRecordNotFound {
+			return nil, cache.ErrNotFound
+		}
+		return user, err
+	})
+	if err != nil {
+		if err == cache.ErrNotFound {
 			return nil, errors.NewNotFound("user")
 		}
 		return nil, errors.NewInternal("failed to get user", err)
 	}
-
-	s.cacheSetUser(ctx, user)
 	return user, nil
 }
 
@@ -110,6 +140,12 @@ func (s *userService) UpdateUser(ctx context.Context, id uint, req *model.Update
 	if req.Status != nil {
 		user.Status = *req.Status
 	}
+	if len(req.Roles) > 0 {
+		user.Roles = req.Roles
+	}
+	if req.EmailVerified != nil {
+		user.EmailVerified = *req.EmailVerified
+	}
 	if actor == "" {
 		actor = "system"
 	}
@@ -120,7 +156,7 @@ func (s *userService) UpdateUser(ctx context.Context, id uint, req *model.Update
 		return nil, errors.NewInternal("failed to update user", err)
 	}
 
-	s.cacheSetUser(ctx, user)
+	s.invalidateUser(ctx, user.ID)
 
 	return user, nil
 }
@@ -145,7 +181,7 @@ func (s *userService) DeleteUser(ctx context.Context, id uint, actor string) err
 		return errors.NewInternal("failed to delete user", err)
 	}
 
-	s.cacheDeleteUser(ctx, id)
+	s.invalidateUser(ctx, id)
 
 	return nil
 }
@@ -154,80 +190,24 @@ func (s *userService) DeleteUser(ctx context.Context, id uint, actor string) err
 func (s *userService) ListUsers(ctx context.Context, query *model.ListUsersQuery) ([]*model.User, int64, error) {
 	query.ApplyDefaults()
 
-	if cachedUsers, cachedTotal, ok := s.cacheGetUserList(ctx, query); ok {
-		return cachedUsers, cachedTotal, nil
-	}
-
-	users, total, err := s.repo.List(ctx, query)
+	page, err := cache.GetOrLoad(ctx, s.cache, s.userListCacheKey(query), userListCacheOptions, func(ctx context.Context) (userPage, error) {
+		users, total, err := s.repo.List(ctx, query)
+		return userPage{Users: users, Total: total}, err
+	})
 	if err != nil {
 		return nil, 0, errors.NewInternal("failed to list users", err)
 	}
-
-	s.cacheSetUserList(ctx, query, users, total)
-	return users, total, nil
-}
-
-func (s *userService) cacheGetUser(ctx context.Context, id uint) *model.User {
-	if s.cache == nil || !s.cache.Enabled() {
-		return nil
-	}
-
-	var user model.User
-	found, err := s.cache.GetJSON(ctx, fmt.Sprintf("user:%d", id), &user)
-	if err != nil || !found {
-		return nil
-	}
-	return &user
+	return page.Users, page.Total, nil
 }
 
-func (s *userService) cacheSetUser(ctx context.Context, user *model.User) {
-	if s.cache == nil || !s.cache.Enabled() || user == nil {
-		return
-	}
-
-	_ = s.cache.SetJSON(ctx, fmt.Sprintf("user:%d", user.ID), user, 0)
+// invalidateUser drops the cached user and every cached list page.
+func (s *userService) invalidateUser(ctx context.Context, id uint) {
+	_ = s.cache.Delete(ctx, userCacheKey(id))
+	_ = s.cache.InvalidateTags(ctx, userListCacheTag)
 }
 
-func (s *userService) cacheDeleteUser(ctx context.Context, id uint) {
-	if s.cache == nil || !s.cache.Enabled() {
-		return
-	}
-	_ = s.cache.Delete(ctx, fmt.Sprintf("user:%d", id))
-}
-
-func (s *userService) cacheGetUserList(ctx context.Context, query *model.ListUsersQuery) ([]*model.User, int64, bool) {
-	if s.cache == nil || !s.cache.Enabled() {
-		return nil, 0, false
-	}
-
-	key := s.userListCacheKey(query)
-	var payload struct {
-		Users []*model.User `json:"users"`
-		Total int64         `json:"total"`
-	}
-
-	found, err := s.cache.GetJSON(ctx, key, &payload)
-	if err != nil || !found {
-		return nil, 0, false
-	}
-	return payload.Users, payload.Total, true
-}
-
-func (s *userService) cacheSetUserList(ctx context.Context, query *model.ListUsersQuery, users []*model.User, total int64) {
-	if s.cache == nil || !s.cache.Enabled() {
-		return
-	}
-
-	key := s.userListCacheKey(query)
-	payload := struct {
-		Users []*model.User `json:"users"`
-		Total int64         `json:"total"`
-	}{
-		Users: users,
-		Total: total,
-	}
-
-	_ = s.cache.SetJSON(ctx, key, payload, 60*time.Second)
+func userCacheKey(id uint) string {
+	return fmt.Sprintf("user:%d", id)
 }
 
 func (s *userService) userListCacheKey(query *model.ListUsersQuery) string {
@@ -0,0 +1,163 @@
+package tests
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"enterprise-microservice-system/services/user-service/internal/model"
+	"enterprise-microservice-system/services/user-service/internal/repository"
+	"enterprise-microservice-system/services/user-service/internal/service"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/auth"
+	"gorm.io/gorm"
+)
+
+func setupAPIKeyService(t *testing.T) service.APIKeyService {
+	svc, _ := setupAPIKeyServiceWithDB(t)
+	return svc
+}
+
+func setupAPIKeyServiceWithDB(t *testing.T) (service.APIKeyService, *gorm.DB) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&model.APIKey{}, &model.OAuthClient{}); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+	svc := service.NewAPIKeyService(
+		repository.NewAPIKeyRepository(db),
+		repository.NewUserRepository(db),
+		repository.NewOAuthClientRepository(db),
+	)
+	return svc, db
+}
+
+func TestAPIKeyCreateVerifyAndRevoke(t *testing.T) {
+	svc := setupAPIKeyService(t)
+	ctx := context.Background()
+
+	key, value, err := svc.CreateKey(ctx, &model.CreateAPIKeyRequest{
+		Name:  "Acme integration",
+		Owner: "partner-acme",
+		Roles: []string{"user"},
+	}, "admin")
+	if err != nil {
+		t.Fatalf("CreateKey() error = %v", err)
+	}
+	if key.SecretHash == "" || key.SecretHash == value {
+		t.Fatal("expected the key secret to be stored only as a hash")
+	}
+
+	principal, err := svc.VerifyAPIKey(ctx, value)
+	if err != nil {
+		t.Fatalf("VerifyAPIKey() error = %v", err)
+	}
+	if principal.KeyID != key.KeyID || principal.Subject != "partner-acme" || len(principal.Roles) != 1 {
+		t.Fatalf("unexpected principal %+v", principal)
+	}
+
+	keys, _, err := svc.ListKeys(ctx, &model.ListAPIKeysQuery{Owner: "partner-acme"})
+	if err != nil {
+		t.Fatalf("ListKeys() error = %v", err)
+	}
+	if len(keys) != 1 || keys[0].LastUsedAt == nil {
+		t.Fatalf("expected one key with last_used_at set, got %+v", keys)
+	}
+
+	if _, err := svc.VerifyAPIKey(ctx, key.KeyID+"_not-the-secret"); err != auth.ErrInvalidAPIKey {
+		t.Fatalf("expected wrong secret to be rejected, got %v", err)
+	}
+	if _, err := svc.VerifyAPIKey(ctx, "not-a-key"); err != auth.ErrInvalidAPIKey {
+		t.Fatalf("expected malformed key to be rejected, got %v", err)
+	}
+
+	if _, err := svc.RevokeKey(ctx, key.KeyID, "admin"); err != nil {
+		t.Fatalf("RevokeKey() error = %v", err)
+	}
+	if _, err := svc.VerifyAPIKey(ctx, value); err != auth.ErrInvalidAPIKey {
+		t.Fatalf("expected revoked key to be rejected, got %v", err)
+	}
+}
+
+func TestAPIKeyExpiry(t *testing.T) {
+	svc := setupAPIKeyService(t)
+	ctx := context.Background()
+
+	past := time.Now().Add(-time.Minute)
+	if _, _, err := svc.CreateKey(ctx, &model.CreateAPIKeyRequest{
+		Name:      "Expired",
+		Owner:     "partner-acme",
+		Roles:     []string{"user"},
+		ExpiresAt: &past,
+	}, "admin"); err == nil {
+		t.Fatal("expected a past expiry to be rejected")
+	}
+
+	soon := time.Now().Add(50 * time.Millisecond)
+	_, value, err := svc.CreateKey(ctx, &model.CreateAPIKeyRequest{
+		Name:      "Short lived",
+		Owner:     "partner-acme",
+		Roles:     []string{"user"},
+		ExpiresAt: &soon,
+	}, "admin")
+	if err != nil {
+		t.Fatalf("CreateKey() error = %v", err)
+	}
+	if _, err := svc.VerifyAPIKey(ctx, value); err != nil {
+		t.Fatalf("expected key to be valid before expiry, got %v", err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if _, err := svc.VerifyAPIKey(ctx, value); err != auth.ErrInvalidAPIKey {
+		t.Fatalf("expected expired key to be rejected, got %v", err)
+	}
+}
+
+func TestAPIKeyRejectedOnceOwnerIsInactive(t *testing.T) {
+	svc, db := setupAPIKeyServiceWithDB(t)
+	ctx := context.Background()
+
+	users := repository.NewUserRepository(db)
+	user := &model.User{Email: "owner@example.com", Name: "Owner", Status: model.UserStatusActive}
+	if err := users.Create(ctx, user); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	_, value, err := svc.CreateKey(ctx, &model.CreateAPIKeyRequest{
+		Name:  "Personal",
+		Owner: strconv.FormatUint(uint64(user.ID), 10),
+		Roles: []string{"user"},
+	}, "admin")
+	if err != nil {
+		t.Fatalf("CreateKey() error = %v", err)
+	}
+	if _, err := svc.VerifyAPIKey(ctx, value); err != nil {
+		t.Fatalf("expected key of an active owner to verify, got %v", err)
+	}
+
+	user.Status = model.UserStatusInactive
+	if err := users.Update(ctx, user); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if _, err := svc.VerifyAPIKey(ctx, value); err != auth.ErrInvalidAPIKey {
+		t.Fatalf("expected key of a disabled owner to be rejected, got %v", err)
+	}
+
+	if err := users.Delete(ctx, user.ID, "admin"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := svc.VerifyAPIKey(ctx, value); err != auth.ErrInvalidAPIKey {
+		t.Fatalf("expected key of a deleted owner to be rejected, got %v", err)
+	}
+
+	_, orphan, err := svc.CreateKey(ctx, &model.CreateAPIKeyRequest{
+		Name:  "Orphan",
+		Owner: "9999",
+		Roles: []string{"user"},
+	}, "admin")
+	if err != nil {
+		t.Fatalf("CreateKey() error = %v", err)
+	}
+	if _, err := svc.VerifyAPIKey(ctx, orphan); err != auth.ErrInvalidAPIKey {
+		t.Fatalf("expected key of a missing owner to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,184 @@
+package tests
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"enterprise-microservice-system/services/user-service/internal/model"
+	"enterprise-microservice-system/services/user-service/internal/repository"
+	"enterprise-microservice-system/services/user-service/internal/service"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/errors"
+)
+
+func setupCredentialService(t *testing.T, cfg service.CredentialConfig) (service.UserService, service.CredentialService) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&model.PasswordResetToken{}); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+	users := repository.NewUserRepository(db)
+	return service.NewUserService(users, nil),
+		service.NewCredentialService(users, repository.NewPasswordResetTokenRepository(db), nil, cfg)
+}
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	policy := service.PasswordPolicy{MinLength: 8, MaxLength: 16, RequireUpper: true, RequireDigit: true, RequireSymbol: true}
+
+	tests := []struct {
+		name     string
+		password string
+		wantErr  bool
+	}{
+		{"valid", "Str0ng!pass", false},
+		{"too short", "S0!a", true},
+		{"too long", "Str0ng!passwordtoolong", true},
+		{"missing upper", "str0ng!pass", true},
+		{"missing digit", "Strong!pass", true},
+		{"missing symbol", "Str0ngpass", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(tt.password)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate(%q) error = %v, wantErr %v", tt.password, err, tt.wantErr)
+			}
+			if err != nil {
+				if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != errors.ErrCodeValidation {
+					t.Fatalf("expected validation error, got %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestCredentialLoginAndChangePassword(t *testing.T) {
+	for _, algorithm := range []string{service.PasswordHashArgon2id, service.PasswordHashBcrypt} {
+		t.Run(algorithm, func(t *testing.T) {
+			users, svc := setupCredentialService(t, service.CredentialConfig{
+				Algorithm: algorithm,
+				Policy:    service.PasswordPolicy{MinLength: 8},
+			})
+			ctx := context.Background()
+
+			user, err := users.CreateUser(ctx, &model.CreateUserRequest{Email: "jane@example.com", Name: "Jane", Age: 30}, "admin")
+			if err != nil {
+				t.Fatalf("CreateUser() error = %v", err)
+			}
+			if len(user.Roles) != 1 || user.Roles[0] != model.DefaultUserRole {
+				t.Fatalf("expected default roles, got %v", user.Roles)
+			}
+
+			_, err = svc.Authenticate(ctx, "jane@example.com", "anything")
+			expectUnauthorized(t, err, "user without password")
+
+			if err := svc.SetPassword(ctx, user.ID, "short", "admin"); err == nil {
+				t.Fatal("expected policy violation to be rejected")
+			}
+			if err := svc.SetPassword(ctx, user.ID, "initial-pass", "admin"); err != nil {
+				t.Fatalf("SetPassword() error = %v", err)
+			}
+
+			authenticated, err := svc.Authenticate(ctx, "jane@example.com", "initial-pass")
+			if err != nil {
+				t.Fatalf("Authenticate() error = %v", err)
+			}
+			if authenticated.ID != user.ID || authenticated.PasswordChangedAt == nil {
+				t.Fatalf("unexpected authenticated user %+v", authenticated)
+			}
+
+			_, err = svc.Authenticate(ctx, "jane@example.com", "wrong-pass")
+			expectUnauthorized(t, err, "wrong password")
+			_, err = svc.Authenticate(ctx, "nobody@example.com", "initial-pass")
+			expectUnauthorized(t, err, "unknown email")
+
+			err = svc.ChangePassword(ctx, user.ID, "wrong-pass", "changed-pass")
+			expectUnauthorized(t, err, "wrong current password")
+
+			if err := svc.ChangePassword(ctx, user.ID, "initial-pass", "changed-pass"); err != nil {
+				t.Fatalf("ChangePassword() error = %v", err)
+			}
+			_, err = svc.Authenticate(ctx, "jane@example.com", "initial-pass")
+			expectUnauthorized(t, err, "old password")
+			if _, err := svc.Authenticate(ctx, "jane@example.com", "changed-pass"); err != nil {
+				t.Fatalf("expected new password to authenticate, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCredentialCreateUserWithPassword(t *testing.T) {
+	users, svc := setupCredentialService(t, service.CredentialConfig{
+		Algorithm: service.PasswordHashBcrypt,
+		Policy:    service.PasswordPolicy{MinLength: 8},
+	})
+	ctx := context.Background()
+
+	// 45 characters, but 90 bytes: bcrypt would silently ignore the tail.
+	if _, err := svc.HashPassword(strings.Repeat("é", 45)); err == nil {
+		t.Fatal("expected a password over 72 bytes to be rejected for bcrypt")
+	}
+
+	hash, err := svc.HashPassword("initial-pass")
+	if err != nil {
+		t.Fatalf("HashPassword() error = %v", err)
+	}
+	user, err := users.CreateUser(ctx, &model.CreateUserRequest{Email: "new@example.com", Name: "New", Age: 30, PasswordHash: hash}, "admin")
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	if user.PasswordChangedAt == nil {
+		t.Fatal("expected password_changed_at to be set")
+	}
+	if _, err := svc.Authenticate(ctx, "new@example.com", "initial-pass"); err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+}
+
+func TestCredentialResetTokenIsSingleUse(t *testing.T) {
+	users, svc := setupCredentialService(t, service.CredentialConfig{
+		Algorithm: service.PasswordHashBcrypt,
+		Policy:    service.PasswordPolicy{MinLength: 8},
+	})
+	ctx := context.Background()
+
+	user, err := users.CreateUser(ctx, &model.CreateUserRequest{Email: "sam@example.com", Name: "Sam", Age: 41}, "admin")
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	first, err := svc.IssueResetToken(ctx, user.ID, "admin")
+	if err != nil {
+		t.Fatalf("IssueResetToken() error = %v", err)
+	}
+	second, err := svc.IssueResetToken(ctx, user.ID, "admin")
+	if err != nil {
+		t.Fatalf("IssueResetToken() error = %v", err)
+	}
+
+	_, err = svc.ResetPassword(ctx, first.Token, "reset-pass-1")
+	expectUnauthorized(t, err, "superseded reset token")
+
+	if _, err := svc.ResetPassword(ctx, second.Token, "short"); err == nil {
+		t.Fatal("expected policy violation to be rejected")
+	}
+
+	reset, err := svc.ResetPassword(ctx, second.Token, "reset-pass-1")
+	if err != nil {
+		t.Fatalf("ResetPassword() error = %v", err)
+	}
+	if reset.ID != user.ID {
+		t.Fatalf("expected user %d, got %d", user.ID, reset.ID)
+	}
+
+	_, err = svc.ResetPassword(ctx, second.Token, "reset-pass-2")
+	expectUnauthorized(t, err, "reused reset token")
+
+	if _, err := svc.Authenticate(ctx, "sam@example.com", "reset-pass-1"); err != nil {
+		t.Fatalf("expected reset password to authenticate, got %v", err)
+	}
+
+	if _, err := svc.IssueResetToken(ctx, 9999, "admin"); err == nil {
+		t.Fatal("expected unknown user to be rejected")
+	}
+}
@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"enterprise-microservice-system/services/user-service/internal/service"
+)
+
+func TestLoginThrottleLocksClientWithBackoff(t *testing.T) {
+	throttle := service.NewLoginThrottle(nil, service.LockoutPolicy{
+		MaxClientFailures: 3,
+		Window:            time.Minute,
+		BaseLockout:       time.Minute,
+		MaxLockout:        3 * time.Minute,
+	})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		lockouts, err := throttle.RecordFailure(ctx, "partner", "10.0.0.1")
+		if err != nil || len(lockouts) != 0 {
+			t.Fatalf("RecordFailure() #%d = %v, %v; want no lockout", i+1, lockouts, err)
+		}
+	}
+	if wait, _ := throttle.Check(ctx, "partner", "10.0.0.1"); wait != 0 {
+		t.Fatalf("expected no lockout before the threshold, got %v", wait)
+	}
+
+	lockouts, _ := throttle.RecordFailure(ctx, "partner", "10.0.0.1")
+	if len(lockouts) != 1 || lockouts[0].Scope != service.LockoutScopePrincipal || lockouts[0].Duration != time.Minute {
+		t.Fatalf("expected a one minute client lockout, got %+v", lockouts)
+	}
+	if wait, _ := throttle.Check(ctx, "partner", "10.0.0.2"); wait <= 0 {
+		t.Fatal("expected the client to be locked out from any IP")
+	}
+	if wait, _ := throttle.Check(ctx, "other", "10.0.0.1"); wait != 0 {
+		t.Fatal("expected other clients to be unaffected")
+	}
+
+	durations := []time.Duration{}
+	for i := 0; i < 6; i++ {
+		for _, lockout := range mustRecordFailure(t, throttle, "partner") {
+			durations = append(durations, lockout.Duration)
+		}
+	}
+	want := []time.Duration{2 * time.Minute, 3 * time.Minute}
+	if len(durations) != len(want) || durations[0] != want[0] || durations[1] != want[1] {
+		t.Fatalf("expected backoff %v, got %v", want, durations)
+	}
+
+	if err := throttle.RecordSuccess(ctx, "partner"); err != nil {
+		t.Fatalf("RecordSuccess() error = %v", err)
+	}
+	if wait, _ := throttle.Check(ctx, "partner", ""); wait != 0 {
+		t.Fatalf("expected success to clear the client lockout, got %v", wait)
+	}
+}
+
+func TestLoginThrottleLocksIPAcrossClients(t *testing.T) {
+	throttle := service.NewLoginThrottle(nil, service.LockoutPolicy{
+		MaxClientFailures: 10,
+		MaxIPFailures:     3,
+		Window:            time.Minute,
+		BaseLockout:       time.Minute,
+		MaxLockout:        time.Hour,
+	})
+	ctx := context.Background()
+
+	var lockouts []service.Lockout
+	for _, principal := range []string{"a", "b", "c"} {
+		result, err := throttle.RecordFailure(ctx, principal, "10.0.0.9")
+		if err != nil {
+			t.Fatalf("RecordFailure() error = %v", err)
+		}
+		lockouts = append(lockouts, result...)
+	}
+
+	if len(lockouts) != 1 || lockouts[0].Scope != service.LockoutScopeIP || lockouts[0].Key != "10.0.0.9" {
+		t.Fatalf("expected a single IP lockout, got %+v", lockouts)
+	}
+	if wait, _ := throttle.Check(ctx, "d", "10.0.0.9"); wait <= 0 {
+		t.Fatal("expected every client to be locked out from the IP")
+	}
+	if wait, _ := throttle.Check(ctx, "d", "10.0.0.10"); wait != 0 {
+		t.Fatal("expected other IPs to be unaffected")
+	}
+}
+
+func mustRecordFailure(t *testing.T, throttle service.LoginThrottle, principal string) []service.Lockout {
+	t.Helper()
+
+	lockouts, err := throttle.RecordFailure(context.Background(), principal, "")
+	if err != nil {
+		t.Fatalf("RecordFailure() error = %v", err)
+	}
+	return lockouts
+}
@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"context"
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+
+	"enterprise-microservice-system/services/user-service/internal/model"
+	"enterprise-microservice-system/services/user-service/internal/repository"
+	"enterprise-microservice-system/services/user-service/internal/service"
+)
+
+func TestTOTPCodeMatchesRFC6238Vectors(t *testing.T) {
+	// RFC 6238 appendix B SHA-1 secret, truncated to six digits.
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		got, err := service.TOTPCode(secret, time.Unix(tt.unix, 0))
+		if err != nil {
+			t.Fatalf("TOTPCode() error = %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("TOTPCode(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func setupMFAService(t *testing.T) (service.MFAService, *model.User) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&model.UserMFA{}); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+	users := repository.NewUserRepository(db)
+	user, err := service.NewUserService(users, nil).CreateUser(context.Background(), &model.CreateUserRequest{
+		Email: "admin@example.com",
+		Name:  "Admin",
+		Age:   40,
+		Roles: []string{"admin"},
+	}, "system")
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+
+	svc, err := service.NewMFAService(repository.NewMFARepository(db), users, service.MFAConfig{
+		Issuer:        "Test",
+		EncryptionKey: "test-key",
+		RecoveryCodes: 3,
+	})
+	if err != nil {
+		t.Fatalf("NewMFAService() error = %v", err)
+	}
+	return svc, user
+}
+
+func TestMFAEnrollVerifyAndRecovery(t *testing.T) {
+	svc, user := setupMFAService(t)
+	ctx := context.Background()
+
+	enrollment, err := svc.EnrollTOTP(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("EnrollTOTP() error = %v", err)
+	}
+	if !strings.HasPrefix(enrollment.ProvisioningURI, "otpauth://totp/Test:admin@example.com?") {
+		t.Fatalf("unexpected provisioning uri %q", enrollment.ProvisioningURI)
+	}
+	if enabled, _ := svc.Enabled(ctx, user.ID); enabled {
+		t.Fatal("expected enrollment to stay pending until confirmed")
+	}
+
+	if _, err := svc.ConfirmTOTP(ctx, user.ID, "000000"); err != service.ErrInvalidMFACode {
+		// A random secret could produce 000000, but only with negligible probability.
+		t.Fatalf("expected invalid code error, got %v", err)
+	}
+
+	code, _ := service.TOTPCode(enrollment.Secret, time.Now())
+	recoveryCodes, err := svc.ConfirmTOTP(ctx, user.ID, code)
+	if err != nil {
+		t.Fatalf("ConfirmTOTP() error = %v", err)
+	}
+	if len(recoveryCodes) != 3 {
+		t.Fatalf("expected 3 recovery codes, got %d", len(recoveryCodes))
+	}
+	if enabled, _ := svc.Enabled(ctx, user.ID); !enabled {
+		t.Fatal("expected mfa to be enabled after confirmation")
+	}
+
+	if _, err := svc.Verify(ctx, user.ID, code); err != service.ErrInvalidMFACode {
+		t.Fatalf("expected the confirmation code to be rejected as a replay, got %v", err)
+	}
+	next, _ := service.TOTPCode(enrollment.Secret, time.Now().Add(service.TOTPPeriod))
+	if recovery, err := svc.Verify(ctx, user.ID, next); err != nil || recovery {
+		t.Fatalf("Verify(next step) = %v, %v; want totp success", recovery, err)
+	}
+
+	recovery, err := svc.Verify(ctx, user.ID, strings.ToUpper(recoveryCodes[0]))
+	if err != nil || !recovery {
+		t.Fatalf("Verify(recovery code) = %v, %v; want recovery success", recovery, err)
+	}
+	if _, err := svc.Verify(ctx, user.ID, recoveryCodes[0]); err != service.ErrInvalidMFACode {
+		t.Fatalf("expected a used recovery code to be rejected, got %v", err)
+	}
+
+	status, err := svc.Status(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if !status.Enabled || status.RecoveryCodesRemaining != 2 {
+		t.Fatalf("unexpected status %+v", status)
+	}
+
+	if err := svc.Disable(ctx, user.ID, recoveryCodes[1]); err != nil {
+		t.Fatalf("Disable() error = %v", err)
+	}
+	if enabled, _ := svc.Enabled(ctx, user.ID); enabled {
+		t.Fatal("expected mfa to be disabled")
+	}
+}
@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"enterprise-microservice-system/services/user-service/internal/model"
+	"enterprise-microservice-system/services/user-service/internal/repository"
+	"enterprise-microservice-system/services/user-service/internal/service"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/errors"
+)
+
+func setupClientService(t *testing.T) service.OAuthClientService {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&model.OAuthClient{}); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+	return service.NewOAuthClientService(repository.NewOAuthClientRepository(db), 0)
+}
+
+func expectUnauthorized(t *testing.T, err error, msg string) {
+	t.Helper()
+	appErr, ok := err.(*errors.AppError)
+	if !ok || appErr.Code != errors.ErrCodeUnauthorized {
+		t.Fatalf("%s: expected unauthorized error, got %v", msg, err)
+	}
+}
+
+func TestOAuthClientAuthenticate(t *testing.T) {
+	svc := setupClientService(t)
+	ctx := context.Background()
+
+	client, secret, err := svc.CreateClient(ctx, &model.CreateOAuthClientRequest{
+		ClientID:     "billing",
+		Name:         "Billing Team",
+		AllowedRoles: []string{"service", "user"},
+	}, "admin")
+	if err != nil {
+		t.Fatalf("CreateClient() error = %v", err)
+	}
+	if secret == "" || client.SecretHash == secret {
+		t.Fatal("expected a generated secret stored only as a hash")
+	}
+
+	authenticated, err := svc.Authenticate(ctx, "billing", secret)
+	if err != nil {
+		t.Fatalf("Authenticate() error = %v", err)
+	}
+	if roles := authenticated.RoleList(); len(roles) != 2 || roles[0] != "service" {
+		t.Fatalf("unexpected roles %v", roles)
+	}
+
+	_, err = svc.Authenticate(ctx, "billing", "wrong")
+	expectUnauthorized(t, err, "wrong secret")
+
+	_, err = svc.Authenticate(ctx, "unknown", secret)
+	expectUnauthorized(t, err, "unknown client")
+
+	if _, _, err := svc.CreateClient(ctx, &model.CreateOAuthClientRequest{
+		ClientID:     "billing",
+		Name:         "Duplicate",
+		AllowedRoles: []string{"user"},
+	}, "admin"); err == nil {
+		t.Fatal("expected duplicate client_id to be rejected")
+	}
+}
+
+func TestOAuthClientDisableExpireAndRotate(t *testing.T) {
+	svc := setupClientService(t)
+	ctx := context.Background()
+
+	_, secret, err := svc.CreateClient(ctx, &model.CreateOAuthClientRequest{
+		ClientID:     "reports",
+		Name:         "Reports",
+		AllowedRoles: []string{"user"},
+	}, "admin")
+	if err != nil {
+		t.Fatalf("CreateClient() error = %v", err)
+	}
+
+	disabled := model.OAuthClientStatusDisabled
+	if _, err := svc.UpdateClient(ctx, "reports", &model.UpdateOAuthClientRequest{Status: &disabled}, "admin"); err != nil {
+		t.Fatalf("UpdateClient() error = %v", err)
+	}
+	_, err = svc.Authenticate(ctx, "reports", secret)
+	expectUnauthorized(t, err, "disabled client")
+
+	active := model.OAuthClientStatusActive
+	past := time.Now().Add(-time.Minute)
+	if _, err := svc.UpdateClient(ctx, "reports", &model.UpdateOAuthClientRequest{Status: &active, SecretExpiresAt: &past}, "admin"); err != nil {
+		t.Fatalf("UpdateClient() error = %v", err)
+	}
+	_, err = svc.Authenticate(ctx, "reports", secret)
+	expectUnauthorized(t, err, "expired secret")
+
+	_, rotated, err := svc.RotateSecret(ctx, "reports", &model.RotateOAuthClientSecretRequest{}, "admin")
+	if err != nil {
+		t.Fatalf("RotateSecret() error = %v", err)
+	}
+	if _, err := svc.Authenticate(ctx, "reports", rotated); err != nil {
+		t.Fatalf("expected rotated secret to authenticate, got %v", err)
+	}
+	_, err = svc.Authenticate(ctx, "reports", secret)
+	expectUnauthorized(t, err, "previous secret")
+
+	if err := svc.DeleteClient(ctx, "reports", "admin"); err != nil {
+		t.Fatalf("DeleteClient() error = %v", err)
+	}
+	_, err = svc.Authenticate(ctx, "reports", rotated)
+	expectUnauthorized(t, err, "deleted client")
+}
@@ -0,0 +1,103 @@
+package tests
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"enterprise-microservice-system/services/user-service/internal/model"
+	"enterprise-microservice-system/services/user-service/internal/repository"
+	"enterprise-microservice-system/services/user-service/internal/service"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/errors"
+)
+
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func TestAuthorizationCodeRedemption(t *testing.T) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&model.AuthorizationCode{}); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+	users := repository.NewUserRepository(db)
+	svc := service.NewOIDCService(repository.NewAuthorizationCodeRepository(db), users, service.OIDCConfig{
+		Issuer:  "https://id.example.com/",
+		CodeTTL: time.Minute,
+	})
+	if svc.Issuer() != "https://id.example.com" {
+		t.Fatalf("expected trailing slash to be trimmed, got %q", svc.Issuer())
+	}
+
+	ctx := context.Background()
+	user, err := service.NewUserService(users, nil).CreateUser(ctx, &model.CreateUserRequest{
+		Email: "jane@example.com",
+		Name:  "Jane",
+		Age:   30,
+	}, "admin")
+	if err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+
+	verifier := strings.Repeat("v", 43)
+	request := &service.AuthorizationRequest{
+		ClientID:      "grafana",
+		RedirectURI:   "https://grafana.example.com/login/generic_oauth",
+		Scope:         "openid email",
+		Nonce:         "nonce-1",
+		CodeChallenge: pkceChallenge(verifier),
+		UserID:        user.ID,
+		AMR:           []string{"pwd"},
+		AuthTime:      time.Now(),
+	}
+
+	if _, err := svc.CreateCode(ctx, &service.AuthorizationRequest{CodeChallenge: "plain-challenge"}); err == nil {
+		t.Fatal("expected a non-S256 challenge to be rejected")
+	}
+
+	code, err := svc.CreateCode(ctx, request)
+	if err != nil {
+		t.Fatalf("CreateCode failed: %v", err)
+	}
+
+	mismatches := []struct {
+		name        string
+		clientID    string
+		redirectURI string
+		verifier    string
+	}{
+		{"wrong client", "other", request.RedirectURI, verifier},
+		{"wrong redirect", request.ClientID, "https://evil.example.com/cb", verifier},
+		{"wrong verifier", request.ClientID, request.RedirectURI, strings.Repeat("w", 43)},
+	}
+	for _, tt := range mismatches {
+		if _, _, err := svc.RedeemCode(ctx, code, tt.clientID, tt.redirectURI, tt.verifier); err != service.ErrInvalidAuthorizationCode {
+			t.Fatalf("%s: expected ErrInvalidAuthorizationCode, got %v", tt.name, err)
+		}
+	}
+
+	redeemed, redeemedUser, err := svc.RedeemCode(ctx, code, request.ClientID, request.RedirectURI, verifier)
+	if err != nil {
+		t.Fatalf("RedeemCode failed: %v", err)
+	}
+	if redeemedUser.ID != user.ID || redeemed.Nonce != "nonce-1" || redeemed.AMRList()[0] != "pwd" {
+		t.Fatalf("unexpected redeemed code: %+v", redeemed)
+	}
+
+	_, _, err = svc.RedeemCode(ctx, code, request.ClientID, request.RedirectURI, verifier)
+	if appErr, ok := err.(*errors.AppError); !ok || appErr.Code != errors.ErrCodeUnauthorized {
+		t.Fatalf("expected a reused code to be rejected, got %v", err)
+	}
+
+	info, err := svc.UserInfo(ctx, user.ID)
+	if err != nil {
+		t.Fatalf("UserInfo failed: %v", err)
+	}
+	if info.Email != "jane@example.com" || info.Name != "Jane" || info.EmailVerified {
+		t.Fatalf("unexpected userinfo: %+v", info)
+	}
+}
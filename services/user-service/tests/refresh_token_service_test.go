@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"enterprise-microservice-system/services/user-service/internal/model"
+	"enterprise-microservice-system/services/user-service/internal/repository"
+	"enterprise-microservice-system/services/user-service/internal/service"
+)
+
+func setupRefreshTokenService(t *testing.T) (service.RefreshTokenService, service.UserService, service.OAuthClientService) {
+	db := setupTestDB(t)
+	if err := db.AutoMigrate(&model.RefreshToken{}, &model.OAuthClient{}); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+	users := repository.NewUserRepository(db)
+	clients := repository.NewOAuthClientRepository(db)
+	return service.NewRefreshTokenService(repository.NewRefreshTokenRepository(db), users, clients, time.Hour),
+		service.NewUserService(users, nil),
+		service.NewOAuthClientService(clients, 0)
+}
+
+func TestRefreshTokenRotationKeepsFamilyExpiry(t *testing.T) {
+	svc, _, clients := setupRefreshTokenService(t)
+	ctx := context.Background()
+
+	if _, _, err := clients.CreateClient(ctx, &model.CreateOAuthClientRequest{
+		ClientID:     "billing",
+		Name:         "Billing Team",
+		AllowedRoles: []string{"service"},
+	}, "admin"); err != nil {
+		t.Fatalf("CreateClient() error = %v", err)
+	}
+
+	issued, err := svc.Issue(ctx, "billing", []string{"service"}, nil)
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	rotated, err := svc.Rotate(ctx, issued.Value)
+	if err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if rotated.ExpiresAt.After(issued.ExpiresAt) {
+		t.Fatalf("rotation extended the family from %v to %v", issued.ExpiresAt, rotated.ExpiresAt)
+	}
+
+	stored, err := svc.Inspect(ctx, rotated.Value)
+	if err != nil {
+		t.Fatalf("Inspect() error = %v", err)
+	}
+	if !stored.FamilyExpiresAt.Equal(issued.ExpiresAt) {
+		t.Fatalf("expected family expiry %v, got %v", issued.ExpiresAt, stored.FamilyExpiresAt)
+	}
+}
+
+func TestRefreshTokenRotationRevalidatesSubject(t *testing.T) {
+	svc, users, clients := setupRefreshTokenService(t)
+	ctx := context.Background()
+
+	if _, _, err := clients.CreateClient(ctx, &model.CreateOAuthClientRequest{
+		ClientID:     "billing",
+		Name:         "Billing Team",
+		AllowedRoles: []string{"service", "user"},
+	}, "admin"); err != nil {
+		t.Fatalf("CreateClient() error = %v", err)
+	}
+
+	issued, err := svc.Issue(ctx, "billing", []string{"service", "user"}, nil)
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+
+	// Roles the client lost are dropped from the family.
+	if _, err := clients.UpdateClient(ctx, "billing", &model.UpdateOAuthClientRequest{AllowedRoles: []string{"user"}}, "admin"); err != nil {
+		t.Fatalf("UpdateClient() error = %v", err)
+	}
+	rotated, err := svc.Rotate(ctx, issued.Value)
+	if err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if len(rotated.Roles) != 1 || rotated.Roles[0] != "user" {
+		t.Fatalf("expected roles narrowed to [user], got %v", rotated.Roles)
+	}
+
+	// A disabled client can no longer refresh, and its family is revoked.
+	disabled := model.OAuthClientStatusDisabled
+	if _, err := clients.UpdateClient(ctx, "billing", &model.UpdateOAuthClientRequest{Status: &disabled}, "admin"); err != nil {
+		t.Fatalf("UpdateClient() error = %v", err)
+	}
+	_, err = svc.Rotate(ctx, rotated.Value)
+	expectUnauthorized(t, err, "disabled client")
+	if stored, err := svc.Inspect(ctx, rotated.Value); err != nil || stored.Status != model.RefreshTokenStatusRevoked {
+		t.Fatalf("expected family revoked, got %+v %v", stored, err)
+	}
+
+	// The same applies to users.
+	user, err := users.CreateUser(ctx, &model.CreateUserRequest{Email: "refresh@example.com", Name: "Refresh", Age: 30}, "admin")
+	if err != nil {
+		t.Fatalf("CreateUser() error = %v", err)
+	}
+	subject := strconv.FormatUint(uint64(user.ID), 10)
+	userToken, err := svc.Issue(ctx, subject, []string{"user"}, nil)
+	if err != nil {
+		t.Fatalf("Issue() error = %v", err)
+	}
+	inactive := model.UserStatusInactive
+	if _, err := users.UpdateUser(ctx, user.ID, &model.UpdateUserRequest{Status: &inactive}, "admin"); err != nil {
+		t.Fatalf("UpdateUser() error = %v", err)
+	}
+	_, err = svc.Rotate(ctx, userToken.Value)
+	expectUnauthorized(t, err, "inactive user")
+}
@@ -2,11 +2,13 @@ package tests
 
 import (
 	"context"
+	"github.com/RashadTanjim/enterprise-microservice-system/common/cache"
 	"github.com/RashadTanjim/enterprise-microservice-system/common/errors"
 	"enterprise-microservice-system/services/user-service/internal/model"
 	"enterprise-microservice-system/services/user-service/internal/repository"
 	"enterprise-microservice-system/services/user-service/internal/service"
 	"testing"
+	"time"
 
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -260,6 +262,54 @@ func TestListUsers(t *testing.T) {
 	}
 }
 
+func TestUserCacheInvalidatedOnWrite(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewUserRepository(db)
+	userCache, err := cache.NewMemoryCache(cache.Config{DefaultTTL: time.Minute})
+	if err != nil {
+		t.Fatalf("NewMemoryCache: %v", err)
+	}
+	svc := service.NewUserService(repo, userCache)
+
+	ctx := context.Background()
+
+	user, err := svc.CreateUser(ctx, &model.CreateUserRequest{Email: testEmail(1), Name: testName(1), Age: 21}, "tester")
+	if err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+	if _, err := svc.GetUser(ctx, user.ID); err != nil {
+		t.Fatalf("Failed to get user: %v", err)
+	}
+	if _, total, err := svc.ListUsers(ctx, &model.ListUsersQuery{Page: 1, PageSize: 10}); err != nil || total != 1 {
+		t.Fatalf("Expected 1 user, got %d (%v)", total, err)
+	}
+
+	// Writes that bypass the service are not seen until the entry is invalidated
+	if err := db.Model(&model.User{}).Where("id = ?", user.ID).Update("name", "Changed Directly").Error; err != nil {
+		t.Fatalf("Failed to update user: %v", err)
+	}
+	cached, err := svc.GetUser(ctx, user.ID)
+	if err != nil || cached.Name != testName(1) {
+		t.Fatalf("Expected cached name %q, got %+v (%v)", testName(1), cached, err)
+	}
+
+	newName := "Renamed"
+	if _, err := svc.UpdateUser(ctx, user.ID, &model.UpdateUserRequest{Name: &newName}, "tester"); err != nil {
+		t.Fatalf("Failed to update user: %v", err)
+	}
+	updated, err := svc.GetUser(ctx, user.ID)
+	if err != nil || updated.Name != newName {
+		t.Fatalf("Expected name %q after update, got %+v (%v)", newName, updated, err)
+	}
+
+	if _, err := svc.CreateUser(ctx, &model.CreateUserRequest{Email: testEmail(2), Name: testName(2), Age: 22}, "tester"); err != nil {
+		t.Fatalf("Failed to create test user: %v", err)
+	}
+	if _, total, err := svc.ListUsers(ctx, &model.ListUsersQuery{Page: 1, PageSize: 10}); err != nil || total != 2 {
+		t.Errorf("Expected list to reflect the new user, got total %d (%v)", total, err)
+	}
+}
+
 func testEmail(i int) string {
 	return "user" + string(rune('0'+i)) + "@example.com"
 }